@@ -0,0 +1,108 @@
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// LabelGeneratedFrom is added to every object generated by a HierarchicalObjectGenerator, and
+	// its value is the namespace of the generator. It's used to find the generated objects again
+	// (e.g. to clean them up) via a label selector.
+	LabelGeneratedFrom = MetaGroup + "/generated-from"
+
+	// AnnotationGenerator is added to every object generated by a HierarchicalObjectGenerator, and
+	// its value is "<namespace>/<name>" of the generator.
+	AnnotationGenerator = MetaGroup + "/generator"
+
+	// FinalizerGeneratedObjects is added to HierarchicalObjectGenerators so that HNC can delete the
+	// objects they've generated before the generators themselves are deleted.
+	FinalizerGeneratedObjects = MetaGroup + "/generatedObjects"
+
+	// ConditionGenerationFailed is set on a HierarchicalObjectGenerator when its template couldn't
+	// be rendered or written in at least one namespace. The message includes the details.
+	ConditionGenerationFailed string = "GenerationFailed"
+
+	// Reasons for ConditionGenerationFailed.
+	ReasonInvalidTemplate string = "InvalidTemplate"
+	ReasonObjectConflict  string = "ObjectConflict"
+	ReasonCannotWrite     string = "CannotWrite"
+
+	// EventCannotGenerateObject is for events when a HierarchicalObjectGenerator cannot generate
+	// its object in one of its selected namespaces.
+	EventCannotGenerateObject string = "CannotGenerateObject"
+)
+
+// HierarchicalObjectGeneratorSpec defines the object to generate and the namespaces in which to
+// generate it.
+type HierarchicalObjectGeneratorSpec struct {
+	// Template is the object to generate in every selected namespace. It must include the
+	// apiVersion, kind and metadata.name of the object; metadata.namespace is ignored. Every string
+	// in the template, including map keys, may use Go template syntax to refer to the namespace the
+	// object is generated in (e.g. `{{ .Namespace }}`); see the user guide for details.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:EmbeddedResource
+	Template runtime.RawExtension `json:"template"`
+
+	// Selector restricts the descendant namespaces in which the object is generated, based on their
+	// labels (including the tree labels added by HNC). If it's unset, the object is generated in all
+	// descendants.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// IncludeSelf causes the object to also be generated in the namespace of the generator, if that
+	// namespace is selected.
+	// +optional
+	IncludeSelf bool `json:"includeSelf,omitempty"`
+}
+
+// HierarchicalObjectGeneratorStatus describes the objects that are currently generated.
+type HierarchicalObjectGeneratorStatus struct {
+	// NumGeneratedObjects is the number of namespaces in which the object is currently generated.
+	// +optional
+	NumGeneratedObjects int `json:"numGeneratedObjects,omitempty"`
+
+	// GeneratedAPIVersion and GeneratedKind record the type of the generated objects, so that they
+	// can be cleaned up if the type in the template changes.
+	// +optional
+	GeneratedAPIVersion string `json:"generatedAPIVersion,omitempty"`
+	// +optional
+	GeneratedKind string `json:"generatedKind,omitempty"`
+
+	// Conditions describes any problems that prevented the object from being generated.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=hierarchicalobjectgenerators,shortName=hog,scope=Namespaced
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Kind",type="string",JSONPath=".spec.template.kind"
+// +kubebuilder:printcolumn:name="Generated",type="integer",JSONPath=".status.numGeneratedObjects"
+
+// HierarchicalObjectGenerator renders a templated object into a namespace and its descendants.
+type HierarchicalObjectGenerator struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines the object to generate
+	Spec HierarchicalObjectGeneratorSpec `json:"spec,omitempty"`
+	// Status describes the generated objects
+	Status HierarchicalObjectGeneratorStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// HierarchicalObjectGeneratorList contains a list of HierarchicalObjectGenerator
+type HierarchicalObjectGeneratorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HierarchicalObjectGenerator `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HierarchicalObjectGenerator{}, &HierarchicalObjectGeneratorList{})
+}
@@ -16,6 +16,9 @@ limitations under the License.
 package v1alpha2
 
 import (
+	"regexp"
+	"strings"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -39,6 +42,22 @@ const (
 	AnnotationNoneSelector = AnnotationPropagatePrefix + "/none"
 	AnnotationAllSelector  = AnnotationPropagatePrefix + "/all"
 
+	// AnnotationPaused, when set to "true" on a source object, stops HNC from creating, updating or
+	// deleting any of its propagated copies until it's removed or set to "false".
+	AnnotationPaused = AnnotationPropagatePrefix + "/paused"
+
+	// AnnotationRollout opts a source object into progressive rollouts: each new version of the
+	// object is propagated in stages instead of to all descendants at once. Its value is either
+	// "levels" (one level of the tree per stage) or "canary=<namespace>" (the subtree rooted at the
+	// namespace first, and then all other descendants).
+	AnnotationRollout = AnnotationPropagatePrefix + "/rollout"
+	// AnnotationRolloutInterval is the minimum time to wait between rollout stages, as a duration
+	// such as "10m". The default is five minutes.
+	AnnotationRolloutInterval = AnnotationPropagatePrefix + "/rolloutInterval"
+	// AnnotationRolloutStatus is set by HNC on source objects with AnnotationRollout to show the
+	// progress of the rollout of the current version of the object. Its value is a JSON object.
+	AnnotationRolloutStatus = MetaGroup + "/rollout-status"
+
 	// LabelManagedByStandard will eventually replace our own managed-by annotation (we didn't know
 	// about this standard label when we invented our own).
 	LabelManagedByApps = "app.kubernetes.io/managed-by"
@@ -46,6 +65,25 @@ const (
 	// LabelIncludedNamespace is the label added by HNC on the namespaces that
 	// should be enforced by our validators.
 	LabelIncludedNamespace = MetaGroup + "/included-namespace"
+
+	// AnnotationParent can be set on a namespace when it's created to insert it into the hierarchy
+	// as a child of the namespace named by its value. It's only read when HNC first creates the
+	// HierarchyConfiguration of the namespace; after that, use HierarchyConfigurationSpec.Parent.
+	AnnotationParent = MetaGroup + "/parent"
+
+	// AnnotationDefaultParentRule is set by HNC along with AnnotationParent when a new namespace is
+	// assigned a parent by one of the default parent rules in the HNCConfiguration. Its value is the
+	// name of the rule.
+	AnnotationDefaultParentRule = MetaGroup + "/default-parent-rule"
+
+	// LabelNetworkIsolation is added to the NetworkPolicies that HNC creates to implement
+	// HierarchyConfigurationSpec.NetworkIsolation, so that they can be found by a selector.
+	LabelNetworkIsolation = MetaGroup + "/network-isolation"
+
+	// AnnotationRequestedBy is set by HNC on a HierarchyConfiguration to the name of the user who last
+	// changed its parent, so that it can be included in the events that HNC records for the change.
+	// It's removed when HNC itself sets the parent (e.g. for subnamespaces).
+	AnnotationRequestedBy = MetaGroup + "/requested-by"
 )
 
 const (
@@ -55,6 +93,7 @@ const (
 
 	// Condition reasons. Please keep this list in alphabetical order. IF ADDING ANYTHING HERE, PLEASE
 	// ALSO ADD THEM TO AllConditions, BELOW.
+	ReasonAdminHalted              string = "AdminHalted"
 	ReasonAncestor                 string = "AncestorHaltActivities"
 	ReasonAnchorMissing            string = "SubnamespaceAnchorMissing"
 	ReasonDeletingCRD              string = "DeletingCRD"
@@ -63,6 +102,7 @@ const (
 	ReasonIllegalParent            string = "IllegalParent"
 	ReasonInCycle                  string = "InCycle"
 	ReasonParentMissing            string = "ParentMissing"
+	ReasonPendingDeletion          string = "PendingDeletion"
 )
 
 // AllConditions have all the conditions by type and reason. Please keep this
@@ -70,6 +110,7 @@ const (
 // conditions in the metrics.
 var AllConditions = map[string][]string{
 	ConditionActivitiesHalted: {
+		ReasonAdminHalted,
 		ReasonAncestor,
 		ReasonDeletingCRD,
 		ReasonIllegalManagedAnnotation,
@@ -77,6 +118,7 @@ var AllConditions = map[string][]string{
 		ReasonIllegalParent,
 		ReasonInCycle,
 		ReasonParentMissing,
+		ReasonPendingDeletion,
 	},
 	ConditionBadConfiguration: {
 		ReasonAnchorMissing,
@@ -97,9 +139,41 @@ const (
 	// the first place, or it couldn't be updated. The error message will point to
 	// the source namespace.
 	EventCannotUpdate string = "CannotUpdateObject"
+	// EventRecreatedObject is for events when a propagated object in this namespace was deleted and
+	// created again, because it couldn't be updated without changing its immutable fields. See
+	// UpdateStrategyRecreate.
+	EventRecreatedObject string = "RecreatedObject"
+	// EventPropagationPaused is for events when an object is a propagated copy (or the source of
+	// propagated copies) that's out of date because propagation of its source has been paused with
+	// AnnotationPaused.
+	EventPropagationPaused string = "PropagationPaused"
+	// EventRolloutStageStarted is for events when the rollout of a new version of a source object
+	// (see AnnotationRollout) has started a new stage.
+	EventRolloutStageStarted string = "RolloutStageStarted"
+	// EventDriftDetected is for events when an object of a type in the Audit mode differs from what
+	// HNC would propagate - that is, if the type were in the Propagate mode, HNC would create, update
+	// or delete it.
+	EventDriftDetected string = "DriftDetected"
 	// EventCannotGetSelector is for events when an object has annotations that cannot be
 	// parsed into a valid selector
 	EventCannotParseSelector string = "CannotParseSelector"
+
+	// EventParentChanged is for events on a HierarchyConfiguration when the parent of its namespace
+	// is set or changed.
+	EventParentChanged string = "ParentChanged"
+	// EventChildAdded is for events on a HierarchyConfiguration when its namespace gains a child,
+	// either because a new namespace (such as a subnamespace) was created under it, or because an
+	// existing namespace was moved under it.
+	EventChildAdded string = "ChildAdded"
+	// EventChildRemoved is for events on a HierarchyConfiguration when its namespace loses a child,
+	// either because the child was deleted, or because it was moved somewhere else.
+	EventChildRemoved string = "ChildRemoved"
+	// EventConditionAdded is for events on a HierarchyConfiguration when its namespace gains a
+	// condition.
+	EventConditionAdded string = "ConditionAdded"
+	// EventConditionResolved is for events on a HierarchyConfiguration when a condition of its
+	// namespace is resolved.
+	EventConditionResolved string = "ConditionResolved"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -129,6 +203,13 @@ type HierarchyConfigurationSpec struct {
 	// allowed to cascading delete.
 	AllowCascadingDeletion bool `json:"allowCascadingDeletion,omitempty"`
 
+	// Halted lets an administrator halt all activities in this namespace and its descendants, e.g.
+	// during a risky migration. While it's set, HNC doesn't propagate any objects into or out of
+	// this subtree, and doesn't allow its structure to be changed. The namespace gets the
+	// ActivitiesHalted condition with the AdminHalted reason.
+	// +optional
+	Halted bool `json:"halted,omitempty"`
+
 	// Lables is a list of labels and values to apply to the current namespace and all of its
 	// descendants. All label keys must match a regex specified on the command line by
 	// --managed-namespace-label. A namespace cannot have a KVP that conflicts with one of its
@@ -140,6 +221,99 @@ type HierarchyConfigurationSpec struct {
 	// --managed-namespace-annotation. A namespace cannot have a KVP that conflicts with one of its
 	// ancestors.
 	Annotations []MetaKVP `json:"annotations,omitempty"`
+
+	// NetworkIsolation causes HNC to create a NetworkPolicy in this namespace and all of its
+	// descendants that only allows ingress from the namespaces in this subtree ("Subtree") or from
+	// the same namespace ("Namespace"), or doesn't restrict ingress ("None"). It can't be looser than
+	// the setting inherited from the ancestors; if it is, the strictest setting of the ancestors is
+	// used instead. If it's unset, the setting of the nearest ancestor that sets it is used.
+	// +optional
+	NetworkIsolation NetworkIsolationMode `json:"networkIsolation,omitempty"`
+
+	// NetworkIsolationExceptions selects namespaces that are always allowed ingress, in addition to
+	// the ones allowed by NetworkIsolation. It's inherited along with NetworkIsolation, and is ignored
+	// if NetworkIsolation isn't set. Descendants can only keep the exceptions of their isolated
+	// ancestors, not add new ones; only the exceptions set on every isolated ancestor apply.
+	// +optional
+	NetworkIsolationExceptions []metav1.LabelSelector `json:"networkIsolationExceptions,omitempty"`
+
+	// SubnamespaceNaming restricts the names of new subnamespaces created anywhere in this namespace's
+	// subtree. New subnamespaces must also satisfy the policies set by any of the ancestors.
+	// +optional
+	SubnamespaceNaming *SubnamespaceNamingPolicy `json:"subnamespaceNaming,omitempty"`
+}
+
+// SubnamespaceNamingPolicy restricts the names of new subnamespaces. Both of its fields are
+// templates that may refer to "{{parent}}", the name of the parent of the new subnamespace, and to
+// "{{root}}", the name of the root of its tree.
+type SubnamespaceNamingPolicy struct {
+	// Prefix, if set, must be a prefix of the name of every new subnamespace, e.g. "{{parent}}-".
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// NameRegex, if set, is a regex that must match the entire name of every new subnamespace (it's
+	// implicitly wrapped by "^...$"). The values of the template variables are quoted, so they only
+	// match themselves.
+	// +optional
+	NameRegex string `json:"nameRegex,omitempty"`
+}
+
+// Template variables for SubnamespaceNamingPolicy.
+const (
+	NamingParentVar = "{{parent}}"
+	NamingRootVar   = "{{root}}"
+)
+
+// RequiredPrefix returns the prefix that the names of new subnamespaces of the given parent must
+// have, if any.
+func (p *SubnamespaceNamingPolicy) RequiredPrefix(parent, root string) string {
+	if p == nil {
+		return ""
+	}
+	return strings.NewReplacer(NamingParentVar, parent, NamingRootVar, root).Replace(p.Prefix)
+}
+
+// CompileNameRegex returns the regex that the names of new subnamespaces of the given parent must
+// match, or nil if there isn't one.
+func (p *SubnamespaceNamingPolicy) CompileNameRegex(parent, root string) (*regexp.Regexp, error) {
+	if p == nil || p.NameRegex == "" {
+		return nil, nil
+	}
+	re := strings.NewReplacer(NamingParentVar, regexp.QuoteMeta(parent), NamingRootVar, regexp.QuoteMeta(root)).Replace(p.NameRegex)
+	return regexp.Compile("^(?:" + re + ")$")
+}
+
+// NetworkIsolationMode describes which namespaces are allowed ingress into a namespace.
+// +kubebuilder:validation:Enum=Subtree;Namespace;None
+type NetworkIsolationMode string
+
+const (
+	// NetworkIsolationSubtree only allows ingress from the namespaces in the subtree of the
+	// namespace where it's set.
+	NetworkIsolationSubtree NetworkIsolationMode = "Subtree"
+
+	// NetworkIsolationNamespace only allows ingress from within each namespace.
+	NetworkIsolationNamespace NetworkIsolationMode = "Namespace"
+
+	// NetworkIsolationNone doesn't restrict ingress.
+	NetworkIsolationNone NetworkIsolationMode = "None"
+)
+
+// IsLooserThan returns true if this mode allows ingress from more namespaces than the other one,
+// assuming that this one is set on the same namespace as the other one, or on one of its
+// descendants. An empty mode is treated as None.
+func (m NetworkIsolationMode) IsLooserThan(other NetworkIsolationMode) bool {
+	return m.strictness() < other.strictness()
+}
+
+func (m NetworkIsolationMode) strictness() int {
+	switch m {
+	case NetworkIsolationNamespace:
+		return 2
+	case NetworkIsolationSubtree:
+		return 1
+	}
+	return 0
 }
 
 // HierarchyStatus defines the observed state of Hierarchy
@@ -0,0 +1,61 @@
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	HierarchyHistories = "hierarchyhistories"
+
+	// DefaultHierarchyHistoryLimit is the default maximum number of entries kept in each
+	// HierarchyHistory. Once the limit is reached, the oldest entries are discarded.
+	DefaultHierarchyHistoryLimit = 100
+)
+
+// HierarchyHistoryEntry records a single change to the hierarchy, as seen from one namespace.
+type HierarchyHistoryEntry struct {
+	// Time is when HNC observed the change.
+	Time metav1.Time `json:"time"`
+
+	// Reason is a short, machine-understandable description of the change, and is the same as the
+	// reason of the Event recorded for it (e.g. ParentChanged, ChildAdded or ConditionAdded).
+	Reason string `json:"reason"`
+
+	// Message is a human-readable description of the change, including the user who requested it
+	// if it's known.
+	Message string `json:"message"`
+
+	// Parent is the parent of the namespace once the change was made, or empty if it was a root. It
+	// can be used to reconstruct the hierarchy at any point in the past.
+	// +optional
+	Parent string `json:"parent,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=hierarchyhistories,shortName=hh,scope=Namespaced
+// +kubebuilder:storageversion
+
+// HierarchyHistory is a bounded log of the changes to the hierarchy that affected its namespace,
+// such as changes to its parent, subnamespaces being created or deleted, and conditions being added
+// or resolved. It's written by HNC only, and there's at most one per namespace, named "hierarchy".
+type HierarchyHistory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Entries are the recorded changes, oldest first.
+	// +optional
+	Entries []HierarchyHistoryEntry `json:"entries,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// HierarchyHistoryList contains a list of HierarchyHistory
+type HierarchyHistoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HierarchyHistory `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HierarchyHistory{}, &HierarchyHistoryList{})
+}
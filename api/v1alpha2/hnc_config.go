@@ -31,7 +31,8 @@ const (
 )
 
 // SynchronizationMode describes propagation mode of objects of the same kind.
-// The only four modes currently supported are "Propagate", "AllowPropagate", "Ignore", and "Remove".
+// The only five modes currently supported are "Propagate", "AllowPropagate", "Ignore", "Remove" and
+// "Audit".
 // See detailed definition below. An unsupported mode will be treated as "ignore".
 type SynchronizationMode string
 
@@ -50,6 +51,34 @@ const (
 	// AllowPropagate allows propagation of objects from ancestors to descendants
 	// and deletes obsolete descendants only if a an annotation is set on the object
 	AllowPropagate SynchronizationMode = "AllowPropagate"
+
+	// Audit determines what the Propagate mode would do, but never creates, updates or deletes any
+	// objects. Instead, any differences between the objects in the descendants and their sources are
+	// reported via events, metrics and the HNCConfiguration status. Since HNC doesn't own the
+	// propagated copies in this mode, users can modify them freely.
+	Audit SynchronizationMode = "Audit"
+)
+
+// UpdateStrategy describes what HNC does when it can't update a propagated object because the update
+// would change one of the object's immutable fields, such as the data in a Secret that's marked as
+// immutable. It has no effect on any other kind of update, which always succeed or are retried.
+type UpdateStrategy string
+
+const (
+	// UpdateStrategyUpdate keeps retrying the update with exponential backoff, generating events each
+	// time it fails. This is the default for all types except RoleBindings, and is mainly useful if
+	// something else is expected to fix the copy or the source.
+	UpdateStrategyUpdate UpdateStrategy = "Update"
+
+	// UpdateStrategyRecreate deletes the propagated object and creates it again from the source. Note
+	// that this can have side effects if other objects refer to the propagated object, such as
+	// through owner references. This is the default for RoleBindings, since their roleRefs are
+	// immutable.
+	UpdateStrategyRecreate UpdateStrategy = "Recreate"
+
+	// UpdateStrategyFail leaves the propagated object alone and generates an event, but doesn't retry
+	// the update until the object or its source changes again.
+	UpdateStrategyFail UpdateStrategy = "Fail"
 )
 
 const (
@@ -71,11 +100,13 @@ const (
 )
 
 // EnforcedTypes are the types enforced by HNC that they should not show up in
-// the spec and only in the status. Any configurations of the enforced types in
-// the spec would cause 'MultipleConfigurationsForType' condition.
+// the spec and only in the status. The only thing that can be configured for
+// them in the spec is their update strategy; any other configurations of the
+// enforced types in the spec would cause 'MultipleConfigurationsForType'
+// condition.
 var EnforcedTypes = []ResourceSpec{
 	{Group: RBACGroup, Resource: RoleResource, Mode: Propagate},
-	{Group: RBACGroup, Resource: RoleBindingResource, Mode: Propagate},
+	{Group: RBACGroup, Resource: RoleBindingResource, Mode: Propagate, UpdateStrategy: UpdateStrategyRecreate},
 }
 
 // IsEnforcedType returns true if configuration is on an enforced type.
@@ -98,8 +129,23 @@ type ResourceSpec struct {
 	// Synchronization mode of the kind. If the field is empty, it will be treated
 	// as "Propagate".
 	// +optional
-	// +kubebuilder:validation:Enum=Propagate;Ignore;Remove;AllowPropagate
+	// +kubebuilder:validation:Enum=Propagate;Ignore;Remove;AllowPropagate;Audit
 	Mode SynchronizationMode `json:"mode,omitempty"`
+	// What to do if a propagated object can't be updated because the update would change an
+	// immutable field. If the field is empty, it will be treated as "Update", except for
+	// RoleBindings, which use "Recreate" by default. See the UpdateStrategy type for details. This is
+	// the only field that can be set for the types whose mode is enforced by HNC (Roles and
+	// RoleBindings).
+	// +optional
+	// +kubebuilder:validation:Enum=Update;Recreate;Fail
+	UpdateStrategy UpdateStrategy `json:"updateStrategy,omitempty"`
+	// Paths of fields that should not be copied to propagated objects, in addition to any that HNC
+	// never propagates for builtin types (such as the cluster IPs of Services). This is useful for
+	// fields that are set separately for each object, such as by another controller. Each path is a
+	// dot-separated list of field names, such as "spec.volumeName"; if any field along the path is a
+	// list, the rest of the path applies to every element in the list.
+	// +optional
+	UnpropagatedFields []string `json:"unpropagatedFields,omitempty"`
 }
 
 // ResourceStatus defines the actual synchronization state of a specific resource.
@@ -128,6 +174,19 @@ type ResourceStatus struct {
 	// +kubebuilder:validation:Minimum=0
 	// +optional
 	NumSourceObjects *int `json:"numSourceObjects,omitempty"`
+
+	// Tracks the number of objects that differ from what HNC would propagate, including missing
+	// objects and objects that would be deleted. Only set in the Audit mode.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	NumDriftedObjects *int `json:"numDriftedObjects,omitempty"`
+
+	// Tracks the number of objects that are out of date because propagation from their sources is
+	// paused (see the propagate.hnc.x-k8s.io/paused annotation). Only set in the modes that propagate
+	// objects.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	NumStaleObjects *int `json:"numStaleObjects,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -151,6 +210,52 @@ type HNCConfigurationSpec struct {
 	// or 'rolebindings' are not allowed. To learn more, see
 	// https://github.com/kubernetes-sigs/hierarchical-namespaces/blob/master/docs/user-guide/how-to.md#admin-types
 	Resources []ResourceSpec `json:"resources,omitempty"`
+
+	// RootPolicy controls who may create root namespaces, i.e. namespaces without a parent. If
+	// omitted, anyone who can create or modify a namespace can make it a root.
+	RootPolicy *RootPolicy `json:"rootPolicy,omitempty"`
+
+	// DefaultParents are rules that automatically assign a parent to new full namespaces (i.e. not
+	// subnamespaces) that are created without one, such as namespaces created by external tools. The
+	// rules are evaluated in order, and the first one that matches the new namespace is used. The
+	// user creating the namespace must still have permission to set the parent.
+	DefaultParents []DefaultParentRule `json:"defaultParents,omitempty"`
+}
+
+// DefaultParentRule assigns a parent to new namespaces whose name and labels match the rule.
+type DefaultParentRule struct {
+	// Name identifies the rule. It's recorded in the hnc.x-k8s.io/default-parent-rule annotation of
+	// every namespace that's assigned a parent by the rule.
+	Name string `json:"name"`
+
+	// NameRegex, if set, is a regex that must match the entire name of the namespace (it's implicitly
+	// wrapped by "^...$").
+	NameRegex string `json:"nameRegex,omitempty"`
+
+	// Selector, if set, must match the labels of the namespace.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Parent is the name of the parent to assign. It may refer to the capture groups of NameRegex,
+	// such as "$1" or "${team}".
+	Parent string `json:"parent"`
+}
+
+// RootPolicy controls who may create root namespaces.
+type RootPolicy struct {
+	// RequireParent, if true, forbids new namespaces from being created without a parent, and existing
+	// namespaces from being made into roots, except by the users and groups listed below. New
+	// namespaces can be given a parent either by creating them as subnamespaces, or by setting the
+	// hnc.x-k8s.io/parent annotation when they're created. Namespaces that are excluded from HNC are
+	// never affected.
+	RequireParent bool `json:"requireParent,omitempty"`
+
+	// AllowedUsers are the names of the users who may still create root namespaces when
+	// RequireParent is true.
+	AllowedUsers []string `json:"allowedUsers,omitempty"`
+
+	// AllowedGroups are the names of the groups whose members may still create root namespaces when
+	// RequireParent is true.
+	AllowedGroups []string `json:"allowedGroups,omitempty"`
 }
 
 // HNCConfigurationStatus defines the observed state of HNC configuration.
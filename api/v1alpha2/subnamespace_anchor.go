@@ -23,6 +23,31 @@ import (
 const (
 	Anchors        = "subnamespaceanchors"
 	SubnamespaceOf = MetaGroup + "/subnamespace-of"
+
+	// AnnotationMoveFrom can be set on a new anchor to move the existing subnamespace with the same
+	// name from the namespace in the annotation's value into the anchor's namespace. HNC removes the
+	// annotation once it has processed the anchor.
+	AnnotationMoveFrom = MetaGroup + "/move-from"
+
+	// AnnotationAdopt can be set to "true" on a new anchor to adopt the existing full namespace with
+	// the same name as a subnamespace of the anchor's namespace. HNC removes the annotation once it
+	// has processed the anchor.
+	AnnotationAdopt = MetaGroup + "/adopt"
+
+	// AnnotationRelease can be set to "true" on an anchor to release its subnamespace, converting it
+	// into a full namespace with the same parent. HNC then deletes the anchor without deleting the
+	// namespace.
+	AnnotationRelease = MetaGroup + "/release"
+
+	// AnnotationDeleteAfter is set by HNC on a subnamespace whose anchor has been deleted while a
+	// deletion grace period is configured (see --subnamespace-deletion-grace-period). Its value is the
+	// time, in RFC 3339 format, after which the subnamespace will be deleted unless its anchor is
+	// recreated first.
+	AnnotationDeleteAfter = MetaGroup + "/delete-after"
+
+	// PendingDeletionQuota is the name of the ResourceQuota that HNC creates in subnamespaces that
+	// are pending deletion, to prevent any new pods from being created in them.
+	PendingDeletionQuota = "pending-deletion." + MetaGroup
 )
 
 // SubnamespaceAnchorState describes the state of the subnamespace. The state could be
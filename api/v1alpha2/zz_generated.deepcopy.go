@@ -21,11 +21,31 @@ limitations under the License.
 package v1alpha2
 
 import (
-	"k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultParentRule) DeepCopyInto(out *DefaultParentRule) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultParentRule.
+func (in *DefaultParentRule) DeepCopy() *DefaultParentRule {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultParentRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HNCConfiguration) DeepCopyInto(out *HNCConfiguration) {
 	*out = *in
@@ -91,7 +111,21 @@ func (in *HNCConfigurationSpec) DeepCopyInto(out *HNCConfigurationSpec) {
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
 		*out = make([]ResourceSpec, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RootPolicy != nil {
+		in, out := &in.RootPolicy, &out.RootPolicy
+		*out = new(RootPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DefaultParents != nil {
+		in, out := &in.DefaultParents, &out.DefaultParents
+		*out = make([]DefaultParentRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -117,7 +151,7 @@ func (in *HNCConfigurationStatus) DeepCopyInto(out *HNCConfigurationStatus) {
 	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]metav1.Condition, len(*in))
+		*out = make([]v1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -134,6 +168,108 @@ func (in *HNCConfigurationStatus) DeepCopy() *HNCConfigurationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HierarchicalObjectGenerator) DeepCopyInto(out *HierarchicalObjectGenerator) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HierarchicalObjectGenerator.
+func (in *HierarchicalObjectGenerator) DeepCopy() *HierarchicalObjectGenerator {
+	if in == nil {
+		return nil
+	}
+	out := new(HierarchicalObjectGenerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HierarchicalObjectGenerator) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HierarchicalObjectGeneratorList) DeepCopyInto(out *HierarchicalObjectGeneratorList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HierarchicalObjectGenerator, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HierarchicalObjectGeneratorList.
+func (in *HierarchicalObjectGeneratorList) DeepCopy() *HierarchicalObjectGeneratorList {
+	if in == nil {
+		return nil
+	}
+	out := new(HierarchicalObjectGeneratorList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HierarchicalObjectGeneratorList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HierarchicalObjectGeneratorSpec) DeepCopyInto(out *HierarchicalObjectGeneratorSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HierarchicalObjectGeneratorSpec.
+func (in *HierarchicalObjectGeneratorSpec) DeepCopy() *HierarchicalObjectGeneratorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HierarchicalObjectGeneratorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HierarchicalObjectGeneratorStatus) DeepCopyInto(out *HierarchicalObjectGeneratorStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HierarchicalObjectGeneratorStatus.
+func (in *HierarchicalObjectGeneratorStatus) DeepCopy() *HierarchicalObjectGeneratorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HierarchicalObjectGeneratorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HierarchicalResourceQuota) DeepCopyInto(out *HierarchicalResourceQuota) {
 	*out = *in
@@ -198,7 +334,7 @@ func (in *HierarchicalResourceQuotaSpec) DeepCopyInto(out *HierarchicalResourceQ
 	*out = *in
 	if in.Hard != nil {
 		in, out := &in.Hard, &out.Hard
-		*out = make(v1.ResourceList, len(*in))
+		*out = make(corev1.ResourceList, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val.DeepCopy()
 		}
@@ -220,14 +356,14 @@ func (in *HierarchicalResourceQuotaStatus) DeepCopyInto(out *HierarchicalResourc
 	*out = *in
 	if in.Hard != nil {
 		in, out := &in.Hard, &out.Hard
-		*out = make(v1.ResourceList, len(*in))
+		*out = make(corev1.ResourceList, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val.DeepCopy()
 		}
 	}
 	if in.Used != nil {
 		in, out := &in.Used, &out.Used
-		*out = make(v1.ResourceList, len(*in))
+		*out = make(corev1.ResourceList, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val.DeepCopy()
 		}
@@ -316,6 +452,18 @@ func (in *HierarchyConfigurationSpec) DeepCopyInto(out *HierarchyConfigurationSp
 		*out = make([]MetaKVP, len(*in))
 		copy(*out, *in)
 	}
+	if in.NetworkIsolationExceptions != nil {
+		in, out := &in.NetworkIsolationExceptions, &out.NetworkIsolationExceptions
+		*out = make([]v1.LabelSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SubnamespaceNaming != nil {
+		in, out := &in.SubnamespaceNaming, &out.SubnamespaceNaming
+		*out = new(SubnamespaceNamingPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HierarchyConfigurationSpec.
@@ -338,7 +486,7 @@ func (in *HierarchyConfigurationStatus) DeepCopyInto(out *HierarchyConfiguration
 	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]metav1.Condition, len(*in))
+		*out = make([]v1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -355,6 +503,86 @@ func (in *HierarchyConfigurationStatus) DeepCopy() *HierarchyConfigurationStatus
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HierarchyHistory) DeepCopyInto(out *HierarchyHistory) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]HierarchyHistoryEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HierarchyHistory.
+func (in *HierarchyHistory) DeepCopy() *HierarchyHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(HierarchyHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HierarchyHistory) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HierarchyHistoryEntry) DeepCopyInto(out *HierarchyHistoryEntry) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HierarchyHistoryEntry.
+func (in *HierarchyHistoryEntry) DeepCopy() *HierarchyHistoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(HierarchyHistoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HierarchyHistoryList) DeepCopyInto(out *HierarchyHistoryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HierarchyHistory, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HierarchyHistoryList.
+func (in *HierarchyHistoryList) DeepCopy() *HierarchyHistoryList {
+	if in == nil {
+		return nil
+	}
+	out := new(HierarchyHistoryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HierarchyHistoryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetaKVP) DeepCopyInto(out *MetaKVP) {
 	*out = *in
@@ -373,6 +601,11 @@ func (in *MetaKVP) DeepCopy() *MetaKVP {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceSpec) DeepCopyInto(out *ResourceSpec) {
 	*out = *in
+	if in.UnpropagatedFields != nil {
+		in, out := &in.UnpropagatedFields, &out.UnpropagatedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceSpec.
@@ -398,6 +631,16 @@ func (in *ResourceStatus) DeepCopyInto(out *ResourceStatus) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.NumDriftedObjects != nil {
+		in, out := &in.NumDriftedObjects, &out.NumDriftedObjects
+		*out = new(int)
+		**out = **in
+	}
+	if in.NumStaleObjects != nil {
+		in, out := &in.NumStaleObjects, &out.NumStaleObjects
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceStatus.
@@ -410,6 +653,31 @@ func (in *ResourceStatus) DeepCopy() *ResourceStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RootPolicy) DeepCopyInto(out *RootPolicy) {
+	*out = *in
+	if in.AllowedUsers != nil {
+		in, out := &in.AllowedUsers, &out.AllowedUsers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedGroups != nil {
+		in, out := &in.AllowedGroups, &out.AllowedGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RootPolicy.
+func (in *RootPolicy) DeepCopy() *RootPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RootPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SubnamespaceAnchor) DeepCopyInto(out *SubnamespaceAnchor) {
 	*out = *in
@@ -508,3 +776,18 @@ func (in *SubnamespaceAnchorStatus) DeepCopy() *SubnamespaceAnchorStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubnamespaceNamingPolicy) DeepCopyInto(out *SubnamespaceNamingPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubnamespaceNamingPolicy.
+func (in *SubnamespaceNamingPolicy) DeepCopy() *SubnamespaceNamingPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(SubnamespaceNamingPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -74,12 +74,16 @@ var (
 	excludedNamespaces      arrayArg
 	managedNamespaceLabels  arrayArg
 	managedNamespaceAnnots  arrayArg
+	monotonicNamespaceLbls  arrayArg
 	nopropagationLabel      arrayArg
 	includedNamespacesRegex string
 	webhooksOnly            bool
 	enableHRQ               bool
+	cacheMetadataOnly       bool
 	hncNamespace            string
 	hrqSyncInterval         time.Duration
+	subnsDeletionGrace      time.Duration
+	hierarchyHistoryLimit   int
 )
 
 // init preloads some global vars before main() starts. Since this is the top-level module, I'm not
@@ -156,10 +160,14 @@ func parseFlags() {
 	flag.BoolVar(&restartOnSecretRefresh, "cert-restart-on-secret-refresh", false, "Kills the process when secrets are refreshed so that the pod can be restarted (secrets take up to 60s to be updated by running pods)")
 	flag.Var(&managedNamespaceLabels, "managed-namespace-label", "A regex indicating the labels on namespaces that are managed by HNC. These labels may only be set via the HierarchyConfiguration object. All regexes are implictly wrapped by \"^...$\". This argument can be specified multiple times. See the user guide for more information.")
 	flag.Var(&managedNamespaceAnnots, "managed-namespace-annotation", "A regex indicating the annotations on namespaces that are managed by HNC. These annotations may only be set via the HierarchyConfiguration object. All regexes are implictly wrapped by \"^...$\". This argument can be specified multiple times. See the user guide for more information.")
+	flag.Var(&monotonicNamespaceLbls, "monotonic-namespace-label", "A managed namespace label specified as key=value1,value2,... with its legal values ordered from the loosest to the strictest. Descendant namespaces may tighten the value inherited from their ancestors but never loosen it. This argument can be specified multiple times. See the user guide for more information.")
 	flag.BoolVar(&webhooksOnly, "webhooks-only", false, "Disables the controllers so HNC can be run in HA webhook mode")
 	flag.BoolVar(&enableHRQ, "enable-hrq", false, "Enables hierarchical resource quotas")
 	flag.StringVar(&hncNamespace, "namespace", "hnc-system", "Namespace where hnc-manager and hnc resources deployed")
 	flag.DurationVar(&hrqSyncInterval, "hrq-sync-interval", 1*time.Minute, "Frequency to double-check that all HRQ usages are up-to-date (shouldn't be needed)")
+	flag.DurationVar(&subnsDeletionGrace, "subnamespace-deletion-grace-period", 0, "If set, subnamespaces are kept for this long after their anchors are deleted, and can be restored during that time (e.g. via 'kubectl hns restore'). New pods are blocked in the meantime, but existing pods keep running until the subnamespaces are deleted. If zero, subnamespaces are deleted immediately. See the user guide for more information.")
+	flag.IntVar(&hierarchyHistoryLimit, "hierarchy-history-limit", v1a2.DefaultHierarchyHistoryLimit, "The maximum number of changes recorded in the HierarchyHistory of each namespace (see 'kubectl hns history'). If zero, no history is recorded.")
+	flag.BoolVar(&cacheMetadataOnly, "cache-object-metadata-only", false, "If true, only caches the metadata of propagated objects, reading full objects from the apiserver only when needed. This reduces memory usage at the cost of more API calls. See the user guide for more information.")
 	flag.Var(&nopropagationLabel, "nopropagation-label", "A label specified as key=val that, if present, will cause HNC to skip objects that match this label. May be specified multiple times, with each key=value pair specifying one label. See the user guide for more information.")
 	flag.Parse()
 
@@ -191,6 +199,10 @@ func parseFlags() {
 		setupLog.Error(err, "Illegal flag values")
 		os.Exit(1)
 	}
+	if err := config.SetMonotonicLabels(monotonicNamespaceLbls); err != nil {
+		setupLog.Error(err, "Illegal flag values")
+		os.Exit(1)
+	}
 
 	// Basic legality checks
 	if webhooksOnly && noWebhooks {
@@ -340,6 +352,10 @@ func startControllers(mgr ctrl.Manager, certsReady chan struct{}) {
 		MaxReconciles:   maxReconciles,
 		HRQ:             enableHRQ,
 		HRQSyncInterval: hrqSyncInterval,
+
+		CacheObjectMetadataOnly: cacheMetadataOnly,
+		DeletionGracePeriod:     subnsDeletionGrace,
+		HierarchyHistoryLimit:   hierarchyHistoryLimit,
 	}
 	setup.Create(setupLog, mgr, f, opts)
 
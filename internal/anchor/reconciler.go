@@ -19,10 +19,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -48,6 +50,11 @@ type Reconciler struct {
 
 	Forest *forest.Forest
 
+	// DeletionGracePeriod is how long a subnamespace is kept after its anchor is deleted, during which
+	// it can be restored by recreating the anchor. If it's zero, subnamespaces are deleted as soon as
+	// their anchors are.
+	DeletionGracePeriod time.Duration
+
 	// affected is a channel of event.GenericEvent (see "Watching Channels" in
 	// https://book-v1.book.kubebuilder.io/beyond_basics/controller_watches.html) that is used to
 	// enqueue additional objects that need updating.
@@ -71,7 +78,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			log.Info("Anchor has been deleted")
-			return ctrl.Result{}, nil
+			return r.syncPendingDeletion(ctx, log, pnm, nm)
 		}
 		return ctrl.Result{}, err
 	}
@@ -107,6 +114,21 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
+	// If the anchor is releasing its subnamespace, convert it into a full namespace and get rid of
+	// the anchor; nothing else applies.
+	if inst.Annotations[api.AnnotationRelease] == "true" {
+		return ctrl.Result{}, r.release(ctx, log, inst, snsInst)
+	}
+
+	// If this anchor was created to move an existing subnamespace from another parent, or to adopt a
+	// full namespace, take it over before checking the state.
+	if err := r.syncMove(ctx, log, inst, snsInst); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.syncAdopt(ctx, log, inst, snsInst); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Update the state so we know the relationship between the anchor and its namespace.
 	r.updateState(log, inst, snsInst)
 
@@ -119,6 +141,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	// after the subnamespace is deleted.
 	controllerutil.AddFinalizer(inst, api.MetaGroup)
 
+	// If the anchor has been recreated while its subnamespace was pending deletion, restore it.
+	if err := r.restore(ctx, log, inst, snsInst); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// If the subnamespace doesn't exist, create it.
 	if inst.Status.State == api.Missing {
 		if err := r.writeNamespace(ctx, log, nm, pnm); err != nil {
@@ -159,6 +186,9 @@ func (r *Reconciler) onDeleting(ctx context.Context, log logr.Logger, inst *api.
 	// something to happen. See method-level comments for details.
 	switch {
 	case r.shouldDeleteSubns(log, inst, snsInst, deletingCRD):
+		if r.DeletionGracePeriod > 0 {
+			return r.softDeleteNamespace(ctx, log, inst, snsInst)
+		}
 		log.Info("Deleting subnamespace due to anchor being deleted")
 		return r.deleteNamespace(ctx, log, snsInst)
 	case r.shouldFinalizeAnchor(log, inst, snsInst):
@@ -246,6 +276,146 @@ func (r *Reconciler) shouldDeleteSubns(log logr.Logger, inst *api.SubnamespaceAn
 
 }
 
+// softDeleteNamespace is called instead of deleteNamespace if a deletion grace period is
+// configured. It marks the subnamespace as pending deletion until the grace period expires, which
+// also halts it; prevents any new pods from being created in it, or in any subnamespaces that would
+// be deleted along with it; and then allows the anchor to be finalized. Existing pods are left
+// running, since scaling down or evicting them couldn't be undone if the anchor is restored. Once
+// the anchor is gone, the subnamespace is deleted by syncPendingDeletion when the grace period
+// expires, unless the anchor is recreated first (see restore).
+func (r *Reconciler) softDeleteNamespace(ctx context.Context, log logr.Logger, inst *api.SubnamespaceAnchor, snsInst *corev1.Namespace) error {
+	// Don't extend the grace period if we've already started it.
+	if snsInst.Annotations[api.AnnotationDeleteAfter] == "" {
+		deleteAfter := time.Now().Add(r.DeletionGracePeriod).UTC().Format(time.RFC3339)
+		log.Info("Marking subnamespace as pending deletion due to anchor being deleted", "deleteAfter", deleteAfter)
+		metadata.SetAnnotation(snsInst, api.AnnotationDeleteAfter, deleteAfter)
+		if err := r.Update(ctx, snsInst); err != nil {
+			log.Error(err, "While marking subnamespace as pending deletion")
+			return err
+		}
+	}
+
+	for _, nm := range r.getDeletedNames(inst.Name) {
+		if err := r.writePendingDeletionQuota(ctx, log, nm); err != nil {
+			return err
+		}
+	}
+
+	log.V(1).Info("Unblocking deletion of anchor; the subnamespace is pending deletion")
+	controllerutil.RemoveFinalizer(inst, api.MetaGroup)
+	return r.writeInstance(ctx, log, inst)
+}
+
+// syncPendingDeletion is called when an anchor doesn't exist. If its subnamespace is pending
+// deletion, it deletes the subnamespace once the grace period has expired, or requeues the anchor
+// until then.
+func (r *Reconciler) syncPendingDeletion(ctx context.Context, log logr.Logger, pnm, nm string) (ctrl.Result, error) {
+	snsInst, err := r.getNamespace(ctx, nm)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	deleteAfter := snsInst.Annotations[api.AnnotationDeleteAfter]
+	if deleteAfter == "" || snsInst.Annotations[api.SubnamespaceOf] != pnm || !snsInst.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, deleteAfter)
+	if err != nil {
+		// Stay on the safe side - don't delete
+		log.Error(err, "Illegal deletion time on subnamespace; will not delete", "deleteAfter", deleteAfter)
+		return ctrl.Result{}, nil
+	}
+	if wait := time.Until(t); wait > 0 {
+		log.V(1).Info("Subnamespace is pending deletion", "deleteAfter", deleteAfter)
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	// The subtree has been halted since the anchor was deleted, but check that it's still safe to
+	// delete the subnamespace, just like when the anchor was deleted.
+	if !r.canDeleteSubns(nm) {
+		log.Info("This subnamespace has descendants and allowCascadingDeletion is disabled; will not delete")
+		return ctrl.Result{}, nil
+	}
+	log.Info("Deleting subnamespace since its deletion grace period has expired", "deleteAfter", deleteAfter)
+	return ctrl.Result{}, r.deleteNamespace(ctx, log, snsInst)
+}
+
+// restore cancels the pending deletion of the subnamespace when its anchor is recreated.
+func (r *Reconciler) restore(ctx context.Context, log logr.Logger, inst *api.SubnamespaceAnchor, snsInst *corev1.Namespace) error {
+	if inst.Status.State != api.Ok || snsInst.Annotations[api.AnnotationDeleteAfter] == "" {
+		return nil
+	}
+
+	log.Info("Restoring subnamespace that was pending deletion", "deleteAfter", snsInst.Annotations[api.AnnotationDeleteAfter])
+	delete(snsInst.Annotations, api.AnnotationDeleteAfter)
+	if err := r.Update(ctx, snsInst); err != nil {
+		log.Error(err, "While restoring subnamespace")
+		return err
+	}
+
+	// The subtree may have changed since the quotas were created, so look for them everywhere.
+	for _, nm := range r.getSubtreeNames(inst.Name) {
+		if err := r.deletePendingDeletionQuota(ctx, log, nm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// canDeleteSubns returns true if it's safe to delete the subnamespace, i.e. if it's a leaf or if
+// ACD=true on it or one of its ancestors.
+func (r *Reconciler) canDeleteSubns(nm string) bool {
+	r.Forest.Lock()
+	defer r.Forest.Unlock()
+	cns := r.Forest.Get(nm)
+	return cns.ChildNames() == nil || cns.AllowsCascadingDeletion()
+}
+
+// getDeletedNames returns the name of the subnamespace and of all of its descendants that would be
+// deleted along with it.
+func (r *Reconciler) getDeletedNames(nm string) []string {
+	r.Forest.Lock()
+	defer r.Forest.Unlock()
+	return append([]string{nm}, r.Forest.Get(nm).SubnamespaceDescendantNames()...)
+}
+
+// getSubtreeNames returns the name of the namespace and of all of its descendants.
+func (r *Reconciler) getSubtreeNames(nm string) []string {
+	r.Forest.Lock()
+	defer r.Forest.Unlock()
+	return append([]string{nm}, r.Forest.Get(nm).DescendantNames()...)
+}
+
+// writePendingDeletionQuota creates a ResourceQuota that prevents any new pods from being created in
+// the namespace, if it doesn't already exist.
+func (r *Reconciler) writePendingDeletionQuota(ctx context.Context, log logr.Logger, nsnm string) error {
+	inst := &corev1.ResourceQuota{}
+	inst.ObjectMeta.Name = api.PendingDeletionQuota
+	inst.ObjectMeta.Namespace = nsnm
+	// Make sure the quota is never overwritten by ancestors if resource quotas are propagated.
+	metadata.SetAnnotation(inst, api.NonPropagateAnnotation, "true")
+	inst.Spec.Hard = corev1.ResourceList{corev1.ResourcePods: resource.MustParse("0")}
+	log.V(1).Info("Creating pending-deletion quota", "namespace", nsnm)
+	if err := r.Create(ctx, inst); err != nil && !apierrors.IsAlreadyExists(err) {
+		log.Error(err, "While creating pending-deletion quota", "namespace", nsnm)
+		return err
+	}
+	return nil
+}
+
+// deletePendingDeletionQuota deletes the ResourceQuota created by writePendingDeletionQuota, if it
+// exists.
+func (r *Reconciler) deletePendingDeletionQuota(ctx context.Context, log logr.Logger, nsnm string) error {
+	inst := &corev1.ResourceQuota{}
+	inst.ObjectMeta.Name = api.PendingDeletionQuota
+	inst.ObjectMeta.Namespace = nsnm
+	if err := r.Delete(ctx, inst); err != nil && !apierrors.IsNotFound(err) {
+		log.Error(err, "While deleting pending-deletion quota", "namespace", nsnm)
+		return err
+	}
+	return nil
+}
+
 // shouldFinalizeAnchor determines whether the anchor is safe to delete. It should only be called once
 // we know that we don't need to delete the subnamespace itself (e.g. it's already gone, it can't be
 // deleted, it's in the process of being deleted, etc).
@@ -294,6 +464,126 @@ func (r *Reconciler) shouldFinalizeAnchor(log logr.Logger, inst *api.Subnamespac
 	}
 }
 
+// syncMove handles anchors with the move-from annotation, which the validator only allows on new
+// anchors for existing subnamespaces of the namespace in the annotation. It moves the subnamespace
+// by pointing its subnamespace-of annotation to this anchor's namespace; the HierarchyConfiguration
+// reconciler then updates its parent. The old anchor is no longer bound to the subnamespace, so it's
+// deleted without deleting the subnamespace (see deleteMovedAnchor).
+//
+// The move is only attempted once, after which the annotation is removed from the anchor (when it's
+// next written), and it behaves like any other anchor.
+func (r *Reconciler) syncMove(ctx context.Context, log logr.Logger, inst *api.SubnamespaceAnchor, snsInst *corev1.Namespace) error {
+	from := inst.Annotations[api.AnnotationMoveFrom]
+	if from == "" || snsInst.Name == "" {
+		// If the namespace doesn't exist yet, wait until it does. If it's really gone, the anchor will
+		// simply create a new one.
+		return nil
+	}
+	delete(inst.Annotations, api.AnnotationMoveFrom)
+
+	sOf := snsInst.Annotations[api.SubnamespaceOf]
+	if sOf == inst.Namespace {
+		// We've already moved it, but couldn't delete the old anchor.
+		return r.deleteMovedAnchor(ctx, log, from, inst.Name)
+	}
+	if sOf != from || !inst.DeletionTimestamp.IsZero() || !snsInst.DeletionTimestamp.IsZero() {
+		log.Info("Not moving subnamespace since it's no longer a subnamespace of the requested namespace, or is being deleted", "moveFrom", from, "annotation", sOf)
+		return nil
+	}
+
+	log.Info("Moving subnamespace", "from", from)
+	metadata.SetAnnotation(snsInst, api.SubnamespaceOf, inst.Namespace)
+	if err := r.Update(ctx, snsInst); err != nil {
+		log.Error(err, "While moving subnamespace")
+		return err
+	}
+	return r.deleteMovedAnchor(ctx, log, from, inst.Name)
+}
+
+// deleteMovedAnchor deletes the old anchor of a subnamespace that's just been moved to a new parent,
+// which is now in the Conflict state. Its finalizer is removed first, and the deletion is made
+// conditional on that exact version of the anchor, so that it's deleted at once rather than being
+// reconciled again; otherwise, if its reconciler still saw the old subnamespace-of annotation in
+// its cache, it could delete the subnamespace along with it. If the old anchor is changed in the
+// meantime, an error is returned so that the move is reconciled (and the deletion retried) again.
+func (r *Reconciler) deleteMovedAnchor(ctx context.Context, log logr.Logger, pnm, nm string) error {
+	inst := &api.SubnamespaceAnchor{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: pnm, Name: nm}, inst); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	log.Info("Deleting the old anchor of the moved subnamespace", "oldParent", pnm)
+	if controllerutil.ContainsFinalizer(inst, api.MetaGroup) {
+		controllerutil.RemoveFinalizer(inst, api.MetaGroup)
+		if err := r.Update(ctx, inst); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+	}
+	rv := inst.ResourceVersion
+	return client.IgnoreNotFound(r.Delete(ctx, inst, client.Preconditions{ResourceVersion: &rv}))
+}
+
+// syncAdopt handles anchors with the adopt annotation, which the validator only allows on new
+// anchors for existing full namespaces. It adopts the namespace by setting its subnamespace-of
+// annotation to this anchor's namespace; the HierarchyConfiguration reconciler then updates its
+// parent. Like a move, adoption is only attempted once.
+func (r *Reconciler) syncAdopt(ctx context.Context, log logr.Logger, inst *api.SubnamespaceAnchor, snsInst *corev1.Namespace) error {
+	if inst.Annotations[api.AnnotationAdopt] != "true" || snsInst.Name == "" {
+		// If the namespace doesn't exist (yet), the anchor will simply create a new one.
+		return nil
+	}
+	delete(inst.Annotations, api.AnnotationAdopt)
+
+	sOf := snsInst.Annotations[api.SubnamespaceOf]
+	if sOf == inst.Namespace {
+		return nil
+	}
+	mgr := snsInst.Annotations[api.AnnotationManagedBy]
+	if sOf != "" || (mgr != "" && mgr != api.MetaGroup) || !inst.DeletionTimestamp.IsZero() || !snsInst.DeletionTimestamp.IsZero() {
+		log.Info("Not adopting namespace since it's already a subnamespace, is external or is being deleted", "annotation", sOf, "managedBy", mgr)
+		return nil
+	}
+
+	log.Info("Adopting namespace")
+	metadata.SetAnnotation(snsInst, api.SubnamespaceOf, inst.Namespace)
+	if err := r.Update(ctx, snsInst); err != nil {
+		log.Error(err, "While adopting namespace")
+		return err
+	}
+	return nil
+}
+
+// release handles anchors with the release annotation. If the anchor is bound to its subnamespace,
+// it converts the subnamespace into a full namespace (with the same parent) by removing its
+// subnamespace-of annotation. It then deletes the anchor, after removing its finalizer so that it
+// can never cause the namespace to be deleted.
+func (r *Reconciler) release(ctx context.Context, log logr.Logger, inst *api.SubnamespaceAnchor, snsInst *corev1.Namespace) error {
+	if snsInst.Name != "" && snsInst.Annotations[api.SubnamespaceOf] == inst.Namespace {
+		log.Info("Releasing subnamespace")
+		delete(snsInst.Annotations, api.SubnamespaceOf)
+		if err := r.Update(ctx, snsInst); err != nil {
+			log.Error(err, "While releasing subnamespace")
+			return err
+		}
+	}
+
+	if controllerutil.ContainsFinalizer(inst, api.MetaGroup) {
+		controllerutil.RemoveFinalizer(inst, api.MetaGroup)
+		if err := r.writeInstance(ctx, log, inst); err != nil {
+			return err
+		}
+	}
+	if !inst.DeletionTimestamp.IsZero() {
+		// It'll be gone now that there are no more finalizers.
+		return nil
+	}
+	log.Info("Deleting released anchor")
+	return r.deleteInstance(ctx, inst)
+}
+
 func (r *Reconciler) updateState(log logr.Logger, inst *api.SubnamespaceAnchor, snsInst *corev1.Namespace) {
 	pnm := inst.Namespace
 	sOf := snsInst.Annotations[api.SubnamespaceOf]
@@ -358,8 +648,6 @@ func (r *Reconciler) writeInstance(ctx context.Context, log logr.Logger, inst *a
 
 // deleteInstance deletes the anchor instance. Note: Make sure there's no
 // finalizers on the instance before calling this function.
-//
-//lint:ignore U1000 Ignore for now, as it may be used again in the future
 func (r *Reconciler) deleteInstance(ctx context.Context, inst *api.SubnamespaceAnchor) error {
 	if err := r.Delete(ctx, inst); err != nil {
 		return fmt.Errorf("while deleting on apiserver: %w", err)
@@ -410,7 +698,9 @@ func (r *Reconciler) deleteNamespace(ctx context.Context, log logr.Logger, inst
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.affected = make(chan event.GenericEvent)
 
-	// Maps an subnamespace to its anchor in the parent namespace.
+	// Maps an subnamespace to its anchor in the parent namespace. For updates, this is called for both
+	// the old and new objects, so when a subnamespace is moved, the anchors in both the old and new
+	// parents are enqueued.
 	nsMapFn := func(obj client.Object) []reconcile.Request {
 		if obj.GetAnnotations()[api.SubnamespaceOf] == "" {
 			return nil
@@ -3,9 +3,13 @@ package anchor_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
 
 	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
@@ -85,6 +89,95 @@ var _ = Describe("Anchor", func() {
 		Eventually(getAnchorState(ctx, fooName, bazName)).Should(Equal(api.Conflict))
 	})
 
+	It("should move a subnamespace to the namespace of an anchor with the move-from annotation", func() {
+		// Create "bar" anchor in "foo" namespace.
+		updateAnchor(ctx, newAnchor(barName, fooName))
+		Eventually(getAnchorState(ctx, fooName, barName)).Should(Equal(api.Ok))
+
+		// Move "bar" to the full namespace "baz".
+		bazName := CreateNS(ctx, "baz")
+		baz_anchor_bar := newAnchor(barName, bazName)
+		baz_anchor_bar.Annotations = map[string]string{api.AnnotationMoveFrom: fooName}
+		updateAnchor(ctx, baz_anchor_bar)
+
+		// The subnamespace should be updated, and the old anchor should be deleted without deleting
+		// the subnamespace.
+		Eventually(func() string {
+			return GetNamespace(ctx, barName).GetAnnotations()[api.SubnamespaceOf]
+		}).Should(Equal(bazName))
+		Eventually(func() string {
+			return GetHierarchy(ctx, barName).Spec.Parent
+		}).Should(Equal(bazName))
+		Eventually(getAnchorState(ctx, bazName, barName)).Should(Equal(api.Ok))
+		Eventually(canGetAnchor(ctx, fooName, barName)).Should(Equal(false))
+		Expect(getAnchor(ctx, bazName, barName).Annotations).ShouldNot(HaveKey(api.AnnotationMoveFrom))
+		Consistently(func() string {
+			return GetNamespace(ctx, barName).GetAnnotations()[api.SubnamespaceOf]
+		}).Should(Equal(bazName))
+	})
+
+	It("should adopt an existing full namespace if the anchor has the adopt annotation", func() {
+		bazName := CreateNS(ctx, "baz")
+		foo_anchor_baz := newAnchor(bazName, fooName)
+		foo_anchor_baz.Annotations = map[string]string{api.AnnotationAdopt: "true"}
+		updateAnchor(ctx, foo_anchor_baz)
+
+		Eventually(func() string {
+			return GetNamespace(ctx, bazName).GetAnnotations()[api.SubnamespaceOf]
+		}).Should(Equal(fooName))
+		Eventually(func() string {
+			return GetHierarchy(ctx, bazName).Spec.Parent
+		}).Should(Equal(fooName))
+		Eventually(getAnchorState(ctx, fooName, bazName)).Should(Equal(api.Ok))
+		Expect(getAnchor(ctx, fooName, bazName).Annotations).ShouldNot(HaveKey(api.AnnotationAdopt))
+	})
+
+	It("should release the subnamespace and delete the anchor if the anchor has the release annotation", func() {
+		updateAnchor(ctx, newAnchor(barName, fooName))
+		Eventually(getAnchorState(ctx, fooName, barName)).Should(Equal(api.Ok))
+
+		Eventually(func() error {
+			foo_anchor_bar := getAnchor(ctx, fooName, barName)
+			foo_anchor_bar.Annotations = map[string]string{api.AnnotationRelease: "true"}
+			return K8sClient.Update(ctx, foo_anchor_bar) // can fail if the reconciler updates the anchor
+		}).Should(Succeed())
+
+		// The anchor should be gone, but "bar" should still be a full child of "foo".
+		Eventually(canGetAnchor(ctx, fooName, barName)).Should(Equal(false))
+		Eventually(func() string {
+			return GetNamespace(ctx, barName).GetAnnotations()[api.SubnamespaceOf]
+		}).Should(Equal(""))
+		Consistently(func() string {
+			return GetHierarchy(ctx, barName).Spec.Parent
+		}).Should(Equal(fooName))
+	})
+
+	It("should restore a subnamespace that is pending deletion when its anchor exists", func() {
+		updateAnchor(ctx, newAnchor(barName, fooName))
+		Eventually(getAnchorState(ctx, fooName, barName)).Should(Equal(api.Ok))
+
+		// Put "bar" into the state that it would be in if its anchor had been deleted with a grace
+		// period and then recreated.
+		quota := &corev1.ResourceQuota{}
+		quota.Name = api.PendingDeletionQuota
+		quota.Namespace = barName
+		quota.Spec.Hard = corev1.ResourceList{corev1.ResourcePods: resource.MustParse("0")}
+		Expect(K8sClient.Create(ctx, quota)).Should(Succeed())
+		Eventually(func() error {
+			ns := GetNamespace(ctx, barName)
+			ns.Annotations[api.AnnotationDeleteAfter] = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+			return K8sClient.Update(ctx, ns)
+		}).Should(Succeed())
+
+		// The reconciler should remove the annotation and the quota.
+		Eventually(GetAnnotation(ctx, barName, api.AnnotationDeleteAfter)).Should(Equal(""))
+		Eventually(func() bool {
+			err := K8sClient.Get(ctx, types.NamespacedName{Namespace: barName, Name: api.PendingDeletionQuota}, &corev1.ResourceQuota{})
+			return apierrors.IsNotFound(err)
+		}).Should(BeTrue())
+		Expect(GetNamespace(ctx, barName).GetAnnotations()[api.SubnamespaceOf]).Should(Equal(fooName))
+	})
+
 	It("should always set the owner as the parent if otherwise", func() {
 		// Create "bar" anchor in "foo" namespace.
 		foo_anchor_bar := newAnchor(barName, fooName)
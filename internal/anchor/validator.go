@@ -2,14 +2,15 @@ package anchor
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/go-logr/logr"
 	k8sadm "k8s.io/api/admission/v1"
+	authnv1 "k8s.io/api/authentication/v1"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
@@ -30,15 +31,35 @@ const (
 // +kubebuilder:webhook:admissionReviewVersions=v1,path=/validate-hnc-x-k8s-io-v1alpha2-subnamespaceanchors,mutating=false,failurePolicy=fail,groups="hnc.x-k8s.io",resources=subnamespaceanchors,sideEffects=None,verbs=create;update;delete,versions=v1alpha2,name=subnamespaceanchors.hnc.x-k8s.io
 
 type Validator struct {
-	Log     logr.Logger
-	Forest  *forest.Forest
+	Log    logr.Logger
+	Forest *forest.Forest
+
+	// Mover checks whether anchors with the move-from or adopt annotations are allowed to move or
+	// adopt their namespaces. If it's nil (e.g. in unit tests), only the checks in this validator are
+	// applied.
+	Mover Mover
+
+	// Reader is used to count the workloads that would be deleted by a dry-run deletion. It should
+	// bypass the manager's cache, since HNC doesn't otherwise watch workloads. If it's nil, workloads
+	// aren't counted.
+	Reader client.Reader
+
 	decoder *admission.Decoder
 }
 
+// Mover checks whether a subnamespace can be moved to a new parent, or a full namespace can be
+// adopted as a subnamespace. It's implemented by the HierarchyConfiguration validator, so that both
+// are subject to the same checks as changing the parent of a full namespace.
+type Mover interface {
+	CheckMove(ctx context.Context, log logr.Logger, ui *authnv1.UserInfo, nnm, pnm string) admission.Response
+	CheckAdopt(ctx context.Context, log logr.Logger, ui *authnv1.UserInfo, nnm, pnm string) admission.Response
+}
+
 // req defines the aspects of the admission.Request that we care about.
 type anchorRequest struct {
-	anchor *api.SubnamespaceAnchor
-	op     k8sadm.Operation
+	anchor    *api.SubnamespaceAnchor
+	oldAnchor *api.SubnamespaceAnchor // only set for updates
+	op        k8sadm.Operation
 }
 
 // Handle implements the validation webhook.
@@ -57,6 +78,12 @@ func (v *Validator) Handle(ctx context.Context, req admission.Request) admission
 	}
 
 	resp := v.handle(decoded)
+	if resp.Allowed {
+		resp = v.checkMove(ctx, log, &req.UserInfo, decoded)
+	}
+	if decoded.op == k8sadm.Delete && req.DryRun != nil && *req.DryRun {
+		resp = resp.WithWarnings(v.dryRunDelete(ctx, log, decoded)...)
+	}
 	if !resp.Allowed {
 		log.Info("Denied", "code", resp.Result.Code, "reason", resp.Result.Reason, "message", resp.Result.Message)
 	} else {
@@ -94,6 +121,13 @@ func (v *Validator) handle(req *anchorRequest) admission.Response {
 		return webhooks.DenyInvalid(api.SubnamespaceAnchorGK, req.anchor.Name, allErrs)
 	}
 
+	// Subnamespaces can't loosen the monotonic labels of their parent.
+	if req.op != k8sadm.Delete {
+		if allErrs := config.ValidateMonotonicLabels(req.anchor.Spec.Labels, v.Forest.Get(pnm).GetMonotonicLabels()); len(allErrs) > 0 {
+			return webhooks.DenyInvalid(api.SubnamespaceAnchorGK, req.anchor.Name, allErrs)
+		}
+	}
+
 	switch req.op {
 	case k8sadm.Create:
 		// Can't create subnamespaces in unmanaged namespaces
@@ -106,32 +140,170 @@ func (v *Validator) handle(req *anchorRequest) admission.Response {
 			err := fmt.Errorf("cannot create a subnamespace using the unmanaged namespace name %q (%s)", cnm, why)
 			return webhooks.DenyForbidden(api.SubnamespaceAnchorGR, cnm, err)
 		}
+		// Can't create subnamespaces in subtrees that have been halted by an administrator
+		if root := v.Forest.Get(pnm).GetAdminHaltedRoot(); root != "" {
+			err := fmt.Errorf("cannot create a subnamespace in %q because namespace %q has been halted by an administrator", pnm, root)
+			return webhooks.DenyForbidden(api.SubnamespaceAnchorGR, cnm, err)
+		}
 
 		// Can't create anchors for existing namespaces, _unless_ it's for a subns with a missing
-		// anchor.
-		if cns.Exists() {
+		// anchor, or the anchor is moving a subns from another parent or adopting a full namespace.
+		from := req.anchor.Annotations[api.AnnotationMoveFrom]
+		adopt := req.anchor.Annotations[api.AnnotationAdopt] == "true"
+		switch {
+		case from != "" && adopt:
+			err := fmt.Errorf("the %q and %q annotations cannot be set at the same time", api.AnnotationMoveFrom, api.AnnotationAdopt)
+			return webhooks.DenyConflict(api.SubnamespaceAnchorGR, cnm, err)
+		case adopt:
+			if !cns.Exists() {
+				err := fmt.Errorf("cannot adopt %q because it does not exist", cnm)
+				return webhooks.DenyConflict(api.SubnamespaceAnchorGR, cnm, err)
+			}
+			if cns.IsSub {
+				err := fmt.Errorf("cannot adopt %q because it is already a subnamespace of %q; move it instead (e.g. via 'kubectl hns move')", cnm, cns.Parent().Name())
+				return webhooks.DenyConflict(api.SubnamespaceAnchorGR, cnm, err)
+			}
+			if cns.IsExternal() {
+				err := fmt.Errorf("cannot adopt %q because it is managed by %q, not HNC", cnm, cns.Manager)
+				return webhooks.DenyForbidden(api.SubnamespaceAnchorGR, cnm, err)
+			}
+		case from != "":
+			if from == pnm {
+				err := fmt.Errorf("cannot move subnamespace %q from %q to the same namespace", cnm, from)
+				return webhooks.DenyConflict(api.SubnamespaceAnchorGR, cnm, err)
+			}
+			if !cns.Exists() || !cns.IsSub || cns.Parent().Name() != from {
+				err := fmt.Errorf("cannot move %q from %q because it is not a subnamespace of %q", cnm, from, from)
+				return webhooks.DenyConflict(api.SubnamespaceAnchorGR, cnm, err)
+			}
+		case cns.Exists():
 			childIsMissingAnchor := (cns.Parent().Name() == pnm && cns.IsSub)
 			if !childIsMissingAnchor {
-				err := errors.New("cannot create a subnamespace using an existing namespace")
+				err := fmt.Errorf("cannot create a subnamespace using an existing namespace. To turn it into a subnamespace, set the %q annotation to \"true\" (e.g. via 'kubectl hns adopt')", api.AnnotationAdopt)
 				return webhooks.DenyConflict(api.SubnamespaceAnchorGR, cnm, err)
 			}
+		default:
+			// Only the names of new namespaces are checked; existing namespaces can always be moved or
+			// adopted, or have their anchors recreated.
+			if rsp := v.checkNaming(pnm, cnm); !rsp.Allowed {
+				return rsp
+			}
 		}
 
 	case k8sadm.Delete:
+		// Can't delete or release subnamespaces in subtrees that have been halted by an administrator,
+		// since that would change their structure. Anchors that aren't in a good state don't control
+		// any namespace, so they can always be deleted.
+		if req.anchor.Status.State == api.Ok {
+			if root := cns.GetAdminHaltedRoot(); root != "" {
+				err := fmt.Errorf("cannot delete the anchor for %q because namespace %q has been halted by an administrator", cnm, root)
+				return webhooks.DenyForbidden(api.SubnamespaceAnchorGR, cnm, err)
+			}
+		}
+
 		// Don't allow the anchor to be deleted if it's in a good state and has descendants of its own,
-		// unless allowCascadingDeletion is set.
-		if req.anchor.Status.State == api.Ok && cns.ChildNames() != nil && !cns.AllowsCascadingDeletion() {
+		// unless allowCascadingDeletion is set, or the subnamespace is being released rather than
+		// deleted.
+		released := req.anchor.Annotations[api.AnnotationRelease] == "true"
+		if req.anchor.Status.State == api.Ok && !released && cns.ChildNames() != nil && !cns.AllowsCascadingDeletion() {
 			err := fmt.Errorf("subnamespace %s is not a leaf and doesn't allow cascading deletion. Please set allowCascadingDeletion flag or make it a leaf first", cnm)
 			return webhooks.DenyForbidden(api.SubnamespaceAnchorGR, cnm, err)
 		}
 
+	case k8sadm.Update:
+		// The move-from and adopt annotations are only checked when the anchor is created, so they
+		// can't be added or changed later. HNC removes them once the anchor is processed.
+		if req.oldAnchor != nil {
+			for _, key := range []string{api.AnnotationMoveFrom, api.AnnotationAdopt} {
+				val, ok := req.anchor.Annotations[key]
+				if ok && val != req.oldAnchor.Annotations[key] {
+					err := fmt.Errorf("the %q annotation can only be set when an anchor is created", key)
+					return webhooks.DenyForbidden(api.SubnamespaceAnchorGR, cnm, err)
+				}
+			}
+		}
+
+		// Releasing a subnamespace changes the structure of its subtree just like deleting its anchor
+		// does, and HNC deletes the anchor itself once it's released, so this is the only chance to
+		// stop it in a subtree halted by an administrator.
+		released := req.anchor.Annotations[api.AnnotationRelease] == "true"
+		wasReleased := req.oldAnchor != nil && req.oldAnchor.Annotations[api.AnnotationRelease] == "true"
+		if released && !wasReleased {
+			if root := cns.GetAdminHaltedRoot(); root != "" {
+				err := fmt.Errorf("cannot release %q because namespace %q has been halted by an administrator", cnm, root)
+				return webhooks.DenyForbidden(api.SubnamespaceAnchorGR, cnm, err)
+			}
+		}
+
 	default:
-		// nop for updates etc
+		// nop for other operations
+	}
+
+	return webhooks.Allow("")
+}
+
+// checkMove asks the Mover whether a new anchor is allowed to move its subnamespace from its
+// current parent, or to adopt its namespace. This is called after handle, once the forest lock has
+// been released.
+func (v *Validator) checkMove(ctx context.Context, log logr.Logger, ui *authnv1.UserInfo, req *anchorRequest) admission.Response {
+	if req.op != k8sadm.Create || v.Mover == nil {
+		return webhooks.Allow("")
+	}
+	if from := req.anchor.Annotations[api.AnnotationMoveFrom]; from != "" {
+		log.Info("Checking move", "from", from)
+		return v.Mover.CheckMove(ctx, log, ui, req.anchor.Name, req.anchor.Namespace)
+	}
+	if req.anchor.Annotations[api.AnnotationAdopt] == "true" {
+		log.Info("Checking adoption")
+		return v.Mover.CheckAdopt(ctx, log, ui, req.anchor.Name, req.anchor.Namespace)
+	}
+	return webhooks.Allow("")
+}
+
+// checkNaming checks that the name of a new subnamespace follows the naming policies that apply to
+// its parent, if any.
+func (v *Validator) checkNaming(pnm, cnm string) admission.Response {
+	pns := v.Forest.Get(pnm)
+	policies, srcs := pns.GetSubnamespaceNamings()
+	if len(policies) == 0 {
+		return webhooks.Allow("")
 	}
+	root := pns.AncestryNames()[0]
+	fldPath := field.NewPath("metadata", "name")
+
+	for i, policy := range policies {
+		src := srcs[i]
+		if prefix := policy.RequiredPrefix(pnm, root); !strings.HasPrefix(cnm, prefix) {
+			msg := fmt.Sprintf("the naming policy set on %q requires subnamespaces of %q to start with %q (e.g. via 'kubectl hns create --auto-prefix')", src, pnm, prefix)
+			return webhooks.DenyInvalid(api.SubnamespaceAnchorGK, cnm, field.ErrorList{field.Invalid(fldPath, cnm, msg)})
+		}
 
+		re, err := policy.CompileNameRegex(pnm, root)
+		if err != nil {
+			// This can only happen if the HierarchyConfiguration webhook was bypassed.
+			err = fmt.Errorf("the naming policy set on %q is invalid: %w", src, err)
+			return webhooks.DenyForbidden(api.SubnamespaceAnchorGR, cnm, err)
+		}
+		if re != nil && !re.MatchString(cnm) {
+			msg := fmt.Sprintf("the naming policy set on %q requires subnamespaces of %q to match the regex %q", src, pnm, re.String())
+			return webhooks.DenyInvalid(api.SubnamespaceAnchorGK, cnm, field.ErrorList{field.Invalid(fldPath, cnm, msg)})
+		}
+	}
 	return webhooks.Allow("")
 }
 
+// dryRunDelete describes what would be deleted along with the anchor, even if the deletion is
+// denied, so users can see the impact of setting allowCascadingDeletion before they do so.
+func (v *Validator) dryRunDelete(ctx context.Context, log logr.Logger, req *anchorRequest) []string {
+	if req.anchor.Status.State != api.Ok {
+		return []string{fmt.Sprintf("dry run: this would not delete any namespaces, since the anchor's state is %q", req.anchor.Status.State)}
+	}
+	if req.anchor.Annotations[api.AnnotationRelease] == "true" {
+		return []string{"dry run: this would not delete any namespaces, since the subnamespace is being released"}
+	}
+	return webhooks.DryRunDeletion(ctx, log, v.Forest, v.Reader, req.anchor.Name)
+}
+
 // decodeRequest gets the information we care about into a simple struct that's easy to both a) use
 // and b) factor out in unit tests.
 func (v *Validator) decodeRequest(log logr.Logger, in admission.Request) (*anchorRequest, error) {
@@ -149,9 +321,18 @@ func (v *Validator) decodeRequest(log logr.Logger, in admission.Request) (*ancho
 		return nil, err
 	}
 
+	var oldAnchor *api.SubnamespaceAnchor
+	if in.Operation == k8sadm.Update {
+		oldAnchor = &api.SubnamespaceAnchor{}
+		if err := v.decoder.DecodeRaw(in.OldObject, oldAnchor); err != nil {
+			return nil, err
+		}
+	}
+
 	return &anchorRequest{
-		anchor: anchor,
-		op:     in.Operation,
+		anchor:    anchor,
+		oldAnchor: oldAnchor,
+		op:        in.Operation,
 	}, nil
 }
 
@@ -1,8 +1,10 @@
 package anchor
 
 import (
+	"context"
 	"testing"
 
+	"github.com/go-logr/logr"
 	. "github.com/onsi/gomega"
 	k8sadm "k8s.io/api/admission/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -56,8 +58,51 @@ func TestCreateSubnamespaces(t *testing.T) {
 	}
 }
 
-func TestDeleteSubnamespaces(t *testing.T) {
-	f := foresttest.Create("-Aa")
+func TestCreateSubnamespacesWhenHalted(t *testing.T) {
+	// a <- b <- c; d
+	f := foresttest.Create("-ab-")
+	f.Get("b").UpdateAdminHalted(true)
+	v := &Validator{Forest: f}
+
+	tests := []struct {
+		name string
+		pnm  string
+		fail bool
+	}{
+		{name: "outside the halted subtree", pnm: "a"},
+		{name: "in an unrelated tree", pnm: "d"},
+		{name: "in the halted namespace", pnm: "b", fail: true},
+		{name: "in a descendant of the halted namespace", pnm: "c", fail: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			g := NewWithT(t)
+			anchor := &api.SubnamespaceAnchor{}
+			anchor.ObjectMeta.Namespace = tc.pnm
+			anchor.ObjectMeta.Name = "brumpf"
+			req := &anchorRequest{
+				anchor: anchor,
+				op:     k8sadm.Create,
+			}
+
+			// Test
+			got := v.handle(req)
+
+			// Report
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).ShouldNot(Equal(tc.fail))
+		})
+	}
+}
+
+func TestSubnamespaceNaming(t *testing.T) {
+	// a <- b <- c; d; e <- F
+	f := foresttest.Create("-ab--E")
+	f.Get("a").UpdateSubnamespaceNaming(&api.SubnamespaceNamingPolicy{Prefix: "{{parent}}-"})
+	f.Get("c").UpdateSubnamespaceNaming(&api.SubnamespaceNamingPolicy{NameRegex: ".*-(dev|prod)"})
+	f.Get("d").UpdateSubnamespaceNaming(&api.SubnamespaceNamingPolicy{Prefix: "{{root}}-", NameRegex: "{{root}}-(dev|prod)"})
+	f.Get("e").UpdateSubnamespaceNaming(&api.SubnamespaceNamingPolicy{NameRegex: "["}) // bypassed the HC webhook
 	v := &Validator{Forest: f}
 
 	tests := []struct {
@@ -65,9 +110,176 @@ func TestDeleteSubnamespaces(t *testing.T) {
 		pnm  string
 		cnm  string
 		fail bool
+	}{
+		{name: "parent prefix", pnm: "a", cnm: "a-foo"},
+		{name: "missing parent prefix", pnm: "a", cnm: "foo", fail: true},
+		{name: "inherited parent prefix", pnm: "b", cnm: "b-foo"},
+		{name: "inherited prefix of the wrong parent", pnm: "b", cnm: "a-foo", fail: true},
+		{name: "own and inherited policies", pnm: "c", cnm: "c-dev"},
+		{name: "own policy without inherited prefix", pnm: "c", cnm: "x-dev", fail: true},
+		{name: "inherited prefix without own regex", pnm: "c", cnm: "c-foo", fail: true},
+		{name: "root prefix and regex", pnm: "d", cnm: "d-dev"},
+		{name: "root prefix without regex", pnm: "d", cnm: "d-test", fail: true},
+		{name: "invalid policy", pnm: "e", cnm: "foo", fail: true},
+		{name: "existing subnamespace with a missing anchor", pnm: "e", cnm: "f"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			g := NewWithT(t)
+			anchor := &api.SubnamespaceAnchor{}
+			anchor.ObjectMeta.Namespace = tc.pnm
+			anchor.ObjectMeta.Name = tc.cnm
+			req := &anchorRequest{
+				anchor: anchor,
+				op:     k8sadm.Create,
+			}
+
+			// Test
+			got := v.handle(req)
+
+			// Report
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).ShouldNot(Equal(tc.fail))
+		})
+	}
+}
+
+func TestMoveSubnamespaces(t *testing.T) {
+	// a <- b (subnamespace) and a <- c (full namespace); d
+	f := foresttest.Create("-Aa-")
+	v := &Validator{Forest: f}
+
+	tests := []struct {
+		name    string
+		op      k8sadm.Operation
+		pnm     string
+		cnm     string
+		from    string
+		oldFrom string
+		fail    bool
+	}{
+		{name: "to another tree", op: k8sadm.Create, pnm: "d", cnm: "b", from: "a"},
+		{name: "to a sibling", op: k8sadm.Create, pnm: "c", cnm: "b", from: "a"},
+		{name: "from the wrong parent", op: k8sadm.Create, pnm: "d", cnm: "b", from: "c", fail: true},
+		{name: "to the same parent", op: k8sadm.Create, pnm: "a", cnm: "b", from: "a", fail: true},
+		{name: "a full namespace", op: k8sadm.Create, pnm: "d", cnm: "c", from: "a", fail: true},
+		{name: "a missing namespace", op: k8sadm.Create, pnm: "d", cnm: "brumpf", from: "a", fail: true},
+		{name: "add annotation on update", op: k8sadm.Update, pnm: "a", cnm: "b", from: "d", fail: true},
+		{name: "keep annotation on update", op: k8sadm.Update, pnm: "d", cnm: "b", from: "a", oldFrom: "a"},
+		{name: "remove annotation on update", op: k8sadm.Update, pnm: "d", cnm: "b", oldFrom: "a"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			g := NewWithT(t)
+			anchor := &api.SubnamespaceAnchor{}
+			anchor.ObjectMeta.Namespace = tc.pnm
+			anchor.ObjectMeta.Name = tc.cnm
+			if tc.from != "" {
+				anchor.ObjectMeta.Annotations = map[string]string{api.AnnotationMoveFrom: tc.from}
+			}
+			req := &anchorRequest{
+				anchor: anchor,
+				op:     tc.op,
+			}
+			if tc.op == k8sadm.Update {
+				req.oldAnchor = anchor.DeepCopy()
+				req.oldAnchor.ObjectMeta.Annotations = nil
+				if tc.oldFrom != "" {
+					req.oldAnchor.ObjectMeta.Annotations = map[string]string{api.AnnotationMoveFrom: tc.oldFrom}
+				}
+			}
+
+			// Test
+			got := v.handle(req)
+
+			// Report
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).ShouldNot(Equal(tc.fail))
+		})
+	}
+}
+
+func TestAdoptNamespaces(t *testing.T) {
+	// a <- b (subnamespace) and a <- c (full namespace); d; e (external)
+	f := foresttest.Create("-Aa--")
+	f.Get("e").Manager = "other"
+	v := &Validator{Forest: f}
+
+	tests := []struct {
+		name     string
+		op       k8sadm.Operation
+		pnm      string
+		cnm      string
+		moveFrom string
+		adopt    bool
+		oldAdopt bool
+		fail     bool
+	}{
+		{name: "a root", op: k8sadm.Create, pnm: "a", cnm: "d", adopt: true},
+		{name: "a full child", op: k8sadm.Create, pnm: "a", cnm: "c", adopt: true},
+		{name: "a full child of another namespace", op: k8sadm.Create, pnm: "d", cnm: "c", adopt: true},
+		{name: "a subnamespace", op: k8sadm.Create, pnm: "d", cnm: "b", adopt: true, fail: true},
+		{name: "a missing namespace", op: k8sadm.Create, pnm: "a", cnm: "brumpf", adopt: true, fail: true},
+		{name: "an external namespace", op: k8sadm.Create, pnm: "a", cnm: "e", adopt: true, fail: true},
+		{name: "while moving", op: k8sadm.Create, pnm: "d", cnm: "b", moveFrom: "a", adopt: true, fail: true},
+		{name: "without the annotation", op: k8sadm.Create, pnm: "a", cnm: "d", fail: true},
+		{name: "add annotation on update", op: k8sadm.Update, pnm: "a", cnm: "b", adopt: true, fail: true},
+		{name: "keep annotation on update", op: k8sadm.Update, pnm: "a", cnm: "b", adopt: true, oldAdopt: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			g := NewWithT(t)
+			anchor := &api.SubnamespaceAnchor{}
+			anchor.ObjectMeta.Namespace = tc.pnm
+			anchor.ObjectMeta.Name = tc.cnm
+			anchor.ObjectMeta.Annotations = map[string]string{}
+			if tc.adopt {
+				anchor.ObjectMeta.Annotations[api.AnnotationAdopt] = "true"
+			}
+			if tc.moveFrom != "" {
+				anchor.ObjectMeta.Annotations[api.AnnotationMoveFrom] = tc.moveFrom
+			}
+			req := &anchorRequest{
+				anchor: anchor,
+				op:     tc.op,
+			}
+			if tc.op == k8sadm.Update {
+				req.oldAnchor = &api.SubnamespaceAnchor{}
+				if tc.oldAdopt {
+					req.oldAnchor.ObjectMeta.Annotations = map[string]string{api.AnnotationAdopt: "true"}
+				}
+			}
+
+			// Test
+			got := v.handle(req)
+
+			// Report
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).ShouldNot(Equal(tc.fail))
+		})
+	}
+}
+
+func TestDeleteSubnamespaces(t *testing.T) {
+	// a <- B; a <- c (full); d <- E <- F, with d halted by an administrator
+	f := foresttest.Create("-Aa-DE")
+	f.Get("d").UpdateAdminHalted(true)
+	v := &Validator{Forest: f}
+
+	tests := []struct {
+		name  string
+		pnm   string
+		cnm   string
+		state api.SubnamespaceAnchorState
+		fail  bool
 	}{
 		{name: "for existing subns", pnm: "a", cnm: "b"},
 		{name: "with name more than 63 characters", pnm: "a", cnm: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"},
+		{name: "in a halted subtree", pnm: "e", cnm: "f", state: api.Ok, fail: true},
+		{name: "in a halted subtree, not in a good state", pnm: "e", cnm: "f", state: api.Conflict},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -76,6 +288,7 @@ func TestDeleteSubnamespaces(t *testing.T) {
 			anchor := &api.SubnamespaceAnchor{}
 			anchor.ObjectMeta.Namespace = tc.pnm
 			anchor.ObjectMeta.Name = tc.cnm
+			anchor.Status.State = tc.state
 			req := &anchorRequest{
 				anchor: anchor,
 				op:     k8sadm.Delete,
@@ -91,6 +304,97 @@ func TestDeleteSubnamespaces(t *testing.T) {
 	}
 }
 
+func TestReleaseSubnamespaces(t *testing.T) {
+	// a <- B; a <- c (full); d <- E <- F, with d halted by an administrator
+	f := foresttest.Create("-Aa-DE")
+	f.Get("d").UpdateAdminHalted(true)
+	v := &Validator{Forest: f}
+
+	tests := []struct {
+		name        string
+		pnm         string
+		cnm         string
+		oldReleased bool
+		fail        bool
+	}{
+		{name: "ok", pnm: "a", cnm: "b"},
+		{name: "in a halted subtree", pnm: "e", cnm: "f", fail: true},
+		{name: "already released in a halted subtree", pnm: "e", cnm: "f", oldReleased: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			g := NewWithT(t)
+			anchor := &api.SubnamespaceAnchor{}
+			anchor.ObjectMeta.Namespace = tc.pnm
+			anchor.ObjectMeta.Name = tc.cnm
+			anchor.Status.State = api.Ok
+			oldAnchor := anchor.DeepCopy()
+			anchor.ObjectMeta.Annotations = map[string]string{api.AnnotationRelease: "true"}
+			if tc.oldReleased {
+				oldAnchor.ObjectMeta.Annotations = map[string]string{api.AnnotationRelease: "true"}
+			}
+			req := &anchorRequest{
+				anchor:    anchor,
+				oldAnchor: oldAnchor,
+				op:        k8sadm.Update,
+			}
+
+			// Test
+			got := v.handle(req)
+
+			// Report
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).ShouldNot(Equal(tc.fail))
+		})
+	}
+}
+
+func TestDryRunDeleteSubnamespaces(t *testing.T) {
+	// a <- B <- C, a <- d (full)
+	f := foresttest.Create("-ABa")
+	v := &Validator{Forest: f}
+
+	tests := []struct {
+		name     string
+		cnm      string
+		state    api.SubnamespaceAnchorState
+		rel      bool
+		wantMsg  string
+		numWarns int
+	}{
+		{name: "leaf", cnm: "c", state: api.Ok, wantMsg: "1 namespace(s): c", numWarns: 2},
+		{name: "subtree", cnm: "b", state: api.Ok, wantMsg: "2 namespace(s): b, c", numWarns: 2},
+		{name: "not Ok", cnm: "b", state: api.Conflict, wantMsg: "would not delete any namespaces", numWarns: 1},
+		{name: "released", cnm: "b", state: api.Ok, rel: true, wantMsg: "would not delete any namespaces", numWarns: 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			g := NewWithT(t)
+			anchor := &api.SubnamespaceAnchor{}
+			anchor.ObjectMeta.Namespace = f.Get(tc.cnm).Parent().Name()
+			anchor.ObjectMeta.Name = tc.cnm
+			anchor.Status.State = tc.state
+			if tc.rel {
+				anchor.ObjectMeta.Annotations = map[string]string{api.AnnotationRelease: "true"}
+			}
+			req := &anchorRequest{
+				anchor: anchor,
+				op:     k8sadm.Delete,
+			}
+
+			// Test
+			got := v.dryRunDelete(context.Background(), logr.Discard(), req)
+
+			// Report
+			t.Log(got)
+			g.Expect(got).Should(HaveLen(tc.numWarns))
+			g.Expect(got[0]).Should(ContainSubstring(tc.wantMsg))
+		})
+	}
+}
+
 func TestManagedMeta(t *testing.T) {
 	f := foresttest.Create("-") // a
 	v := &Validator{Forest: f}
@@ -175,6 +479,7 @@ func TestAllowCascadingDeleteSubnamespaces(t *testing.T) {
 		pnm  string
 		cnm  string
 		stt  api.SubnamespaceAnchorState // anchor state, "ok" by default
+		rel  bool                        // the anchor is releasing its subnamespace
 		fail bool
 	}{
 		{name: "set in parent", acd: "c", pnm: "c", cnm: "d"},
@@ -182,6 +487,7 @@ func TestAllowCascadingDeleteSubnamespaces(t *testing.T) {
 		{name: "set in ancestor that is not the first full namespace", acd: "a", pnm: "c", cnm: "d"},
 		{name: "unset in leaf", pnm: "d", cnm: "e"},
 		{name: "unset in non-leaf", pnm: "c", cnm: "d", fail: true},
+		{name: "unset in non-leaf but released", pnm: "c", cnm: "d", rel: true},
 		{name: "unset in non-leaf but bad anchor (incorrect hierarchy)", pnm: "b", cnm: "d", stt: api.Conflict},
 		{name: "unset in non-leaf but bad anchor (correct hierarchy)", pnm: "c", cnm: "d", stt: api.Conflict},
 	}
@@ -201,6 +507,9 @@ func TestAllowCascadingDeleteSubnamespaces(t *testing.T) {
 				tc.stt = api.Ok
 			}
 			anchor.Status.State = tc.stt
+			if tc.rel {
+				anchor.ObjectMeta.Annotations = map[string]string{api.AnnotationRelease: "true"}
+			}
 			req := &anchorRequest{
 				anchor: anchor,
 				op:     k8sadm.Delete,
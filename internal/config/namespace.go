@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
 )
@@ -32,6 +35,11 @@ var (
 	// namespace or one of its ancestors.
 	managedAnnotations []*regexp.Regexp
 
+	// monotonicLabels maps the key of each monotonic managed label to its legal values, ordered from
+	// the loosest to the strictest. Monotonic labels are always managed, and a namespace can never
+	// have a looser value for them than any of its ancestors.
+	monotonicLabels map[string][]string
+
 	// hncNamespace is the namespace where hnc-manager and hnc resources deployed. It set by commandline argument,
 	// default to hnc-system.
 	hncNamespace string
@@ -116,7 +124,88 @@ func setManagedMeta(patterns []string, option string, regexes *[]*regexp.Regexp)
 	return nil
 }
 
+// SetMonotonicLabels sets the monotonic managed labels from the values of the
+// --monotonic-namespace-label option, each of which has the form "key=value1,value2,..." with the
+// values ordered from the loosest to the strictest.
+func SetMonotonicLabels(specs []string) error {
+	// Reset (useful for unit tests)
+	monotonicLabels = nil
+
+	for _, spec := range specs {
+		kv := strings.SplitN(spec, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("illegal value for --monotonic-namespace-label %q: must have the form key=value1,value2,...", spec)
+		}
+		k := kv[0]
+		if errs := validation.IsQualifiedName(k); len(errs) != 0 {
+			return fmt.Errorf("illegal value for --monotonic-namespace-label %q: %s", spec, strings.Join(errs, "; "))
+		}
+		if strings.Contains(k, api.MetaGroup) {
+			return fmt.Errorf("illegal value for --monotonic-namespace-label %q: cannot specify a key in %q", spec, api.MetaGroup)
+		}
+		if _, ok := monotonicLabels[k]; ok {
+			return fmt.Errorf("illegal value for --monotonic-namespace-label %q: %q is specified more than once", spec, k)
+		}
+
+		vals := strings.Split(kv[1], ",")
+		seen := map[string]bool{}
+		for _, v := range vals {
+			if v == "" {
+				return fmt.Errorf("illegal value for --monotonic-namespace-label %q: values cannot be empty", spec)
+			}
+			if errs := validation.IsValidLabelValue(v); len(errs) != 0 {
+				return fmt.Errorf("illegal value for --monotonic-namespace-label %q: %s", spec, strings.Join(errs, "; "))
+			}
+			if seen[v] {
+				return fmt.Errorf("illegal value for --monotonic-namespace-label %q: %q is specified more than once", spec, v)
+			}
+			seen[v] = true
+		}
+
+		if monotonicLabels == nil {
+			monotonicLabels = map[string][]string{}
+		}
+		monotonicLabels[k] = vals
+	}
+	return nil
+}
+
+// IsMonotonicLabel returns true if the label was specified via --monotonic-namespace-label.
+func IsMonotonicLabel(k string) bool {
+	_, ok := monotonicLabels[k]
+	return ok
+}
+
+// GetMonotonicLabelValues returns the legal values of the monotonic label, from the loosest to the
+// strictest.
+func GetMonotonicLabelValues(k string) []string {
+	return monotonicLabels[k]
+}
+
+// MonotonicLabelRank returns the position of the value in the order of the monotonic label, where
+// zero is the loosest value, or -1 if it's not a legal value of the label.
+func MonotonicLabelRank(k, v string) int {
+	for i, val := range monotonicLabels[k] {
+		if val == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// StricterLabelValue returns the stricter of the two values of the monotonic label. Illegal and
+// empty values are looser than any legal value.
+func StricterLabelValue(k, a, b string) string {
+	if MonotonicLabelRank(k, b) > MonotonicLabelRank(k, a) {
+		return b
+	}
+	return a
+}
+
 func IsManagedLabel(k string) bool {
+	if IsMonotonicLabel(k) {
+		return true
+	}
 	for _, regex := range managedLabels {
 		if regex.MatchString(k) {
 			return true
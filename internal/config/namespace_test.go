@@ -34,3 +34,51 @@ func TestIsNamespaceIncluded(t *testing.T) {
 	}
 
 }
+
+func TestSetMonotonicLabels(t *testing.T) {
+	const psaLabel = "pod-security.kubernetes.io/enforce"
+	tests := []struct {
+		name  string
+		specs []string
+		fail  bool
+	}{
+		{name: "ok", specs: []string{psaLabel + "=privileged,baseline,restricted"}},
+		{name: "ok: single value", specs: []string{"foo=bar"}},
+		{name: "no values", specs: []string{psaLabel}, fail: true},
+		{name: "empty value", specs: []string{psaLabel + "=privileged,,restricted"}, fail: true},
+		{name: "duplicate value", specs: []string{psaLabel + "=privileged,privileged"}, fail: true},
+		{name: "duplicate key", specs: []string{"foo=bar", "foo=baz"}, fail: true},
+		{name: "illegal key", specs: []string{"foo bar=baz"}, fail: true},
+		{name: "HNC key", specs: []string{"tree.hnc.x-k8s.io/depth=1,2"}, fail: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			defer SetMonotonicLabels(nil)
+
+			err := SetMonotonicLabels(tc.specs)
+
+			if tc.fail {
+				g.Expect(err).ShouldNot(BeNil())
+			} else {
+				g.Expect(err).Should(BeNil())
+			}
+		})
+	}
+}
+
+func TestStricterLabelValue(t *testing.T) {
+	const psaLabel = "pod-security.kubernetes.io/enforce"
+	g := NewWithT(t)
+	g.Expect(SetMonotonicLabels([]string{psaLabel + "=privileged,baseline,restricted"})).Should(Succeed())
+	defer SetMonotonicLabels(nil)
+
+	g.Expect(IsMonotonicLabel(psaLabel)).Should(BeTrue())
+	g.Expect(IsManagedLabel(psaLabel)).Should(BeTrue())
+	g.Expect(IsMonotonicLabel("foo")).Should(BeFalse())
+
+	g.Expect(StricterLabelValue(psaLabel, "privileged", "baseline")).Should(Equal("baseline"))
+	g.Expect(StricterLabelValue(psaLabel, "restricted", "baseline")).Should(Equal("restricted"))
+	g.Expect(StricterLabelValue(psaLabel, "", "privileged")).Should(Equal("privileged"))
+	g.Expect(StricterLabelValue(psaLabel, "bogus", "privileged")).Should(Equal("privileged"))
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/util/validation"
@@ -22,6 +23,28 @@ func ValidateManagedLabels(labels []api.MetaKVP) field.ErrorList {
 		}
 		if fldErr := validateLabelValue(l.Key, l.Value, fldPath); fldErr != nil {
 			allErrs = append(allErrs, fldErr)
+		} else if IsMonotonicLabel(l.Key) && MonotonicLabelRank(l.Key, l.Value) < 0 {
+			vals := GetMonotonicLabelValues(l.Key)
+			allErrs = append(allErrs, field.NotSupported(fldPath.Key(l.Key), l.Value, vals))
+		}
+	}
+	return allErrs
+}
+
+// ValidateMonotonicLabels checks that none of the monotonic labels is looser than the value
+// inherited from the ancestors of the namespace, as returned by Namespace.GetMonotonicLabels.
+func ValidateMonotonicLabels(labels []api.MetaKVP, inherited map[string]string) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	fldPath := field.NewPath("spec", "labels")
+	for _, l := range labels {
+		iv, ok := inherited[l.Key]
+		if !ok || !IsMonotonicLabel(l.Key) {
+			continue
+		}
+		if MonotonicLabelRank(l.Key, l.Value) < MonotonicLabelRank(l.Key, iv) {
+			msg := fmt.Sprintf("cannot be looser than the value %q inherited from the ancestors", iv)
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(l.Key), l.Value, msg))
 		}
 	}
 	return allErrs
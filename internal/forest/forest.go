@@ -33,6 +33,13 @@ type Forest struct {
 
 	// nsListeners is a list of listeners
 	listeners []NamespaceListener
+
+	// rootPolicy is the root policy from the HNCConfiguration, which is needed by the validators.
+	rootPolicy *api.RootPolicy
+
+	// defaultParents are the default parent rules from the HNCConfiguration, which are needed by the
+	// namespace mutator.
+	defaultParents []api.DefaultParentRule
 }
 
 type namedNamespaces map[string]*Namespace
@@ -51,11 +58,32 @@ type TypeSyncer interface {
 	// GetMode gets the propagation mode of objects that are handled by the reconciler who implements the interface.
 	GetMode() api.SynchronizationMode
 
+	// SetUpdateStrategy sets what the reconciler who implements the interface does when a propagated
+	// object can't be updated because of its immutable fields.
+	SetUpdateStrategy(logr.Logger, api.UpdateStrategy)
+
+	// SetUnpropagatedFields sets the paths of any fields that shouldn't be propagated by the reconciler
+	// who implements the interface, in addition to the ones it always removes. The method also syncs
+	// objects in the cluster for the type handled by the reconciler if necessary.
+	SetUnpropagatedFields(context.Context, logr.Logger, []string) error
+
 	// CanPropagate returns true if Propagate mode or AllowPropagate mode is set
 	CanPropagate() bool
 
 	// GetNumPropagatedObjects returns the number of propagated objects on the apiserver.
 	GetNumPropagatedObjects() int
+
+	// GetNumDriftedObjects returns the number of objects that differ from what would be propagated,
+	// which is only tracked in the Audit mode.
+	GetNumDriftedObjects() int
+
+	// GetNumStaleObjects returns the number of objects that are out of date because propagation from
+	// their sources is paused.
+	GetNumStaleObjects() int
+
+	// Stop shuts down the reconciler who implements the interface, including any informers it uses
+	// to watch objects of its type. A stopped reconciler can't be restarted.
+	Stop()
 }
 
 // NamespaceListener has methods that get called whenever a namespace changes.
@@ -128,6 +156,21 @@ func (f *Forest) AddTypeSyncer(nss TypeSyncer) {
 	f.types = append(f.types, nss)
 }
 
+// RemoveTypeSyncer removes the reconciler for the given GVK from the types list, if it exists, and
+// forgets all the source objects of that GVK in every namespace. It's up to the caller to stop the
+// reconciler.
+func (f *Forest) RemoveTypeSyncer(gvk schema.GroupVersionKind) {
+	for i, t := range f.types {
+		if t.GetGVK() == gvk {
+			f.types = append(f.types[:i], f.types[i+1:]...)
+			break
+		}
+	}
+	for _, ns := range f.namespaces {
+		delete(ns.sourceObjects, gvk)
+	}
+}
+
 // GetTypeSyncer returns the reconciler for the given GVK or nil if the reconciler
 // does not exist.
 func (f *Forest) GetTypeSyncer(gvk schema.GroupVersionKind) TypeSyncer {
@@ -159,10 +202,40 @@ func (f *Forest) GetTypeSyncers() []TypeSyncer {
 	return types
 }
 
+// SetRootPolicy records the root policy from the HNCConfiguration, which may be nil.
+func (f *Forest) SetRootPolicy(p *api.RootPolicy) {
+	f.rootPolicy = p
+}
+
+// GetRootPolicy returns the root policy from the HNCConfiguration, or nil if it isn't set.
+func (f *Forest) GetRootPolicy() *api.RootPolicy {
+	return f.rootPolicy
+}
+
+// SetDefaultParentRules records the default parent rules from the HNCConfiguration.
+func (f *Forest) SetDefaultParentRules(rules []api.DefaultParentRule) {
+	f.defaultParents = rules
+}
+
+// GetDefaultParentRules returns the default parent rules from the HNCConfiguration.
+func (f *Forest) GetDefaultParentRules() []api.DefaultParentRule {
+	return f.defaultParents
+}
+
 func (f *Forest) AddListener(l NamespaceListener) {
 	f.listeners = append(f.listeners, l)
 }
 
+// RemoveListener removes a listener that was previously added via AddListener, if it exists.
+func (f *Forest) RemoveListener(l NamespaceListener) {
+	for i, ll := range f.listeners {
+		if ll == l {
+			f.listeners = append(f.listeners[:i], f.listeners[i+1:]...)
+			return
+		}
+	}
+}
+
 func (f *Forest) OnChangeNamespace(log logr.Logger, ns *Namespace) {
 	for _, l := range f.listeners {
 		l.OnChangeNamespace(log, ns)
@@ -11,6 +11,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	"sigs.k8s.io/hierarchical-namespaces/internal/config"
 )
 
 // While storing the V in GVK is not strictly necessary to match what's in the HNC type configuration,
@@ -29,6 +30,19 @@ type Namespace struct {
 	exists                 bool
 	allowCascadingDeletion bool
 
+	// halted is true if an administrator has explicitly halted this namespace (see
+	// HierarchyConfigurationSpec.Halted).
+	halted bool
+
+	// networkIsolation and networkIsolationExceptions are the network isolation settings explicitly
+	// set on this namespace (i.e., excluding anything inherited from ancestors).
+	networkIsolation           api.NetworkIsolationMode
+	networkIsolationExceptions []metav1.LabelSelector
+
+	// subnamespaceNaming is the subnamespace naming policy explicitly set on this namespace (i.e.,
+	// excluding anything inherited from ancestors).
+	subnamespaceNaming *api.SubnamespaceNamingPolicy
+
 	// labels store the original namespaces' labels, and are used for object propagation exceptions
 	// and to store the tree labels of external namespaces.
 	labels map[string]string
@@ -166,6 +180,164 @@ func (ns *Namespace) AllowsCascadingDeletion() bool {
 	return ns.parent.AllowsCascadingDeletion()
 }
 
+// UpdateNetworkIsolation updates the network isolation settings explicitly set on this namespace.
+// It returns true if they've changed, false otherwise.
+func (ns *Namespace) UpdateNetworkIsolation(mode api.NetworkIsolationMode, exceptions []metav1.LabelSelector) bool {
+	if len(exceptions) == 0 {
+		exceptions = nil
+	}
+	if ns.networkIsolation == mode && reflect.DeepEqual(ns.networkIsolationExceptions, exceptions) {
+		return false
+	}
+	ns.networkIsolation = mode
+	ns.networkIsolationExceptions = exceptions
+	return true
+}
+
+// GetNetworkIsolation returns the network isolation mode that applies to this namespace, the name
+// of the namespace that sets it, and the exceptions to it. Descendants can't loosen the isolation of
+// their ancestors, so this is the strictest mode set on this namespace or any of its ancestors; if
+// the same mode is set more than once, the nearest namespace wins, since a Subtree mode set on a
+// descendant only allows ingress from a smaller subtree. For the same reason, the exceptions are
+// the ones that every namespace isolating this one allows (see narrowExceptions). If no namespace
+// sets the mode, it returns NetworkIsolationNone.
+func (ns *Namespace) GetNetworkIsolation() (api.NetworkIsolationMode, string, []metav1.LabelSelector) {
+	var src *Namespace
+	var isolating []*Namespace
+	// Iterate rather than recurse, and stop at cycles.
+	visited := map[string]bool{}
+	for cur := ns; cur != nil && !visited[cur.name]; cur = cur.parent {
+		visited[cur.name] = true
+		if cur.networkIsolation == "" {
+			continue
+		}
+		if src == nil || src.networkIsolation.IsLooserThan(cur.networkIsolation) {
+			src = cur
+		}
+		if cur.networkIsolation != api.NetworkIsolationNone {
+			isolating = append(isolating, cur)
+		}
+	}
+	if src == nil {
+		return api.NetworkIsolationNone, "", nil
+	}
+	return src.networkIsolation, src.name, narrowExceptions(isolating)
+}
+
+// narrowExceptions returns the network isolation exceptions that are set on all of the given
+// namespaces, in the order set by the furthest one. This means that descendants can drop the
+// exceptions they inherit, but can never add their own. The namespaces are nearest first.
+func narrowExceptions(nss []*Namespace) []metav1.LabelSelector {
+	if len(nss) == 0 {
+		return nil
+	}
+	exceptions := nss[len(nss)-1].networkIsolationExceptions
+	for i := len(nss) - 2; i >= 0; i-- {
+		var kept []metav1.LabelSelector
+		for _, e := range exceptions {
+			if HasNetworkIsolationException(nss[i].networkIsolationExceptions, e) {
+				kept = append(kept, e)
+			}
+		}
+		exceptions = kept
+	}
+	return exceptions
+}
+
+// HasNetworkIsolationException returns true if the exception is in the list.
+func HasNetworkIsolationException(exceptions []metav1.LabelSelector, e metav1.LabelSelector) bool {
+	for i := range exceptions {
+		if reflect.DeepEqual(exceptions[i], e) {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateSubnamespaceNaming updates the subnamespace naming policy explicitly set on this namespace.
+// It returns true if it's changed, false otherwise.
+func (ns *Namespace) UpdateSubnamespaceNaming(p *api.SubnamespaceNamingPolicy) bool {
+	if reflect.DeepEqual(ns.subnamespaceNaming, p) {
+		return false
+	}
+	ns.subnamespaceNaming = p.DeepCopy()
+	return true
+}
+
+// GetSubnamespaceNamings returns the subnamespace naming policies that apply to new subnamespaces
+// of this namespace, and the names of the namespaces that set them. A new subnamespace must satisfy
+// the policies of this namespace and of all its ancestors, so that descendants can't loosen the
+// policies of their ancestors. They're returned nearest first.
+func (ns *Namespace) GetSubnamespaceNamings() ([]*api.SubnamespaceNamingPolicy, []string) {
+	var policies []*api.SubnamespaceNamingPolicy
+	var srcs []string
+	// Iterate rather than recurse, and stop at cycles.
+	visited := map[string]bool{}
+	for cur := ns; cur != nil && !visited[cur.name]; cur = cur.parent {
+		visited[cur.name] = true
+		if cur.subnamespaceNaming != nil {
+			policies = append(policies, cur.subnamespaceNaming)
+			srcs = append(srcs, cur.name)
+		}
+	}
+	return policies, srcs
+}
+
+// GetMonotonicLabels returns the strictest value of every monotonic managed label (see
+// config.IsMonotonicLabel) that is set on this namespace or any of its ancestors.
+func (ns *Namespace) GetMonotonicLabels() map[string]string {
+	labels := map[string]string{}
+	// Iterate rather than recurse, and stop at cycles.
+	visited := map[string]bool{}
+	for cur := ns; cur != nil && !visited[cur.name]; cur = cur.parent {
+		visited[cur.name] = true
+		for k, v := range cur.ManagedLabels {
+			if config.IsMonotonicLabel(k) {
+				labels[k] = config.StricterLabelValue(k, labels[k], v)
+			}
+		}
+	}
+	return labels
+}
+
+// GetHierarchyLabels returns the tree labels and managed labels that are inherited from this
+// namespace and its ancestors by a namespace that's depth levels below this one (i.e., zero for this
+// namespace itself). Monotonic managed labels (see config.IsMonotonicLabel) get the strictest value
+// in the ancestry; other managed labels set by ancestors override those set by descendants.
+func (ns *Namespace) GetHierarchyLabels(depth int) map[string]string {
+	labels := map[string]string{}
+	for cur := ns; cur != nil; cur = cur.parent {
+		// Set the tree label from this layer of hierarchy
+		labels[cur.name+api.LabelTreeDepthSuffix] = strconv.Itoa(depth)
+
+		// Add any managed labels. TODO: add conditions for conflicts.
+		for k, v := range cur.ManagedLabels {
+			if config.IsMonotonicLabel(k) {
+				v = config.StricterLabelValue(k, labels[k], v)
+			}
+			labels[k] = v
+		}
+
+		// If the root is an external namespace, add all its external tree labels too. Note it's
+		// impossible to have an external namespace as a non-root, which is enforced by both admission
+		// controllers and the reconciler, so technically we don't need to break out of the loop here.
+		// But it's cleaner.
+		if cur.IsExternal() {
+			for k, v := range cur.GetTreeLabels() {
+				labels[k] = strconv.Itoa(depth + v)
+			}
+			break
+		}
+
+		// Stop if this namespace is halted, which could indicate a cycle or orphan.
+		if cur.IsHalted() {
+			break
+		}
+		depth++
+	}
+	return labels
+}
+
 // SetAnchors updates the anchors and returns a difference between the new/old list.
 func (ns *Namespace) SetAnchors(anchors []string) (diff []string) {
 	add := make(map[string]bool)
@@ -36,6 +36,36 @@ func (ns *Namespace) GetHaltedRoot() string {
 	return ns.Parent().GetHaltedRoot()
 }
 
+// UpdateAdminHalted records whether an administrator has explicitly halted this namespace. It
+// returns true if this has changed, false otherwise.
+func (ns *Namespace) UpdateAdminHalted(halted bool) bool {
+	if ns.halted == halted {
+		return false
+	}
+	ns.halted = halted
+	return true
+}
+
+// IsAdminHalted returns true if an administrator has explicitly halted this namespace (as opposed to
+// one of its ancestors).
+func (ns *Namespace) IsAdminHalted() bool {
+	return ns.halted
+}
+
+// GetAdminHaltedRoot returns the name of the nearest namespace, starting with this one, that an
+// administrator has explicitly halted, or the empty string if neither this namespace nor any of its
+// ancestors have been halted by an administrator.
+func (ns *Namespace) GetAdminHaltedRoot() string {
+	visited := map[string]bool{}
+	for cur := ns; cur != nil && !visited[cur.name]; cur = cur.parent {
+		if cur.halted {
+			return cur.name
+		}
+		visited[cur.name] = true
+	}
+	return ""
+}
+
 // SetCondition adds a new condition to the current condition list.
 //
 // Any condition with ReasonAncestor is ignored; this is added dynamically when calling
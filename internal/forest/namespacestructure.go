@@ -204,3 +204,35 @@ func (ns *Namespace) FullDescendantNames() []string {
 	sort.Strings(fd)
 	return fd
 }
+
+// SubnamespaceDescendantNames returns a sorted list of descendant namespaces that are connected to
+// this namespace only via subnamespaces, or nil if there are none. These are the namespaces that are
+// deleted along with this one if cascading deletion is allowed; full namespaces, and anything below
+// them, are never deleted implicitly.
+//
+// This method is cycle-safe.
+func (ns *Namespace) SubnamespaceDescendantNames() []string {
+	ds := map[string]bool{ns.name: true}
+	ns.populateSubnamespaceDescendants(ds)
+	delete(ds, ns.name)
+	if len(ds) == 0 {
+		return nil
+	}
+	d := []string{}
+	for k := range ds {
+		d = append(d, k)
+	}
+	sort.Strings(d)
+	return d
+}
+
+func (ns *Namespace) populateSubnamespaceDescendants(d map[string]bool) {
+	for _, c := range ns.ChildNames() {
+		cns := ns.forest.Get(c)
+		if d[c] || !cns.IsSub {
+			continue
+		}
+		d[c] = true
+		cns.populateSubnamespaceDescendants(d)
+	}
+}
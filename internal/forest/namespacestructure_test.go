@@ -111,3 +111,51 @@ func TestDescendantNames(t *testing.T) {
 		})
 	}
 }
+
+func TestSubnamespaceDescendantNames(t *testing.T) {
+	// Test a tree has the following structure, where full namespaces are marked with a "*":
+	//      a
+	//    /   \
+	//   b     c*
+	//  / \    |
+	// x*  y   z
+	//     |
+	//     w
+	kinship := map[string][]string{
+		"a": {"b", "c"},
+		"b": {"x", "y"},
+		"c": {"z"},
+		"y": {"w"},
+	}
+	full := map[string]bool{"c": true, "x": true}
+
+	// Create the forest
+	f := NewForest()
+	for parentName, childrenNames := range kinship {
+		parent := f.Get(parentName)
+		for _, childName := range childrenNames {
+			child := f.Get(childName)
+			child.SetParent(parent)
+			child.IsSub = !full[childName]
+		}
+	}
+
+	tests := []struct {
+		name string
+		root string
+		want []string
+	}{
+		{name: "leaf", root: "w", want: nil},
+		{name: "full namespace", root: "c", want: []string{"z"}},
+		{name: "skips full children", root: "b", want: []string{"w", "y"}},
+		{name: "skips full subtrees", root: "a", want: []string{"b", "w", "y"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			root := f.Get(tc.root)
+			g.Expect(root.SubnamespaceDescendantNames()).Should(Equal(tc.want))
+		})
+	}
+}
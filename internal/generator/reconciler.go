@@ -0,0 +1,412 @@
+// Package generator contains the reconciler and validator for HierarchicalObjectGenerators, which
+// render a templated object into every selected descendant of their namespace.
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	"sigs.k8s.io/hierarchical-namespaces/internal/config"
+	"sigs.k8s.io/hierarchical-namespaces/internal/forest"
+	"sigs.k8s.io/hierarchical-namespaces/internal/logutils"
+)
+
+// fieldManager is the field manager used to apply generated objects. It's different from the one
+// used by the object reconcilers so that the two can never take over each other's fields.
+const fieldManager = "hnc-generator"
+
+// errConflict is returned when a generated object would overwrite an object that wasn't generated
+// by the same generator.
+var errConflict = errors.New("an object with the same name already exists and was not generated by this generator")
+
+// Reconciler generates the objects described by HierarchicalObjectGenerators, and deletes them once
+// they no longer apply (or the generator is deleted).
+type Reconciler struct {
+	client.Client
+	Log    logr.Logger
+	Forest *forest.Forest
+
+	// APIReader reads directly from the apiserver. Generated objects can be of any type, so we read
+	// them this way instead of starting an informer for every type that's ever used in a template.
+	APIReader client.Reader
+
+	EventRecorder record.EventRecorder
+
+	// trigger is used to enqueue generators when the hierarchy changes.
+	trigger chan event.GenericEvent
+
+	// generators is the set of all generators that currently exist, so that they can all be
+	// enqueued when the hierarchy changes.
+	generatorsLock sync.Mutex
+	generators     map[types.NamespacedName]bool
+}
+
+// renderResult describes what should be generated by a generator given the current state of the
+// forest.
+type renderResult struct {
+	// desired holds the object to generate in each selected namespace.
+	desired map[string]*unstructured.Unstructured
+
+	// frozen holds the namespaces whose generated objects must be neither written nor deleted,
+	// because activities are halted in them.
+	frozen map[string]bool
+
+	// failures holds one message per namespace in which the object couldn't be rendered.
+	failures []string
+}
+
+// +kubebuilder:rbac:groups=hnc.x-k8s.io,resources=hierarchicalobjectgenerators,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=hnc.x-k8s.io,resources=hierarchicalobjectgenerators/status,verbs=get;update;patch
+
+// Reconcile generates the object described by a generator in every selected namespace, deletes any
+// objects that it previously generated but that no longer apply, and updates the status of the
+// generator.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logutils.WithRID(r.Log).WithValues("trigger", req.NamespacedName)
+
+	if !config.IsManagedNamespace(req.Namespace) {
+		return ctrl.Result{}, nil
+	}
+
+	inst := &api.HierarchicalObjectGenerator{}
+	if err := r.Get(ctx, req.NamespacedName, inst); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.forget(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "Couldn't read")
+		return ctrl.Result{}, err
+	}
+
+	if !inst.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.onDelete(ctx, log, inst)
+	}
+	r.remember(req.NamespacedName)
+
+	// Make sure we get a chance to clean up before the generator is deleted. Updating the object
+	// causes it to be reconciled again, so we can stop here.
+	if !controllerutil.ContainsFinalizer(inst, api.FinalizerGeneratedObjects) {
+		log.V(1).Info("Adding finalizer")
+		controllerutil.AddFinalizer(inst, api.FinalizerGeneratedObjects)
+		return ctrl.Result{}, r.Update(ctx, inst)
+	}
+
+	oldStatus := inst.Status.DeepCopy()
+	err := r.generate(ctx, log, inst)
+	// The conditions are recomputed from scratch, so keep the transition times of the ones that were
+	// already set; otherwise, we'd update the status every time.
+	for i := range inst.Status.Conditions {
+		c := &inst.Status.Conditions[i]
+		if old := meta.FindStatusCondition(oldStatus.Conditions, c.Type); old != nil && old.Status == c.Status {
+			c.LastTransitionTime = old.LastTransitionTime
+		}
+	}
+	if !reflect.DeepEqual(oldStatus, &inst.Status) {
+		log.V(1).Info("Updating status", "numGenerated", inst.Status.NumGeneratedObjects)
+		if uerr := r.Status().Update(ctx, inst); uerr != nil {
+			log.Error(uerr, "Couldn't update status")
+			return ctrl.Result{}, uerr
+		}
+	}
+	return ctrl.Result{}, err
+}
+
+// generate writes the desired objects, deletes the stale ones and updates the status of inst
+// accordingly. It only returns errors that are worth retrying; problems caused by the generator
+// itself are reported in its status.
+func (r *Reconciler) generate(ctx context.Context, log logr.Logger, inst *api.HierarchicalObjectGenerator) error {
+	inst.Status.Conditions = nil
+
+	tmpl, err := parseTemplate(inst)
+	if err != nil {
+		// Leave any objects we've already generated alone until the template is fixed, rather than
+		// deleting them all.
+		log.Info("Invalid template", "error", err)
+		r.setFailed(inst, api.ReasonInvalidTemplate, err.Error())
+		return nil
+	}
+
+	res, err := r.renderAll(inst, tmpl)
+	if err != nil {
+		log.Info("Invalid selector", "error", err)
+		r.setFailed(inst, api.ReasonInvalidTemplate, err.Error())
+		return nil
+	}
+	if len(res.failures) > 0 {
+		r.setFailed(inst, api.ReasonInvalidTemplate, summarize(res.failures))
+	}
+
+	// Write the desired objects.
+	var retryErr error
+	conflicts := []string{}
+	writeFailures := []string{}
+	numGenerated := 0
+	for _, nsnm := range sortedKeys(res.desired) {
+		obj := res.desired[nsnm]
+		err := r.write(ctx, inst, obj)
+		switch {
+		case err == nil:
+			numGenerated++
+			continue
+		case errors.Is(err, errConflict):
+			conflicts = append(conflicts, fmt.Sprintf("%s: %s", nsnm, err))
+		default:
+			writeFailures = append(writeFailures, fmt.Sprintf("%s: %s", nsnm, err))
+			retryErr = err
+		}
+		msg := fmt.Sprintf("Couldn't generate %s %q in namespace %q: %s", obj.GetKind(), obj.GetName(), nsnm, err)
+		log.Info(msg)
+		r.EventRecorder.Event(inst, "Warning", api.EventCannotGenerateObject, msg)
+	}
+	if len(conflicts) > 0 {
+		r.setFailed(inst, api.ReasonObjectConflict, summarize(conflicts))
+	}
+	if len(writeFailures) > 0 {
+		r.setFailed(inst, api.ReasonCannotWrite, summarize(writeFailures))
+	}
+
+	// Delete the objects that are no longer desired. This includes objects of the previous type if
+	// the type in the template has changed.
+	gvks := []schema.GroupVersionKind{tmpl.GroupVersionKind()}
+	if inst.Status.GeneratedKind != "" {
+		old := schema.FromAPIVersionAndKind(inst.Status.GeneratedAPIVersion, inst.Status.GeneratedKind)
+		if old != gvks[0] {
+			gvks = append(gvks, old)
+		}
+	}
+	for _, gvk := range gvks {
+		if err := r.deleteStale(ctx, log, inst, gvk, res); err != nil {
+			log.Error(err, "Couldn't delete stale objects", "gvk", gvk)
+			retryErr = err
+		}
+	}
+
+	inst.Status.NumGeneratedObjects = numGenerated
+	inst.Status.GeneratedAPIVersion = tmpl.GetAPIVersion()
+	inst.Status.GeneratedKind = tmpl.GetKind()
+	return retryErr
+}
+
+// renderAll renders the template in every namespace selected by inst.
+func (r *Reconciler) renderAll(inst *api.HierarchicalObjectGenerator, tmpl *unstructured.Unstructured) (renderResult, error) {
+	res := renderResult{
+		desired: map[string]*unstructured.Unstructured{},
+		frozen:  map[string]bool{},
+	}
+	sel := labels.Everything()
+	if inst.Spec.Selector != nil {
+		var err error
+		if sel, err = metav1.LabelSelectorAsSelector(inst.Spec.Selector); err != nil {
+			return res, fmt.Errorf("invalid selector: %w", err)
+		}
+	}
+
+	r.Forest.Lock()
+	defer r.Forest.Unlock()
+
+	nsnms := r.Forest.Get(inst.Namespace).DescendantNames()
+	if inst.Spec.IncludeSelf {
+		nsnms = append([]string{inst.Namespace}, nsnms...)
+	}
+	for _, nsnm := range nsnms {
+		ns := r.Forest.Get(nsnm)
+		if ns.IsHalted() {
+			res.frozen[nsnm] = true
+			continue
+		}
+		if !ns.Exists() || !config.IsManagedNamespace(nsnm) || !sel.Matches(ns.GetLabels()) {
+			continue
+		}
+		obj, err := render(inst, tmpl, getTemplateData(r.Forest, inst.Namespace, ns))
+		if err != nil {
+			res.failures = append(res.failures, fmt.Sprintf("%s: %s", nsnm, err))
+			// Don't delete the object that we generated before; it will be updated once the
+			// template can be rendered again.
+			res.frozen[nsnm] = true
+			continue
+		}
+		res.desired[nsnm] = obj
+	}
+	return res, nil
+}
+
+// write applies a generated object, unless an object with the same name exists in the same
+// namespace and wasn't generated by inst.
+func (r *Reconciler) write(ctx context.Context, inst *api.HierarchicalObjectGenerator, obj *unstructured.Unstructured) error {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(obj.GroupVersionKind())
+	err := r.APIReader.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	switch {
+	case err == nil:
+		if existing.GetAnnotations()[api.AnnotationGenerator] != generatorName(inst) {
+			return errConflict
+		}
+	case !apierrors.IsNotFound(err):
+		return err
+	}
+	return r.Patch(ctx, obj, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership)
+}
+
+// deleteStale deletes all objects of the given type that were generated by inst but that are no
+// longer desired. Namespaces in res.frozen are left alone.
+func (r *Reconciler) deleteStale(ctx context.Context, log logr.Logger, inst *api.HierarchicalObjectGenerator, gvk schema.GroupVersionKind, res renderResult) error {
+	existing, err := r.listGenerated(ctx, inst, gvk)
+	if err != nil {
+		return err
+	}
+	for i := range existing {
+		obj := &existing[i]
+		if res.frozen[obj.GetNamespace()] {
+			continue
+		}
+		if want := res.desired[obj.GetNamespace()]; want != nil && want.GroupVersionKind() == gvk && want.GetName() == obj.GetName() {
+			continue
+		}
+		log.Info("Deleting stale generated object", "gvk", gvk, "object", client.ObjectKeyFromObject(obj))
+		if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// listGenerated returns all objects of the given type that were generated by inst. If the type no
+// longer exists, there can't be any objects of that type left.
+func (r *Reconciler) listGenerated(ctx context.Context, inst *api.HierarchicalObjectGenerator, gvk schema.GroupVersionKind) ([]unstructured.Unstructured, error) {
+	ul := &unstructured.UnstructuredList{}
+	ul.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := r.APIReader.List(ctx, ul, client.MatchingLabels{api.LabelGeneratedFrom: inst.Namespace}); err != nil {
+		if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	objs := []unstructured.Unstructured{}
+	for _, obj := range ul.Items {
+		// Several generators in the same namespace may generate objects of the same type.
+		if obj.GetAnnotations()[api.AnnotationGenerator] == generatorName(inst) {
+			objs = append(objs, obj)
+		}
+	}
+	return objs, nil
+}
+
+// onDelete deletes all objects generated by inst and then removes its finalizer so that it can be
+// deleted.
+func (r *Reconciler) onDelete(ctx context.Context, log logr.Logger, inst *api.HierarchicalObjectGenerator) error {
+	r.forget(types.NamespacedName{Namespace: inst.Namespace, Name: inst.Name})
+	if !controllerutil.ContainsFinalizer(inst, api.FinalizerGeneratedObjects) {
+		return nil
+	}
+
+	if inst.Status.GeneratedKind != "" {
+		gvk := schema.FromAPIVersionAndKind(inst.Status.GeneratedAPIVersion, inst.Status.GeneratedKind)
+		if err := r.deleteStale(ctx, log, inst, gvk, renderResult{}); err != nil {
+			log.Error(err, "Couldn't delete generated objects")
+			return err
+		}
+	}
+
+	log.Info("Removing finalizer")
+	controllerutil.RemoveFinalizer(inst, api.FinalizerGeneratedObjects)
+	return r.Update(ctx, inst)
+}
+
+// setFailed sets (or adds to) the GenerationFailed condition on inst.
+func (r *Reconciler) setFailed(inst *api.HierarchicalObjectGenerator, reason, msg string) {
+	if old := meta.FindStatusCondition(inst.Status.Conditions, api.ConditionGenerationFailed); old != nil {
+		// Keep the first reason, but report all the problems.
+		reason = old.Reason
+		msg = old.Message + "; " + msg
+	}
+	meta.SetStatusCondition(&inst.Status.Conditions, metav1.Condition{
+		Type:    api.ConditionGenerationFailed,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: msg,
+	})
+}
+
+// OnChangeNamespace enqueues all generators, since any namespace change (its labels, its parent,
+// whether it exists...) can change where objects should be generated and what they should contain.
+// This occurs in a goroutine so the caller doesn't block; since the reconciler is never
+// garbage-collected, this is safe.
+func (r *Reconciler) OnChangeNamespace(log logr.Logger, ns *forest.Namespace) {
+	r.generatorsLock.Lock()
+	nnms := make([]types.NamespacedName, 0, len(r.generators))
+	for nnm := range r.generators {
+		nnms = append(nnms, nnm)
+	}
+	r.generatorsLock.Unlock()
+
+	go func() {
+		for _, nnm := range nnms {
+			inst := &api.HierarchicalObjectGenerator{}
+			inst.ObjectMeta.Name = nnm.Name
+			inst.ObjectMeta.Namespace = nnm.Namespace
+			r.trigger <- event.GenericEvent{Object: inst}
+		}
+	}()
+}
+
+func (r *Reconciler) remember(nnm types.NamespacedName) {
+	r.generatorsLock.Lock()
+	defer r.generatorsLock.Unlock()
+	r.generators[nnm] = true
+}
+
+func (r *Reconciler) forget(nnm types.NamespacedName) {
+	r.generatorsLock.Lock()
+	defer r.generatorsLock.Unlock()
+	delete(r.generators, nnm)
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.trigger = make(chan event.GenericEvent)
+	r.generators = map[types.NamespacedName]bool{}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&api.HierarchicalObjectGenerator{}).
+		Watches(&source.Channel{Source: r.trigger}, &handler.EnqueueRequestForObject{}).
+		Complete(r)
+}
+
+// summarize joins a list of per-namespace problems into a single message, truncating it if there
+// are too many.
+func summarize(msgs []string) string {
+	const maxMsgs = 5
+	if len(msgs) <= maxMsgs {
+		return strings.Join(msgs, "; ")
+	}
+	return fmt.Sprintf("%s; and %d more", strings.Join(msgs[:maxMsgs], "; "), len(msgs)-maxMsgs)
+}
+
+func sortedKeys(m map[string]*unstructured.Unstructured) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
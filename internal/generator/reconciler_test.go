@@ -0,0 +1,165 @@
+package generator_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	. "sigs.k8s.io/hierarchical-namespaces/internal/integtest"
+)
+
+func TestInteg(t *testing.T) {
+	HNCRun(t, "HierarchicalObjectGenerator reconciler")
+}
+
+var _ = BeforeSuite(HNCBeforeSuite)
+var _ = AfterSuite(HNCAfterSuite)
+
+const (
+	genName  = "gen"
+	template = `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm-{{ .Namespace }}"}, "data": {"path": "{{ join .Ancestors \"/\" }}"}}`
+)
+
+var _ = Describe("HierarchicalObjectGenerator", func() {
+	ctx := context.Background()
+
+	var (
+		fooName string
+		barName string
+		bazName string
+	)
+
+	BeforeEach(func() {
+		fooName = CreateNS(ctx, "foo")
+		barName = CreateNS(ctx, "bar")
+		bazName = CreateNS(ctx, "baz")
+		SetParent(ctx, barName, fooName)
+		SetParent(ctx, bazName, barName)
+	})
+
+	It("should generate objects in all descendants", func() {
+		createGenerator(ctx, fooName, template, nil)
+
+		Eventually(getData(ctx, barName, "cm-"+barName)).Should(Equal(fooName + "/" + barName))
+		Eventually(getData(ctx, bazName, "cm-"+bazName)).Should(Equal(fooName + "/" + barName + "/" + bazName))
+		Eventually(getNumGenerated(ctx, fooName)).Should(Equal(2))
+		Expect(HasObject(ctx, "configmaps", fooName, "cm-"+fooName)()).Should(BeFalse())
+
+		// The generated objects aren't propagated.
+		Consistently(HasObject(ctx, "configmaps", bazName, "cm-"+barName)).Should(BeFalse())
+	})
+
+	It("should only generate objects in selected namespaces", func() {
+		createGenerator(ctx, fooName, template, &metav1.LabelSelector{MatchLabels: map[string]string{"generate": "yes"}})
+		Consistently(HasObject(ctx, "configmaps", barName, "cm-"+barName)).Should(BeFalse())
+
+		AddNamespaceLabel(ctx, bazName, "generate", "yes")
+		Eventually(HasObject(ctx, "configmaps", bazName, "cm-"+bazName)).Should(BeTrue())
+		Expect(HasObject(ctx, "configmaps", barName, "cm-"+barName)()).Should(BeFalse())
+
+		RemoveNamespaceLabel(ctx, bazName, "generate")
+		Eventually(HasObject(ctx, "configmaps", bazName, "cm-"+bazName)).Should(BeFalse())
+	})
+
+	It("should update and clean up generated objects when the hierarchy changes", func() {
+		createGenerator(ctx, fooName, template, nil)
+		Eventually(getData(ctx, bazName, "cm-"+bazName)).Should(Equal(fooName + "/" + barName + "/" + bazName))
+
+		// Move baz directly under foo; its object should be updated.
+		SetParent(ctx, bazName, fooName)
+		Eventually(getData(ctx, bazName, "cm-"+bazName)).Should(Equal(fooName + "/" + bazName))
+
+		// Move bar out of foo's subtree; its object should be deleted.
+		SetParent(ctx, barName, "")
+		Eventually(HasObject(ctx, "configmaps", barName, "cm-"+barName)).Should(BeFalse())
+		Eventually(HasObject(ctx, "configmaps", bazName, "cm-"+bazName)).Should(BeTrue())
+	})
+
+	It("should not overwrite objects that it didn't generate", func() {
+		MakeObject(ctx, "configmaps", barName, "cm-"+barName)
+		createGenerator(ctx, fooName, template, nil)
+
+		Eventually(HasObject(ctx, "configmaps", bazName, "cm-"+bazName)).Should(BeTrue())
+		Eventually(hasFailedCondition(ctx, fooName, api.ReasonObjectConflict)).Should(BeTrue())
+		Expect(getData(ctx, barName, "cm-"+barName)()).Should(Equal(""))
+	})
+
+	It("should delete all generated objects when the generator is deleted", func() {
+		createGenerator(ctx, fooName, template, nil)
+		Eventually(HasObject(ctx, "configmaps", barName, "cm-"+barName)).Should(BeTrue())
+		Eventually(HasObject(ctx, "configmaps", bazName, "cm-"+bazName)).Should(BeTrue())
+
+		inst := &api.HierarchicalObjectGenerator{}
+		inst.Namespace = fooName
+		inst.Name = genName
+		Expect(K8sClient.Delete(ctx, inst)).Should(Succeed())
+
+		Eventually(HasObject(ctx, "configmaps", barName, "cm-"+barName)).Should(BeFalse())
+		Eventually(HasObject(ctx, "configmaps", bazName, "cm-"+bazName)).Should(BeFalse())
+		Eventually(func() bool {
+			err := K8sClient.Get(ctx, types.NamespacedName{Namespace: fooName, Name: genName}, inst)
+			return errors.IsNotFound(err)
+		}).Should(BeTrue())
+	})
+})
+
+func createGenerator(ctx context.Context, nsnm, tmpl string, sel *metav1.LabelSelector) {
+	inst := &api.HierarchicalObjectGenerator{}
+	inst.Namespace = nsnm
+	inst.Name = genName
+	inst.Spec.Template = runtime.RawExtension{Raw: []byte(tmpl)}
+	inst.Spec.Selector = sel
+	ExpectWithOffset(1, K8sClient.Create(ctx, inst)).Should(Succeed())
+}
+
+func getGenerator(ctx context.Context, nsnm string) *api.HierarchicalObjectGenerator {
+	inst := &api.HierarchicalObjectGenerator{}
+	if err := K8sClient.Get(ctx, types.NamespacedName{Namespace: nsnm, Name: genName}, inst); err != nil {
+		return nil
+	}
+	return inst
+}
+
+func getNumGenerated(ctx context.Context, nsnm string) func() int {
+	return func() int {
+		inst := getGenerator(ctx, nsnm)
+		if inst == nil {
+			return -1
+		}
+		return inst.Status.NumGeneratedObjects
+	}
+}
+
+func hasFailedCondition(ctx context.Context, nsnm, reason string) func() bool {
+	return func() bool {
+		inst := getGenerator(ctx, nsnm)
+		if inst == nil {
+			return false
+		}
+		for _, c := range inst.Status.Conditions {
+			if c.Type == api.ConditionGenerationFailed && c.Reason == reason {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func getData(ctx context.Context, nsnm, nm string) func() string {
+	return func() string {
+		inst, err := GetObject(ctx, "configmaps", nsnm, nm)
+		if err != nil {
+			return ""
+		}
+		data, _ := inst.Object["data"].(map[string]interface{})
+		path, _ := data["path"].(string)
+		return path
+	}
+}
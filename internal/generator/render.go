@@ -0,0 +1,384 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	"sigs.k8s.io/hierarchical-namespaces/internal/forest"
+)
+
+// templateData is the data that's available to the templates in a HierarchicalObjectGenerator. It's
+// documented in the user guide, so please keep the two in sync.
+type templateData struct {
+	// Namespace is the name of the namespace in which the object is generated.
+	Namespace string
+
+	// Parent is the name of the parent of Namespace, if any.
+	Parent string
+
+	// Ancestors are the names of all ancestors of Namespace, starting at the root and ending with
+	// Namespace itself.
+	Ancestors []string
+
+	// Depth is the depth of Namespace below the namespace of the generator (0 if they're the same).
+	Depth int
+
+	// Labels are all the labels on Namespace, including the tree labels and managed labels.
+	Labels map[string]string
+
+	// ManagedLabels and ManagedAnnotations are the managed labels and annotations of Namespace,
+	// including those that it inherits from its ancestors.
+	ManagedLabels      map[string]string
+	ManagedAnnotations map[string]string
+}
+
+// The templates are written by namespace administrators but rendered by the manager, once for
+// every selected namespace whenever the hierarchy changes, so these limit how much a template can
+// cost to render.
+const (
+	// maxTemplateSize is the maximum size of a generator's template, in bytes.
+	maxTemplateSize = 64 * 1024
+
+	// maxRenderedSize is the maximum total size of the strings rendered from a template for a single
+	// namespace, in bytes. Functions can't return strings that are larger than what's left of it.
+	maxRenderedSize = 256 * 1024
+
+	// maxRangeIterations is the maximum total number of iterations of all the range actions in a
+	// template, when it's rendered for a single namespace.
+	maxRangeIterations = 10000
+
+	// rangeIterationFunc is the function that's called at the start of every range iteration to count
+	// them (see limitRanges). It's not meant to be called directly.
+	rangeIterationFunc = "hncRangeIteration"
+)
+
+// printfWidthRE matches the widths and precisions of the verbs in a printf format.
+var printfWidthRE = regexp.MustCompile(`%[-+# 0]*(\*|[0-9]+)?(\.(\*|[0-9]+))?`)
+
+// budget tracks what's left of the limits while a template is rendered for a single namespace.
+type budget struct {
+	size       int
+	iterations int
+}
+
+func newBudget() *budget {
+	return &budget{size: maxRenderedSize, iterations: maxRangeIterations}
+}
+
+// reserve checks that a string of the given size fits in what's left of the budget.
+func (b *budget) reserve(n int) error {
+	if n > b.size {
+		return fmt.Errorf("the rendered template would be larger than %d bytes", maxRenderedSize)
+	}
+	return nil
+}
+
+// funcs returns the functions available to templates, in addition to the Go builtins. The ones that
+// can build large strings are limited by the budget, including the builtins that format strings.
+func (b *budget) funcs() template.FuncMap {
+	return template.FuncMap{
+		"join": func(elems []string, sep string) (string, error) {
+			n := len(sep) * (len(elems) - 1)
+			for _, e := range elems {
+				n += len(e)
+			}
+			if err := b.reserve(n); err != nil {
+				return "", err
+			}
+			return strings.Join(elems, sep), nil
+		},
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		"replace": func(s, old, new string) (string, error) {
+			count := strings.Count(s, old)
+			if err := b.reserve(len(s) + count*(len(new)-len(old))); err != nil {
+				return "", err
+			}
+			return strings.ReplaceAll(s, old, new), nil
+		},
+		"print":    b.limit(fmt.Sprint),
+		"println":  b.limit(fmt.Sprintln),
+		"html":     b.limit(template.HTMLEscaper),
+		"js":       b.limit(template.JSEscaper),
+		"urlquery": b.limit(template.URLQueryEscaper),
+		"printf": func(format string, args ...interface{}) (string, error) {
+			n := len(format)
+			for _, m := range printfWidthRE.FindAllStringSubmatch(format, -1) {
+				for _, w := range []string{m[1], m[3]} {
+					if w == "*" {
+						return "", errors.New("printf widths and precisions must not be arguments")
+					}
+					width, err := strconv.Atoi(w)
+					if w != "" && (err != nil || b.reserve(n+width) != nil) {
+						return "", fmt.Errorf("the printf widths and precisions are larger than %d bytes", maxRenderedSize)
+					}
+					n += width
+				}
+			}
+			return b.limit(func(args ...interface{}) string { return fmt.Sprintf(format, args...) })(args...)
+		},
+		rangeIterationFunc: func() (string, error) {
+			if b.iterations == 0 {
+				return "", fmt.Errorf("the template would run more than %d range iterations", maxRangeIterations)
+			}
+			b.iterations--
+			return "", nil
+		},
+	}
+}
+
+// limit returns a function that calls f, unless the arguments or the result of f don't fit in what's
+// left of the budget. The arguments are formatted as strings to measure them, one by one so that
+// this stops as soon as they're too large.
+func (b *budget) limit(f func(...interface{}) string) func(...interface{}) (string, error) {
+	return func(args ...interface{}) (string, error) {
+		n := 0
+		for _, a := range args {
+			if s, ok := a.(string); ok {
+				n += len(s)
+			} else {
+				n += len(fmt.Sprint(a))
+			}
+			if err := b.reserve(n); err != nil {
+				return "", err
+			}
+		}
+		out := f(args...)
+		if err := b.reserve(len(out)); err != nil {
+			return "", err
+		}
+		return out, nil
+	}
+}
+
+// Write implements io.Writer, spending the budget on everything that's written.
+func (b *budget) Write(p []byte) (int, error) {
+	if err := b.reserve(len(p)); err != nil {
+		return 0, err
+	}
+	b.size -= len(p)
+	return len(p), nil
+}
+
+// newTemplate parses a single string of a generator's template, using the functions of the budget.
+// Templates can't define or call other templates, since they could then recurse, and every range
+// action calls rangeIterationFunc so that the budget can limit the number of iterations.
+func newTemplate(path, s string, b *budget) (*template.Template, error) {
+	t, err := template.New(path).Funcs(b.funcs()).Option("missingkey=zero").Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Templates()) > 1 {
+		return nil, fmt.Errorf("template %s: templates must not define other templates", path)
+	}
+	iter, err := template.New(rangeIterationFunc).Funcs(b.funcs()).Parse("{{" + rangeIterationFunc + "}}")
+	if err != nil {
+		return nil, err
+	}
+	if err := limitRanges(path, t.Tree.Root, iter.Tree.Root.Nodes[0]); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// limitRanges inserts the iteration node at the start of the body of every range action in the
+// list, and returns an error if the list calls another template.
+func limitRanges(path string, l *parse.ListNode, iter parse.Node) error {
+	if l == nil {
+		return nil
+	}
+	for _, n := range l.Nodes {
+		var branch *parse.BranchNode
+		switch tn := n.(type) {
+		case *parse.TemplateNode:
+			return fmt.Errorf("template %s: templates must not call other templates", path)
+		case *parse.IfNode:
+			branch = &tn.BranchNode
+		case *parse.WithNode:
+			branch = &tn.BranchNode
+		case *parse.RangeNode:
+			branch = &tn.BranchNode
+			tn.List.Nodes = append([]parse.Node{iter}, tn.List.Nodes...)
+		default:
+			continue
+		}
+		if err := limitRanges(path, branch.List, iter); err != nil {
+			return err
+		}
+		if err := limitRanges(path, branch.ElseList, iter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getTemplateData returns the template data for the given namespace, which must be in the subtree
+// of the generator's namespace. The forest must be locked.
+func getTemplateData(f *forest.Forest, genNS string, ns *forest.Namespace) templateData {
+	d := templateData{
+		Namespace:          ns.Name(),
+		Ancestors:          ns.AncestryNames(),
+		Labels:             map[string]string{},
+		ManagedLabels:      map[string]string{},
+		ManagedAnnotations: map[string]string{},
+	}
+	if ns.Parent() != nil {
+		d.Parent = ns.Parent().Name()
+	}
+	d.Depth = ns.GetTreeLabels()[genNS+api.LabelTreeDepthSuffix]
+	for k, v := range ns.GetLabels() {
+		d.Labels[k] = v
+	}
+	// Descendants override their ancestors, so start at the root.
+	for _, anm := range d.Ancestors {
+		anc := f.Get(anm)
+		for k, v := range anc.ManagedLabels {
+			d.ManagedLabels[k] = v
+		}
+		for k, v := range anc.ManagedAnnotations {
+			d.ManagedAnnotations[k] = v
+		}
+	}
+	return d
+}
+
+// parseTemplate parses the template in the generator into an unstructured object, and checks that
+// it has the fields that are required to generate it, and that all strings in it are valid
+// templates. It doesn't check that the result of rendering the template is valid, since that
+// depends on the namespace it's rendered in.
+func parseTemplate(inst *api.HierarchicalObjectGenerator) (*unstructured.Unstructured, error) {
+	if len(inst.Spec.Template.Raw) == 0 {
+		return nil, errors.New("the template must not be empty")
+	}
+	if len(inst.Spec.Template.Raw) > maxTemplateSize {
+		return nil, fmt.Errorf("the template must not be larger than %d bytes", maxTemplateSize)
+	}
+	tmpl := &unstructured.Unstructured{}
+	if err := json.Unmarshal(inst.Spec.Template.Raw, &tmpl.Object); err != nil {
+		return nil, fmt.Errorf("the template is not a valid object: %w", err)
+	}
+	if tmpl.GetAPIVersion() == "" || tmpl.GetKind() == "" {
+		return nil, errors.New("the template must include the apiVersion and kind")
+	}
+	if tmpl.GetName() == "" {
+		return nil, errors.New("the template must include metadata.name")
+	}
+	if _, err := walk("", tmpl.Object, func(path, s string) (string, error) {
+		_, err := newTemplate(path, s, newBudget())
+		return s, err
+	}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// render returns the object generated from the template for the given data. The template must have
+// been returned by parseTemplate. The namespace, generator label and generator annotation are set
+// on the rendered object, which is also excluded from propagation so that generated objects can
+// never conflict with each other. Rendering stops with an error if it exceeds the budget.
+func render(inst *api.HierarchicalObjectGenerator, tmpl *unstructured.Unstructured, d templateData) (*unstructured.Unstructured, error) {
+	b := newBudget()
+	obj, err := walk("", tmpl.Object, func(path, s string) (string, error) {
+		t, err := newTemplate(path, s, b)
+		if err != nil {
+			return "", err
+		}
+		buf := &bytes.Buffer{}
+		if err := t.Execute(io.MultiWriter(b, buf), d); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	rendered := &unstructured.Unstructured{Object: obj.(map[string]interface{})}
+	rendered.SetNamespace(d.Namespace)
+
+	labels := rendered.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[api.LabelGeneratedFrom] = inst.Namespace
+	rendered.SetLabels(labels)
+
+	annots := rendered.GetAnnotations()
+	if annots == nil {
+		annots = map[string]string{}
+	}
+	annots[api.AnnotationGenerator] = generatorName(inst)
+	annots[api.NonPropagateAnnotation] = "true"
+	rendered.SetAnnotations(annots)
+	return rendered, nil
+}
+
+// walk returns a copy of the given value, where every string (including map keys) that includes a
+// template action has been replaced by the output of fn. The path is used to identify the string
+// in any error message.
+func walk(path string, v interface{}, fn func(path, s string) (string, error)) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		if !strings.Contains(val, "{{") {
+			return val, nil
+		}
+		out, err := fn(path, val)
+		if err != nil {
+			return nil, fmt.Errorf("in %s: %w", path, err)
+		}
+		return out, nil
+
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		// Iterate in a stable order so that any error is deterministic.
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			kpath := path + "." + k
+			newKey, err := walk(kpath, k, fn)
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := out[newKey.(string)]; exists {
+				return nil, fmt.Errorf("in %s: key %q is generated more than once", kpath, newKey)
+			}
+			if out[newKey.(string)], err = walk(kpath, val[k], fn); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i := range val {
+			var err error
+			if out[i], err = walk(fmt.Sprintf("%s[%d]", path, i), val[i], fn); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	default:
+		// Numbers, bools and nulls are copied as-is.
+		return val, nil
+	}
+}
+
+// generatorName returns the value of api.AnnotationGenerator for objects generated by inst.
+func generatorName(inst *api.HierarchicalObjectGenerator) string {
+	return inst.Namespace + "/" + inst.Name
+}
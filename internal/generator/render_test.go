@@ -0,0 +1,163 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	"sigs.k8s.io/hierarchical-namespaces/internal/foresttest"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		fail bool
+	}{
+		{name: "plain object", tmpl: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "foo"}}`},
+		{name: "templated object", tmpl: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "foo-{{ .Namespace }}"}, "data": {"{{ .Parent }}": "{{ join .Ancestors \"/\" }}"}}`},
+		{name: "empty template", tmpl: ``, fail: true},
+		{name: "not an object", tmpl: `["foo"]`, fail: true},
+		{name: "missing kind", tmpl: `{"apiVersion": "v1", "metadata": {"name": "foo"}}`, fail: true},
+		{name: "missing name", tmpl: `{"apiVersion": "v1", "kind": "ConfigMap"}`, fail: true},
+		{name: "bad template syntax", tmpl: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "foo-{{ .Namespace"}}`, fail: true},
+		{name: "unknown function", tmpl: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "{{ bogus .Namespace }}"}}`, fail: true},
+		{name: "defines a template", tmpl: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "{{ define \"x\" }}x{{ end }}foo"}}`, fail: true},
+		{name: "calls a template", tmpl: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "foo"}, "data": {"x": "{{ if .Parent }}{{ template \"data.x\" . }}{{ end }}"}}`, fail: true},
+		{name: "too large", tmpl: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "foo"}, "data": {"x": "` + strings.Repeat("x", maxTemplateSize) + `"}}`, fail: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			inst := &api.HierarchicalObjectGenerator{}
+			inst.Spec.Template = runtime.RawExtension{Raw: []byte(tc.tmpl)}
+			_, err := parseTemplate(inst)
+			if tc.fail {
+				g.Expect(err).Should(HaveOccurred())
+			} else {
+				g.Expect(err).ShouldNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	// a <- b <- c
+	f := foresttest.Create("-ab")
+	f.Get("a").ManagedLabels = map[string]string{"team": "a-team", "env": "prod"}
+	f.Get("b").ManagedLabels = map[string]string{"team": "b-team"}
+	f.Get("c").SetLabels(map[string]string{
+		"a" + api.LabelTreeDepthSuffix: "2",
+		"b" + api.LabelTreeDepthSuffix: "1",
+		"c" + api.LabelTreeDepthSuffix: "0",
+	})
+
+	tests := []struct {
+		name  string
+		genNS string
+		ns    string
+		tmpl  string
+		want  map[string]string
+		fail  bool
+	}{{
+		name:  "namespace and ancestors",
+		genNS: "a",
+		ns:    "c",
+		tmpl:  `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm-{{ .Namespace }}"}, "data": {"parent": "{{ .Parent }}", "path": "{{ join .Ancestors \"/\" }}", "depth": "{{ .Depth }}"}}`,
+		want:  map[string]string{"parent": "b", "path": "a/b/c", "depth": "2"},
+	}, {
+		name:  "inherited managed labels",
+		genNS: "a",
+		ns:    "c",
+		tmpl:  `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm"}, "data": {"team": "{{ .ManagedLabels.team }}", "env": "{{ .ManagedLabels.env | upper }}", "missing": "{{ .ManagedLabels.missing }}"}}`,
+		want:  map[string]string{"team": "b-team", "env": "PROD", "missing": ""},
+	}, {
+		name:  "templated keys",
+		genNS: "b",
+		ns:    "c",
+		tmpl:  `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm"}, "data": {"{{ .Namespace }}-depth": "{{ .Depth }}"}}`,
+		want:  map[string]string{"c-depth": "1"},
+	}, {
+		name:  "duplicate keys",
+		genNS: "a",
+		ns:    "c",
+		tmpl:  `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm"}, "data": {"{{ .Namespace }}": "1", "c": "2"}}`,
+		fail:  true,
+	}, {
+		name:  "execution error",
+		genNS: "a",
+		ns:    "c",
+		tmpl:  `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm"}, "data": {"x": "{{ index .Ancestors 5 }}"}}`,
+		fail:  true,
+	}, {
+		name:  "ranges",
+		genNS: "a",
+		ns:    "c",
+		tmpl:  `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm"}, "data": {"x": "{{ range .Ancestors }}{{ range 2 }}{{ . }}{{ end }}{{ end }}"}}`,
+		want:  map[string]string{"x": "010101"},
+	}, {
+		name:  "too many range iterations",
+		genNS: "a",
+		ns:    "c",
+		tmpl:  `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm"}, "data": {"x": "{{ range 1000000000000 }}{{ end }}"}}`,
+		fail:  true,
+	}, {
+		name:  "too large output",
+		genNS: "a",
+		ns:    "c",
+		tmpl:  `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm"}, "data": {"x": "{{ range 5000 }}{{ range 5000 }}x{{ end }}{{ end }}"}}`,
+		fail:  true,
+	}, {
+		name:  "too large output across strings",
+		genNS: "a",
+		ns:    "c",
+		tmpl:  `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm"}, "data": {"x": "{{ printf \"%200000s\" \"\" }}", "y": "{{ printf \"%200000s\" \"\" }}"}}`,
+		fail:  true,
+	}, {
+		name:  "too large replace",
+		genNS: "a",
+		ns:    "c",
+		tmpl:  `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm"}, "data": {"x": "{{ $s := \"xxxxxxxxxxxxxxxx\" }}{{ range 5 }}{{ $s = replace $s \"x\" \"xxxxxxxxxxxxxxxx\" }}{{ end }}{{ len $s }}"}}`,
+		fail:  true,
+	}, {
+		name:  "too large printf width",
+		genNS: "a",
+		ns:    "c",
+		tmpl:  `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm"}, "data": {"x": "{{ len (printf \"%999999999d\" 1) }}"}}`,
+		fail:  true,
+	}, {
+		name:  "too large print",
+		genNS: "a",
+		ns:    "c",
+		tmpl:  `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm"}, "data": {"x": "{{ $s := printf \"%100000s\" \"\" }}{{ len (print $s $s $s) }}"}}`,
+		fail:  true,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			inst := &api.HierarchicalObjectGenerator{}
+			inst.Namespace = tc.genNS
+			inst.Name = "gen"
+			inst.Spec.Template = runtime.RawExtension{Raw: []byte(tc.tmpl)}
+			tmpl, err := parseTemplate(inst)
+			g.Expect(err).ShouldNot(HaveOccurred())
+
+			got, err := render(inst, tmpl, getTemplateData(f, tc.genNS, f.Get(tc.ns)))
+			if tc.fail {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(got.GetNamespace()).Should(Equal(tc.ns))
+			g.Expect(got.GetLabels()).Should(HaveKeyWithValue(api.LabelGeneratedFrom, tc.genNS))
+			g.Expect(got.GetAnnotations()).Should(HaveKeyWithValue(api.AnnotationGenerator, tc.genNS+"/gen"))
+			g.Expect(got.GetAnnotations()).Should(HaveKeyWithValue(api.NonPropagateAnnotation, "true"))
+			for k, v := range tc.want {
+				g.Expect(got.Object["data"]).Should(HaveKeyWithValue(k, v))
+			}
+		})
+	}
+}
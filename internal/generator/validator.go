@@ -0,0 +1,206 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	k8sadm "k8s.io/api/admission/v1"
+	authnv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	"sigs.k8s.io/hierarchical-namespaces/internal/forest"
+	"sigs.k8s.io/hierarchical-namespaces/internal/webhooks"
+)
+
+const (
+	// ServingPath is where the validator will run. Must be kept in sync with the
+	// kubebuilder marker below.
+	ServingPath = "/validate-hnc-x-k8s-io-v1alpha2-hierarchicalobjectgenerators"
+)
+
+// forbiddenGroups are API groups whose objects can never be generated. Generating RBAC objects
+// would allow users to escalate their privileges in every descendant namespace, and generating HNC
+// objects would allow generators to modify the hierarchy itself.
+var forbiddenGroups = []string{"rbac.authorization.k8s.io", api.MetaGroup}
+
+// Note: the validating webhook FAILS CLOSED. This means that if the webhook goes down, no
+// generators can be created or modified.
+//
+// +kubebuilder:webhook:admissionReviewVersions=v1,path=/validate-hnc-x-k8s-io-v1alpha2-hierarchicalobjectgenerators,mutating=false,failurePolicy=fail,groups="hnc.x-k8s.io",resources=hierarchicalobjectgenerators,sideEffects=None,verbs=create;update,versions=v1alpha2,name=hierarchicalobjectgenerators.hnc.x-k8s.io
+
+type Validator struct {
+	Log     logr.Logger
+	Forest  *forest.Forest
+	server  serverClient
+	decoder *admission.Decoder
+}
+
+// serverClient represents the checks that should typically be performed against the apiserver, but
+// need to be stubbed out during unit testing.
+type serverClient interface {
+	// Mapping returns the resource of the given kind, and whether that resource is namespaced.
+	Mapping(gvk schema.GroupVersionKind) (schema.GroupVersionResource, bool, error)
+
+	// IsAdmin returns true if the user is an admin of the given namespace, in the same sense as
+	// for changing its hierarchy.
+	IsAdmin(ctx context.Context, ui *authnv1.UserInfo, nnm string) (bool, error)
+
+	// CanCreate returns true if the user can create objects of the given resource in the given
+	// namespace.
+	CanCreate(ctx context.Context, ui *authnv1.UserInfo, gvr schema.GroupVersionResource, nnm string) (bool, error)
+}
+
+// request defines the aspects of the admission.Request that we care about.
+type request struct {
+	gen *api.HierarchicalObjectGenerator
+	ui  *authnv1.UserInfo
+}
+
+// Handle implements the validation webhook.
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	log := v.Log.WithValues("ns", req.Namespace, "nm", req.Name, "op", req.Operation, "user", req.UserInfo.Username)
+	// Early exit since the HNC SA can do whatever it wants (e.g. update the status or remove the
+	// finalizer, even if the template has somehow become invalid).
+	if webhooks.IsHNCServiceAccount(&req.AdmissionRequest.UserInfo) {
+		log.V(1).Info("Allowed change by HNC SA")
+		return webhooks.Allow("HNC SA")
+	}
+	if req.Operation == k8sadm.Delete {
+		return webhooks.Allow("deletions are always allowed")
+	}
+
+	gen := &api.HierarchicalObjectGenerator{}
+	if err := v.decoder.Decode(req, gen); err != nil {
+		log.Error(err, "Couldn't decode request")
+		return webhooks.DenyBadRequest(err)
+	}
+
+	resp := v.handle(ctx, &request{gen: gen, ui: &req.UserInfo})
+	if !resp.Allowed {
+		log.Info("Denied", "code", resp.Result.Code, "reason", resp.Result.Reason, "message", resp.Result.Message)
+	} else {
+		log.V(1).Info("Allowed", "message", resp.Result.Message)
+	}
+	return resp
+}
+
+// handle implements the non-boilerplate logic of this validator, allowing it to be more easily unit
+// tested (ie without constructing a full admission.Request).
+func (v *Validator) handle(ctx context.Context, req *request) admission.Response {
+	gen := req.gen
+	gk := schema.GroupKind{Group: api.MetaGroup, Kind: "HierarchicalObjectGenerator"}
+
+	// Check the size before parsing, so that the error doesn't include the template.
+	if len(gen.Spec.Template.Raw) > maxTemplateSize {
+		fldErr := field.TooLong(field.NewPath("spec", "template"), "", maxTemplateSize)
+		return webhooks.DenyInvalid(gk, gen.Name, field.ErrorList{fldErr})
+	}
+	tmpl, err := parseTemplate(gen)
+	if err != nil {
+		fldErr := field.Invalid(field.NewPath("spec", "template"), "", err.Error())
+		return webhooks.DenyInvalid(gk, gen.Name, field.ErrorList{fldErr})
+	}
+	if gen.Spec.Selector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(gen.Spec.Selector); err != nil {
+			fldErr := field.Invalid(field.NewPath("spec", "selector"), gen.Spec.Selector, err.Error())
+			return webhooks.DenyInvalid(gk, gen.Name, field.ErrorList{fldErr})
+		}
+	}
+
+	gvk := tmpl.GroupVersionKind()
+	for _, g := range forbiddenGroups {
+		if gvk.Group == g {
+			msg := fmt.Sprintf("objects in the %q API group cannot be generated", g)
+			fldErr := field.Forbidden(field.NewPath("spec", "template", "apiVersion"), msg)
+			return webhooks.DenyInvalid(gk, gen.Name, field.ErrorList{fldErr})
+		}
+	}
+
+	gvr, namespaced, err := v.server.Mapping(gvk)
+	if err != nil {
+		fldErr := field.Invalid(field.NewPath("spec", "template", "kind"), gvk.Kind, fmt.Sprintf("unknown type %s: %s", gvk, err))
+		return webhooks.DenyInvalid(gk, gen.Name, field.ErrorList{fldErr})
+	}
+	if !namespaced {
+		msg := fmt.Sprintf("%s is not a namespaced type", gvk.Kind)
+		fldErr := field.Invalid(field.NewPath("spec", "template", "kind"), gvk.Kind, msg)
+		return webhooks.DenyInvalid(gk, gen.Name, field.ErrorList{fldErr})
+	}
+
+	// HNC can write any object, so make sure that users can't use generators to create objects they
+	// couldn't create themselves. Objects are generated in descendants that may not exist yet, or
+	// whose labels may change to match the selector later, so the user must be an admin of the
+	// generator's namespace (and therefore of its whole subtree, as far as HNC is concerned), as well
+	// as being able to create the objects in every namespace where they could currently be generated.
+	allowed, err := v.server.IsAdmin(ctx, req.ui, gen.Namespace)
+	if err != nil {
+		return webhooks.DenyInternalError(fmt.Errorf("while checking permissions: %w", err))
+	}
+	if !allowed {
+		err := fmt.Errorf("user %q is not an admin of namespace %q, and so cannot generate objects in its descendants", req.ui.Username, gen.Namespace)
+		return webhooks.DenyForbidden(schema.GroupResource{Group: api.MetaGroup, Resource: "hierarchicalobjectgenerators"}, gen.Name, err)
+	}
+	for _, nnm := range v.getTargetNamespaces(gen) {
+		allowed, err := v.server.CanCreate(ctx, req.ui, gvr, nnm)
+		if err != nil {
+			return webhooks.DenyInternalError(fmt.Errorf("while checking permissions: %w", err))
+		}
+		if !allowed {
+			err := fmt.Errorf("user %q cannot create %s in namespace %q, and so cannot generate them", req.ui.Username, gvr.Resource, nnm)
+			return webhooks.DenyForbidden(gvr.GroupResource(), tmpl.GetName(), err)
+		}
+	}
+
+	return webhooks.Allow("")
+}
+
+// getTargetNamespaces returns the namespaces in which the generator could currently generate
+// objects: all the descendants of its namespace, regardless of the selector since their labels can
+// change at any time, and its own namespace (which is always checked even if IncludeSelf is false,
+// since it can be set later by anyone who can edit the generator).
+func (v *Validator) getTargetNamespaces(gen *api.HierarchicalObjectGenerator) []string {
+	v.Forest.Lock()
+	defer v.Forest.Unlock()
+	return append([]string{gen.Namespace}, v.Forest.Get(gen.Namespace).DescendantNames()...)
+}
+
+func (v *Validator) InjectClient(c client.Client) error {
+	v.server = &realClient{client: c}
+	return nil
+}
+
+func (v *Validator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// realClient implements serverClient, and is not used during unit tests.
+type realClient struct {
+	client client.Client
+}
+
+// Mapping implements serverClient
+func (r *realClient) Mapping(gvk schema.GroupVersionKind) (schema.GroupVersionResource, bool, error) {
+	mapping, err := r.client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return schema.GroupVersionResource{}, false, err
+	}
+	return mapping.Resource, mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// IsAdmin implements serverClient
+func (r *realClient) IsAdmin(ctx context.Context, ui *authnv1.UserInfo, nnm string) (bool, error) {
+	return webhooks.IsAdmin(ctx, r.client, ui, nnm)
+}
+
+// CanCreate implements serverClient
+func (r *realClient) CanCreate(ctx context.Context, ui *authnv1.UserInfo, gvr schema.GroupVersionResource, nnm string) (bool, error) {
+	return webhooks.CanAccess(ctx, r.client, ui, "create", gvr, nnm)
+}
@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	"sigs.k8s.io/hierarchical-namespaces/internal/foresttest"
+)
+
+func TestValidator(t *testing.T) {
+	cm := `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm-{{ .Namespace }}"}}`
+	tests := []struct {
+		name     string
+		tmpl     string
+		selector *metav1.LabelSelector
+		user     string
+		fail     bool
+	}{
+		{name: "allowed", tmpl: cm},
+		{name: "invalid template", tmpl: `{"apiVersion": "v1", "kind": "ConfigMap"}`, fail: true},
+		{name: "oversized template", tmpl: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm"}, "data": {"x": "` + strings.Repeat("x", maxTemplateSize) + `"}}`, fail: true},
+		{name: "invalid selector", tmpl: cm, selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "a", Operator: "bogus"}}}, fail: true},
+		{name: "RBAC objects", tmpl: `{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "RoleBinding", "metadata": {"name": "rb"}}`, fail: true},
+		{name: "HNC objects", tmpl: `{"apiVersion": "hnc.x-k8s.io/v1alpha2", "kind": "SubnamespaceAnchor", "metadata": {"name": "sub"}}`, fail: true},
+		{name: "unknown type", tmpl: `{"apiVersion": "example.com/v1", "kind": "Unknown", "metadata": {"name": "foo"}}`, fail: true},
+		{name: "cluster-scoped type", tmpl: `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "foo"}}`, fail: true},
+		{name: "user isn't an admin", tmpl: cm, user: "intruder", fail: true},
+		{name: "user can't create the object in a descendant", tmpl: cm, user: "editor", fail: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			// a <- b
+			v := &Validator{Forest: foresttest.Create("-a"), server: fakeServer{}}
+			gen := &api.HierarchicalObjectGenerator{}
+			gen.Namespace = "a"
+			gen.Name = "gen"
+			gen.Spec.Template = runtime.RawExtension{Raw: []byte(tc.tmpl)}
+			gen.Spec.Selector = tc.selector
+			user := tc.user
+			if user == "" {
+				user = "admin"
+			}
+
+			got := v.handle(context.Background(), &request{gen: gen, ui: &authnv1.UserInfo{Username: user}})
+
+			t.Logf("Got response: %+v", got.Result)
+			g.Expect(got.Allowed).Should(Equal(!tc.fail))
+		})
+	}
+}
+
+// fakeServer implements serverClient. It only knows about ConfigMaps and Namespaces. "admin" can do
+// anything, while "editor" is an admin of every namespace but can only create objects in "a".
+type fakeServer struct{}
+
+func (f fakeServer) Mapping(gvk schema.GroupVersionKind) (schema.GroupVersionResource, bool, error) {
+	switch gvk.Kind {
+	case "ConfigMap":
+		return schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, true, nil
+	case "Namespace":
+		return schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, false, nil
+	}
+	return schema.GroupVersionResource{}, false, errors.New("no matches for kind")
+}
+
+func (f fakeServer) IsAdmin(ctx context.Context, ui *authnv1.UserInfo, nnm string) (bool, error) {
+	return ui.Username == "admin" || ui.Username == "editor", nil
+}
+
+func (f fakeServer) CanCreate(ctx context.Context, ui *authnv1.UserInfo, gvr schema.GroupVersionResource, nnm string) (bool, error) {
+	return ui.Username == "admin" || (ui.Username == "editor" && nnm == "a"), nil
+}
@@ -0,0 +1,170 @@
+package hierarchyconfig
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	"sigs.k8s.io/hierarchical-namespaces/internal/forest"
+)
+
+// hierarchyEvent is an Event that should be recorded on the HierarchyConfiguration of a namespace
+// once the reconciler has written its changes to the apiserver.
+type hierarchyEvent struct {
+	nm        string
+	eventtype string
+	reason    string
+	msg       string
+}
+
+// getParentEvents returns the events that describe a change to the parent of the namespace: one on
+// the namespace itself, and one each on its old and new parents. Changes are only reported for
+// namespaces that we've seen before, or that are brand new (i.e. don't have a singleton yet);
+// otherwise, we'd report the entire hierarchy as "new" every time HNC starts.
+func (r *Reconciler) getParentEvents(inst *api.HierarchyConfiguration, ns *forest.Namespace, wasKnown, isNew bool, oldParent string) []hierarchyEvent {
+	newParent := parentName(ns)
+	if oldParent == newParent || !(wasKnown || isNew) {
+		return nil
+	}
+	nm := ns.Name()
+	by := ""
+	if user := inst.Annotations[api.AnnotationRequestedBy]; user != "" {
+		by = fmt.Sprintf(" (requested by %s)", user)
+	}
+	kind := "Namespace"
+	if ns.IsSub {
+		kind = "Subnamespace"
+	}
+
+	events := []hierarchyEvent{}
+	switch {
+	case oldParent == "":
+		events = append(events, hierarchyEvent{nm, corev1.EventTypeNormal, api.EventParentChanged, fmt.Sprintf("Parent set to %q%s", newParent, by)})
+	case newParent == "":
+		events = append(events, hierarchyEvent{nm, corev1.EventTypeNormal, api.EventParentChanged, fmt.Sprintf("Parent %q removed; this namespace is now a root%s", oldParent, by)})
+	default:
+		events = append(events, hierarchyEvent{nm, corev1.EventTypeNormal, api.EventParentChanged, fmt.Sprintf("Parent changed from %q to %q%s", oldParent, newParent, by)})
+	}
+	if oldParent != "" {
+		msg := fmt.Sprintf("%s %q moved to %q%s", kind, nm, newParent, by)
+		if newParent == "" {
+			msg = fmt.Sprintf("%s %q removed from this namespace; it is now a root%s", kind, nm, by)
+		}
+		events = append(events, hierarchyEvent{oldParent, corev1.EventTypeNormal, api.EventChildRemoved, msg})
+	}
+	if newParent != "" {
+		msg := fmt.Sprintf("%s %q moved here from %q%s", kind, nm, oldParent, by)
+		if oldParent == "" {
+			msg = fmt.Sprintf("%s %q added as a child%s", kind, nm, by)
+			if isNew {
+				msg = fmt.Sprintf("%s %q created as a child%s", kind, nm, by)
+			}
+		}
+		events = append(events, hierarchyEvent{newParent, corev1.EventTypeNormal, api.EventChildAdded, msg})
+	}
+	return events
+}
+
+// getDeletionEvents returns the event that tells the parent of a namespace that it's been deleted.
+func (r *Reconciler) getDeletionEvents(ns *forest.Namespace) []hierarchyEvent {
+	delete(r.reportedConditions, ns.Name())
+	pnm := parentName(ns)
+	if pnm == "" {
+		return nil
+	}
+	kind := "Namespace"
+	if ns.IsSub {
+		kind = "Subnamespace"
+	}
+	return []hierarchyEvent{{pnm, corev1.EventTypeNormal, api.EventChildRemoved, fmt.Sprintf("%s %q deleted", kind, ns.Name())}}
+}
+
+// getConditionEvents returns the events that describe any conditions that the namespace has gained
+// or lost. Conditions that were already in the status of the singleton (e.g. when HNC restarts) are
+// not reported as new, and neither are conditions found while the forest is still being built for
+// the first time (if canReport is false), since those are usually transient, such as the
+// ParentMissing condition on a namespace that's synced before its parent. Only conditions that
+// have been reported (or were already in the status) are reported as resolved.
+func (r *Reconciler) getConditionEvents(origHC *api.HierarchyConfiguration, ns *forest.Namespace, canReport bool) []hierarchyEvent {
+	if r.reportedConditions == nil {
+		r.reportedConditions = map[string]map[string]bool{}
+	}
+	nm := ns.Name()
+	reported := r.reportedConditions[nm]
+	if reported == nil {
+		reported = map[string]bool{}
+		r.reportedConditions[nm] = reported
+	}
+	persisted := map[string]bool{}
+	for _, c := range origHC.Status.Conditions {
+		persisted[conditionKey(c.Type, c.Reason)] = true
+	}
+
+	events := []hierarchyEvent{}
+	current := map[string]bool{}
+	for _, c := range ns.Conditions() {
+		key := conditionKey(c.Type, c.Reason)
+		current[key] = true
+		switch {
+		case reported[key]:
+		case persisted[key]:
+			reported[key] = true
+		case canReport:
+			reported[key] = true
+			events = append(events, hierarchyEvent{nm, corev1.EventTypeWarning, api.EventConditionAdded, fmt.Sprintf("Condition %s added: %s", key, c.Message)})
+		}
+	}
+
+	resolved := []string{}
+	for key := range reported {
+		if !current[key] {
+			resolved = append(resolved, key)
+		}
+	}
+	sort.Strings(resolved)
+	for _, key := range resolved {
+		delete(reported, key)
+		events = append(events, hierarchyEvent{nm, corev1.EventTypeNormal, api.EventConditionResolved, fmt.Sprintf("Condition %s resolved", key)})
+	}
+	return events
+}
+
+// recordEvents records the events on the singletons of their namespaces, and appends them to the
+// histories of those namespaces. The singleton of the namespace being reconciled is passed in as
+// inst, if it exists; all others are read from the apiserver, and any events on missing singletons
+// are dropped.
+func (r *Reconciler) recordEvents(ctx context.Context, log logr.Logger, inst *api.HierarchyConfiguration, events []hierarchyEvent) {
+	r.recordHistory(ctx, log, events)
+	if r.EventRecorder == nil {
+		return
+	}
+	for _, e := range events {
+		obj := inst
+		if obj == nil || obj.Namespace != e.nm || obj.UID == "" {
+			obj = &api.HierarchyConfiguration{}
+			if err := r.Get(ctx, types.NamespacedName{Namespace: e.nm, Name: api.Singleton}, obj); err != nil {
+				log.V(1).Info("Couldn't get singleton to record event; dropping it", "ns", e.nm, "reason", e.reason, "err", err.Error())
+				continue
+			}
+		}
+		r.EventRecorder.Event(obj, e.eventtype, e.reason, e.msg)
+	}
+}
+
+// conditionKey identifies a condition by its type and reason, e.g. "ActivitiesHalted/ParentMissing".
+func conditionKey(tp, reason string) string {
+	return tp + "/" + reason
+}
+
+// parentName returns the name of the parent of the namespace, or the empty string if it's a root.
+func parentName(ns *forest.Namespace) string {
+	if ns.Parent() == nil {
+		return ""
+	}
+	return ns.Parent().Name()
+}
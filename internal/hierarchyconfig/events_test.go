@@ -0,0 +1,101 @@
+package hierarchyconfig
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	"sigs.k8s.io/hierarchical-namespaces/internal/foresttest"
+)
+
+func TestGetParentEvents(t *testing.T) {
+	// a <- b, a <- C
+	f := foresttest.Create("-aA")
+
+	tests := []struct {
+		name      string
+		nm        string
+		oldParent string
+		wasKnown  bool
+		isNew     bool
+		by        string
+		expect    []hierarchyEvent
+	}{
+		{name: "unchanged", nm: "b", oldParent: "a", wasKnown: true},
+		{name: "unknown namespace on startup", nm: "b", isNew: false},
+		{name: "new namespace", nm: "b", isNew: true, expect: []hierarchyEvent{
+			{"b", "Normal", api.EventParentChanged, `Parent set to "a"`},
+			{"a", "Normal", api.EventChildAdded, `Namespace "b" created as a child`},
+		}},
+		{name: "new subnamespace", nm: "c", isNew: true, expect: []hierarchyEvent{
+			{"c", "Normal", api.EventParentChanged, `Parent set to "a"`},
+			{"a", "Normal", api.EventChildAdded, `Subnamespace "c" created as a child`},
+		}},
+		{name: "moved", nm: "b", oldParent: "c", wasKnown: true, by: "alice", expect: []hierarchyEvent{
+			{"b", "Normal", api.EventParentChanged, `Parent changed from "c" to "a" (requested by alice)`},
+			{"c", "Normal", api.EventChildRemoved, `Namespace "b" moved to "a" (requested by alice)`},
+			{"a", "Normal", api.EventChildAdded, `Namespace "b" moved here from "c" (requested by alice)`},
+		}},
+		{name: "now a root", nm: "a", oldParent: "z", wasKnown: true, expect: []hierarchyEvent{
+			{"a", "Normal", api.EventParentChanged, `Parent "z" removed; this namespace is now a root`},
+			{"z", "Normal", api.EventChildRemoved, `Namespace "a" removed from this namespace; it is now a root`},
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			r := &Reconciler{}
+			hc := &api.HierarchyConfiguration{}
+			if tc.by != "" {
+				hc.SetAnnotations(map[string]string{api.AnnotationRequestedBy: tc.by})
+			}
+
+			// Test
+			got := r.getParentEvents(hc, f.Get(tc.nm), tc.wasKnown, tc.isNew, tc.oldParent)
+
+			// Report
+			if tc.expect == nil {
+				g.Expect(got).Should(BeEmpty())
+			} else {
+				g.Expect(got).Should(Equal(tc.expect))
+			}
+		})
+	}
+}
+
+func TestGetConditionEvents(t *testing.T) {
+	g := NewWithT(t)
+	f := foresttest.Create("--")
+	r := &Reconciler{}
+	a, b := f.Get("a"), f.Get("b")
+	hc := &api.HierarchyConfiguration{}
+
+	// Conditions found while we can't report them aren't reported, and so are never resolved.
+	a.SetCondition(api.ConditionActivitiesHalted, api.ReasonParentMissing, "missing")
+	g.Expect(r.getConditionEvents(hc, a, false)).Should(BeEmpty())
+	a.ClearConditions()
+	g.Expect(r.getConditionEvents(hc, a, true)).Should(BeEmpty())
+
+	// New conditions are reported once, and then reported again when they're resolved.
+	a.SetCondition(api.ConditionActivitiesHalted, api.ReasonInCycle, "cycle")
+	g.Expect(r.getConditionEvents(hc, a, true)).Should(Equal([]hierarchyEvent{
+		{"a", "Warning", api.EventConditionAdded, "Condition ActivitiesHalted/InCycle added: cycle"},
+	}))
+	g.Expect(r.getConditionEvents(hc, a, true)).Should(BeEmpty())
+	a.ClearConditions()
+	g.Expect(r.getConditionEvents(hc, a, true)).Should(Equal([]hierarchyEvent{
+		{"a", "Normal", api.EventConditionResolved, "Condition ActivitiesHalted/InCycle resolved"},
+	}))
+	g.Expect(r.getConditionEvents(hc, a, true)).Should(BeEmpty())
+
+	// Conditions that are already in the status (e.g. when HNC restarts) aren't reported as new, but
+	// are reported when they're resolved.
+	b.SetCondition(api.ConditionActivitiesHalted, api.ReasonInCycle, "cycle")
+	hc.Status.Conditions = b.Conditions()
+	g.Expect(r.getConditionEvents(hc, b, true)).Should(BeEmpty())
+	b.ClearConditions()
+	g.Expect(r.getConditionEvents(hc, b, true)).Should(Equal([]hierarchyEvent{
+		{"b", "Normal", api.EventConditionResolved, "Condition ActivitiesHalted/InCycle resolved"},
+	}))
+}
@@ -0,0 +1,84 @@
+package hierarchyconfig
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+)
+
+// recordHistory appends the events to the HierarchyHistory singletons of their namespaces, creating
+// them if needed. Events on namespaces that don't exist (e.g. missing parents) are dropped, as are
+// any that can't be written, since the history is only informational.
+func (r *Reconciler) recordHistory(ctx context.Context, log logr.Logger, events []hierarchyEvent) {
+	if r.HistoryLimit <= 0 || len(events) == 0 {
+		return
+	}
+
+	// Group the entries by namespace, and record the current parent of each namespace so that the
+	// history can be used to reconstruct the hierarchy later.
+	now := metav1.Now()
+	nms := []string{}
+	entries := map[string][]api.HierarchyHistoryEntry{}
+	r.Forest.Lock()
+	for _, e := range events {
+		ns := r.Forest.Get(e.nm)
+		if !ns.Exists() {
+			continue
+		}
+		if _, ok := entries[e.nm]; !ok {
+			nms = append(nms, e.nm)
+		}
+		entries[e.nm] = append(entries[e.nm], api.HierarchyHistoryEntry{
+			Time:    now,
+			Reason:  e.reason,
+			Message: e.msg,
+			Parent:  parentName(ns),
+		})
+	}
+	r.Forest.Unlock()
+
+	for _, nm := range nms {
+		err := r.appendHistory(ctx, nm, entries[nm])
+		if err != nil && !apierrors.HasStatusCause(err, corev1.NamespaceTerminatingCause) {
+			log.Error(err, "Couldn't record hierarchy history", "historyNS", nm)
+		}
+	}
+}
+
+// appendHistory appends the entries to the HierarchyHistory in the given namespace, discarding the
+// oldest entries if there are more than HistoryLimit. Since several reconciles may write to the
+// same history at once (e.g. when a parent gains two children), it retries on conflicts.
+func (r *Reconciler) appendHistory(ctx context.Context, nm string, entries []api.HierarchyHistoryEntry) error {
+	isConflict := func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}
+	return retry.OnError(retry.DefaultBackoff, isConflict, func() error {
+		inst := &api.HierarchyHistory{}
+		nnm := types.NamespacedName{Namespace: nm, Name: api.Singleton}
+		if err := r.Get(ctx, nnm, inst); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		inst.Entries = trimHistory(append(inst.Entries, entries...), r.HistoryLimit)
+		if inst.ResourceVersion == "" {
+			inst.Namespace = nm
+			inst.Name = api.Singleton
+			return r.Create(ctx, inst)
+		}
+		return r.Update(ctx, inst)
+	})
+}
+
+// trimHistory returns the last limit entries.
+func trimHistory(entries []api.HierarchyHistoryEntry, limit int) []api.HierarchyHistoryEntry {
+	if len(entries) <= limit {
+		return entries
+	}
+	return entries[len(entries)-limit:]
+}
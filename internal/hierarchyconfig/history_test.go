@@ -0,0 +1,54 @@
+package hierarchyconfig
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	"sigs.k8s.io/hierarchical-namespaces/internal/foresttest"
+)
+
+func TestRecordHistory(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	s := runtime.NewScheme()
+	g.Expect(api.AddToScheme(s)).Should(Succeed())
+	r := &Reconciler{
+		Client:       fake.NewClientBuilder().WithScheme(s).Build(),
+		Forest:       foresttest.Create("-a"), // a <- b
+		HistoryLimit: 2,
+	}
+	getHistory := func(nm string) []api.HierarchyHistoryEntry {
+		inst := &api.HierarchyHistory{}
+		g.Expect(r.Get(ctx, types.NamespacedName{Namespace: nm, Name: api.Singleton}, inst)).Should(Succeed())
+		return inst.Entries
+	}
+
+	// Create the histories, and skip namespaces that don't exist.
+	r.recordHistory(ctx, zap.New(), []hierarchyEvent{
+		{"b", "Normal", api.EventParentChanged, "one"},
+		{"a", "Normal", api.EventChildAdded, "two"},
+		{"z", "Normal", api.EventChildRemoved, "missing"},
+	})
+	g.Expect(getHistory("a")).Should(HaveLen(1))
+	g.Expect(getHistory("a")[0].Message).Should(Equal("two"))
+	g.Expect(getHistory("a")[0].Parent).Should(Equal(""))
+	g.Expect(getHistory("b")).Should(HaveLen(1))
+	g.Expect(getHistory("b")[0].Reason).Should(Equal(api.EventParentChanged))
+	g.Expect(getHistory("b")[0].Parent).Should(Equal("a"))
+
+	// Append to the existing history, discarding the oldest entries.
+	r.recordHistory(ctx, zap.New(), []hierarchyEvent{
+		{"b", "Warning", api.EventConditionAdded, "three"},
+		{"b", "Normal", api.EventConditionResolved, "four"},
+	})
+	g.Expect(getHistory("b")).Should(HaveLen(2))
+	g.Expect(getHistory("b")[0].Message).Should(Equal("three"))
+	g.Expect(getHistory("b")[1].Message).Should(Equal("four"))
+}
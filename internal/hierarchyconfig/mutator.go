@@ -0,0 +1,93 @@
+package hierarchyconfig
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-logr/logr"
+	k8sadm "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	"sigs.k8s.io/hierarchical-namespaces/internal/webhooks"
+)
+
+const (
+	// MutatorServingPath is where the mutator will run. Must be kept in sync with the kubebuilder
+	// markers below.
+	MutatorServingPath = "/mutate-hnc-x-k8s-io-v1alpha2-hierarchyconfigurations"
+)
+
+// Note: the mutating webhook FAILS OPEN. This means that if the webhook goes down, all further
+// changes are allowed, but the events recorded by the reconciler won't say who requested them. The
+// validator still prevents users from setting the annotation to anyone but themselves. (An
+// empty line has to be kept below the kubebuilder marker for the controller-gen to generate
+// manifests.)
+//
+// +kubebuilder:webhook:admissionReviewVersions=v1,path=/mutate-hnc-x-k8s-io-v1alpha2-hierarchyconfigurations,mutating=true,failurePolicy=ignore,groups="hnc.x-k8s.io",resources=hierarchyconfigurations,sideEffects=None,verbs=create;update,versions=v1alpha2,name=hierarchyconfigurations-requested-by.hnc.x-k8s.io
+
+// Mutator records the user who sets the parent of a namespace in the requested-by annotation of
+// its singleton, so that the reconciler can include it in the events that it records.
+type Mutator struct {
+	Log     logr.Logger
+	decoder *admission.Decoder
+}
+
+// Handle implements the mutating webhook.
+func (m *Mutator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	log := m.Log.WithValues("ns", req.Namespace, "user", req.UserInfo.Username)
+	// HNC removes the annotation itself when it sets the parent.
+	if webhooks.IsHNCServiceAccount(&req.AdmissionRequest.UserInfo) {
+		return webhooks.Allow("HNC SA")
+	}
+
+	inst := &api.HierarchyConfiguration{}
+	if err := m.decoder.Decode(req, inst); err != nil {
+		log.Error(err, "Couldn't decode request")
+		return webhooks.DenyBadRequest(err)
+	}
+	var oldInst *api.HierarchyConfiguration
+	if req.Operation == k8sadm.Update {
+		oldInst = &api.HierarchyConfiguration{}
+		if err := m.decoder.DecodeRaw(req.OldObject, oldInst); err != nil {
+			log.Error(err, "Couldn't decode request")
+			return webhooks.DenyBadRequest(err)
+		}
+	}
+
+	m.handle(log, inst, oldInst, req.UserInfo.Username)
+	marshaled, err := json.Marshal(inst)
+	if err != nil {
+		return webhooks.DenyInternalError(err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// handle sets the requested-by annotation to the user if they're changing the parent. Otherwise,
+// it keeps the annotation as it was, so that users can't change it directly.
+func (m *Mutator) handle(log logr.Logger, inst, oldInst *api.HierarchyConfiguration, user string) {
+	oldParent, by := "", ""
+	if oldInst != nil {
+		oldParent = oldInst.Spec.Parent
+		by = oldInst.Annotations[api.AnnotationRequestedBy]
+	}
+	if inst.Spec.Parent != oldParent {
+		log.V(1).Info("Recording the user who changed the parent", "oldParent", oldParent, "parent", inst.Spec.Parent)
+		by = user
+	}
+
+	if by == "" {
+		delete(inst.Annotations, api.AnnotationRequestedBy)
+		return
+	}
+	if inst.Annotations == nil {
+		inst.Annotations = map[string]string{}
+	}
+	inst.Annotations[api.AnnotationRequestedBy] = by
+}
+
+// InjectDecoder injects the decoder.
+func (m *Mutator) InjectDecoder(d *admission.Decoder) error {
+	m.decoder = d
+	return nil
+}
@@ -0,0 +1,58 @@
+package hierarchyconfig
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+)
+
+func TestMutateRequestedBy(t *testing.T) {
+	m := &Mutator{}
+	l := zap.New()
+
+	tests := []struct {
+		name      string
+		oldParent string
+		oldBy     string
+		parent    string
+		by        string
+		isCreate  bool
+		expectBy  string
+	}{
+		{name: "Set on create with parent", isCreate: true, parent: "a", expectBy: "alice"},
+		{name: "Not set on create without parent", isCreate: true},
+		{name: "Users can't set it on create", isCreate: true, by: "mallory"},
+		{name: "Set when parent changes", oldParent: "a", oldBy: "bob", parent: "b", expectBy: "alice"},
+		{name: "Set when parent is removed", oldParent: "a", expectBy: "alice"},
+		{name: "Kept when parent is unchanged", oldParent: "a", oldBy: "bob", parent: "a", expectBy: "bob"},
+		{name: "Users can't change it", oldParent: "a", oldBy: "bob", parent: "a", by: "mallory", expectBy: "bob"},
+		{name: "Users can't add it", oldParent: "a", parent: "a", by: "mallory"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			hc := &api.HierarchyConfiguration{}
+			hc.Spec.Parent = tc.parent
+			if tc.by != "" {
+				hc.SetAnnotations(map[string]string{api.AnnotationRequestedBy: tc.by})
+			}
+			var oldHC *api.HierarchyConfiguration
+			if !tc.isCreate {
+				oldHC = &api.HierarchyConfiguration{}
+				oldHC.Spec.Parent = tc.oldParent
+				if tc.oldBy != "" {
+					oldHC.SetAnnotations(map[string]string{api.AnnotationRequestedBy: tc.oldBy})
+				}
+			}
+
+			// Test
+			m.handle(l, hc, oldHC, "alice")
+
+			// Report
+			g.Expect(hc.Annotations[api.AnnotationRequestedBy]).Should(Equal(tc.expectBy))
+		})
+	}
+}
@@ -19,7 +19,6 @@ import (
 	"context"
 	"fmt"
 	"reflect"
-	"strconv"
 	"strings"
 	"sync/atomic"
 
@@ -27,6 +26,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -71,6 +71,20 @@ type Reconciler struct {
 	// use it to determine how to propagate objects.
 	Forest *forest.Forest
 
+	// EventRecorder records Events on HierarchyConfigurations whenever the hierarchy changes, to give
+	// tenants an audit trail. If it's nil, no events are recorded.
+	EventRecorder record.EventRecorder
+
+	// HistoryLimit is the maximum number of entries kept in the HierarchyHistory of each namespace,
+	// which records the same changes as the events but isn't garbage-collected after an hour. If it's
+	// zero, no history is recorded.
+	HistoryLimit int
+
+	// reportedConditions contains the conditions (by type and reason) of each namespace that have
+	// been reported via events, so that we know which ones to report as resolved. It's guarded by the
+	// forest lock.
+	reportedConditions map[string]map[string]bool
+
 	// affected is a channel of event.GenericEvent (see "Watching Channels" in
 	// https://book-v1.book.kubebuilder.io/beyond_basics/controller_watches.html) that is used to
 	// enqueue additional namespaces that need updating.
@@ -153,7 +167,8 @@ func (r *Reconciler) reconcile(ctx context.Context, log logr.Logger, nm string)
 		if apierrors.IsNotFound(err) {
 			// The namespace doesn't exist or is purged. Update the forest and exit.
 			// (There must be no HC instance and we cannot create one.)
-			r.onMissingNamespace(log, nm)
+			events := r.onMissingNamespace(log, nm)
+			r.recordEvents(ctx, log, nil, events)
 			return nil
 		}
 		return err
@@ -191,22 +206,29 @@ func (r *Reconciler) reconcile(ctx context.Context, log logr.Logger, nm string)
 	r.updateFinalizers(log, inst, nsInst, anms)
 
 	// Sync the Hierarchy singleton with the in-memory forest.
-	r.syncWithForest(log, nsInst, inst, deletingCRD, anms, parentAnchor)
+	events := r.syncWithForest(log, nsInst, inst, deletingCRD, anms, parentAnchor)
 
-	// Write the instances.
-	return r.writeInstances(ctx, log, inst, nsInst)
+	// Write the instances, and only then record the events, so they can refer to the new singleton.
+	if err := r.writeInstances(ctx, log, inst, nsInst); err != nil {
+		return err
+	}
+	r.recordEvents(ctx, log, inst, events)
+	return nil
 }
 
-func (r *Reconciler) onMissingNamespace(log logr.Logger, nm string) {
+func (r *Reconciler) onMissingNamespace(log logr.Logger, nm string) []hierarchyEvent {
 	r.Forest.Lock()
 	defer r.Forest.Unlock()
 	ns := r.Forest.Get(nm)
 
-	if ns.Exists() {
-		r.enqueueAffected(log, "relative of deleted namespace", ns.RelativesNames()...)
-		ns.UnsetExists()
-		log.Info("Namespace has been deleted")
+	if !ns.Exists() {
+		return nil
 	}
+	r.enqueueAffected(log, "relative of deleted namespace", ns.RelativesNames()...)
+	events := r.getDeletionEvents(ns)
+	ns.UnsetExists()
+	log.Info("Namespace has been deleted")
+	return events
 }
 
 // removeIncludedNamespaceLabel removes the `hnc.x-k8s.io/included-namespace`
@@ -271,18 +293,34 @@ func (r *Reconciler) updateFinalizers(log logr.Logger, inst *api.HierarchyConfig
 // fully written back to the apiserver yet, each namespace is reconciled in isolation (apart from
 // the in-memory forest) so this is fine. Return true, if the namespace is just synced or the
 // namespace labels are changed that requires updating all objects in the namespaces.
-func (r *Reconciler) syncWithForest(log logr.Logger, nsInst *corev1.Namespace, inst *api.HierarchyConfiguration, deletingCRD bool, anms []string, parentAnchor *api.SubnamespaceAnchor) {
+func (r *Reconciler) syncWithForest(log logr.Logger, nsInst *corev1.Namespace, inst *api.HierarchyConfiguration, deletingCRD bool, anms []string, parentAnchor *api.SubnamespaceAnchor) []hierarchyEvent {
 	r.Forest.Lock()
 	defer r.Forest.Unlock()
 	origNS := nsInst.DeepCopy()
 	origHC := inst.DeepCopy()
 	ns := r.Forest.Get(nsInst.GetName())
 
+	// Record the state of the structure before we change it, so we can report any changes as events.
+	wasKnown := ns.Exists()
+	oldParent := parentName(ns)
+
 	// Clear all conditions; we'll re-add them if they're still relevant. But first, record whether
 	// this namespace (excluding ancestors) was halted, since if that changes, we'll need to notify
 	// other namespaces.
 	wasHalted := ns.IsHalted()
 	ns.ClearConditions()
+	// An administrator can halt this namespace directly. Any other halting condition found below will
+	// replace this one, but the namespace stays halted either way.
+	if ns.UpdateAdminHalted(inst.Spec.Halted) {
+		log.Info("Updated halted", "newValue", inst.Spec.Halted)
+	}
+	if inst.Spec.Halted {
+		ns.SetCondition(api.ConditionActivitiesHalted, api.ReasonAdminHalted, "An administrator has halted this namespace and its descendants by setting spec.halted; all propagation and hierarchy changes are disabled.")
+	}
+	// A subnamespace whose anchor has been deleted is halted until it's either deleted or restored.
+	if deleteAfter := nsInst.Annotations[api.AnnotationDeleteAfter]; deleteAfter != "" {
+		ns.SetCondition(api.ConditionActivitiesHalted, api.ReasonPendingDeletion, fmt.Sprintf("The anchor of this subnamespace has been deleted, so it will be deleted after %s unless its anchor is recreated (e.g. via 'kubectl hns restore').", deleteAfter))
+	}
 	// We can figure out this condition pretty easily...
 	if deletingCRD {
 		ns.SetCondition(api.ConditionActivitiesHalted, api.ReasonDeletingCRD, "The HierarchyConfiguration CRD is being deleted; all propagation is disabled.")
@@ -291,9 +329,10 @@ func (r *Reconciler) syncWithForest(log logr.Logger, nsInst *corev1.Namespace, i
 	// Set external tree labels in the forest if this is an external namespace.
 	r.syncExternalNamespace(log, nsInst, ns)
 
-	// If this is a subnamespace, make sure .spec.parent is set correctly. Then sync the parent to the
-	// forest, and finally notify any relatives (including the parent) that might have been waiting
-	// for this namespace to be synced.
+	// If this is a new namespace that was created with a parent, or a subnamespace, make sure
+	// .spec.parent is set correctly. Then sync the parent to the forest, and finally notify any
+	// relatives (including the parent) that might have been waiting for this namespace to be synced.
+	r.syncRequestedParent(log, inst, nsInst, ns)
 	r.syncSubnamespaceParent(log, inst, nsInst, ns, parentAnchor)
 	r.syncParent(log, inst, ns)
 	r.markExisting(log, ns)
@@ -308,6 +347,15 @@ func (r *Reconciler) syncWithForest(log logr.Logger, nsInst *corev1.Namespace, i
 		// Added to help debug #1155 if it ever reoccurs
 		log.Info("Updated allowCascadingDeletion", "newValue", inst.Spec.AllowCascadingDeletion)
 	}
+	// Descendants inherit the network isolation settings, so they need to be notified if they change.
+	// This is done via the listeners (see below).
+	netIsolationChanged := ns.UpdateNetworkIsolation(inst.Spec.NetworkIsolation, inst.Spec.NetworkIsolationExceptions)
+	if netIsolationChanged {
+		log.Info("Updated networkIsolation", "newValue", inst.Spec.NetworkIsolation, "exceptions", len(inst.Spec.NetworkIsolationExceptions))
+	}
+	if ns.UpdateSubnamespaceNaming(inst.Spec.SubnamespaceNaming) {
+		log.Info("Updated subnamespaceNaming", "newValue", inst.Spec.SubnamespaceNaming)
+	}
 
 	// Sync the status
 	inst.Status.Children = ns.ChildNames()
@@ -331,7 +379,12 @@ func (r *Reconciler) syncWithForest(log logr.Logger, nsInst *corev1.Namespace, i
 	}
 	if changed {
 		r.Forest.OnChangeNamespace(log.WithValues("reason", "insts updated"), ns)
+	} else if netIsolationChanged {
+		r.Forest.OnChangeNamespace(log.WithValues("reason", "network isolation updated"), ns)
 	}
+
+	events := r.getParentEvents(inst, ns, wasKnown, origHC.CreationTimestamp.IsZero(), oldParent)
+	return append(events, r.getConditionEvents(origHC, ns, wasKnown || origHC.CreationTimestamp.IsZero())...)
 }
 
 // syncExternalNamespace sets external tree labels to the namespace in the forest
@@ -356,6 +409,19 @@ func (r *Reconciler) syncExternalNamespace(log logr.Logger, nsInst *corev1.Names
 	ns.Manager = mgr
 }
 
+// syncRequestedParent sets the parent of a new singleton to the value of the parent annotation, if
+// the namespace has one. The annotation is ignored once the singleton exists, so that it can't
+// override any later changes to the parent.
+func (r *Reconciler) syncRequestedParent(log logr.Logger, inst *api.HierarchyConfiguration, nsInst *corev1.Namespace, ns *forest.Namespace) {
+	pnm := nsInst.Annotations[api.AnnotationParent]
+	if pnm == "" || ns.IsExternal() || !inst.CreationTimestamp.IsZero() || inst.Spec.Parent != "" {
+		return
+	}
+	log.Info("Inserting newly created namespace into the hierarchy", "parent", pnm)
+	inst.Spec.Parent = pnm
+	delete(inst.Annotations, api.AnnotationRequestedBy)
+}
+
 // syncSubnamespaceParent sets the parent to the owner and updates the SubnamespaceAnchorMissing
 // condition if the anchor is missing in the parent namespace according to the forest. The
 // subnamespace-of annotation is the source of truth of the ownership (e.g. being a subnamespace),
@@ -398,6 +464,7 @@ func (r *Reconciler) syncSubnamespaceParent(log logr.Logger, inst *api.Hierarchy
 			log.Info("The parent doesn't match the subnamespace annotation; overwriting parent", "oldParent", inst.Spec.Parent, "parent", pnm)
 		}
 		inst.Spec.Parent = pnm
+		delete(inst.Annotations, api.AnnotationRequestedBy)
 	}
 
 	if parentAnchor == nil {
@@ -505,6 +572,11 @@ func (r *Reconciler) syncLabels(log logr.Logger, inst *api.HierarchyConfiguratio
 			ns.SetCondition(api.ConditionBadConfiguration, api.ReasonIllegalManagedLabel, "Not a legal managed label (set via --managed-namespace-label): "+kvp.Key)
 			continue
 		}
+		if config.IsMonotonicLabel(kvp.Key) && config.MonotonicLabelRank(kvp.Key, kvp.Value) < 0 {
+			log.Info("Illegal monotonic label value", "key", kvp.Key, "value", kvp.Value)
+			ns.SetCondition(api.ConditionBadConfiguration, api.ReasonIllegalManagedLabel, fmt.Sprintf("Not a legal value for the monotonic label %q (set via --monotonic-namespace-label): %s", kvp.Key, kvp.Value))
+			continue
+		}
 		managed[kvp.Key] = kvp.Value
 	}
 
@@ -545,37 +617,10 @@ func (r *Reconciler) syncLabels(log logr.Logger, inst *api.HierarchyConfiguratio
 
 	// Set all managed and tree labels, starting from the current namespace and going up through the
 	// hierarchy.
-	curNS := ns
-	depth := 0
-	for curNS != nil {
-		// Set the tree label from this layer of hierarchy
-		metadata.SetLabel(nsInst, curNS.Name()+api.LabelTreeDepthSuffix, strconv.Itoa(depth))
-
-		// Add any managed labels. TODO: add conditions for conflicts.
-		for k, v := range curNS.ManagedLabels {
-			log.V(1).Info("Setting managed label", "from", curNS.Name(), "key", k, "value", v)
-			metadata.SetLabel(nsInst, k, v)
-		}
-
-		// If the root is an external namespace, add all its external tree labels too.
-		// Note it's impossible to have an external namespace as a non-root, which is
-		// enforced by both admission controllers and the reconciler here.
-		if curNS.IsExternal() {
-			for k, v := range curNS.GetTreeLabels() {
-				metadata.SetLabel(nsInst, k, strconv.Itoa(depth+v))
-			}
-
-			// Note that it's impossible to have an external namespace as a non-root (enforced elsewhere)
-			// so technically we don't need to break out of the loop here. But I find it cleaner.
-			break
-		}
-
-		// Stop if this namespace is halted, which could indicate a cycle or orphan.
-		if curNS.IsHalted() {
-			break
-		}
-		curNS = curNS.Parent()
-		depth++
+	hierLabels := ns.GetHierarchyLabels(0)
+	log.V(1).Info("Setting tree and managed labels", "labels", hierLabels)
+	for k, v := range hierLabels {
+		metadata.SetLabel(nsInst, k, v)
 	}
 
 	// Update the labels in the forest so that they can be used in object propagation. Explicitly
@@ -7,6 +7,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
 	"sigs.k8s.io/hierarchical-namespaces/internal/config"
@@ -42,6 +43,38 @@ var _ = Describe("Hierarchy", func() {
 		Eventually(HasChild(ctx, barName, fooName)).Should(Equal(true))
 	})
 
+	It("should record parent changes in the hierarchy history", func() {
+		SetParent(ctx, fooName, barName)
+		Eventually(HasChild(ctx, barName, fooName)).Should(Equal(true))
+
+		getReasons := func(nm string) func() []string {
+			return func() []string {
+				inst := &api.HierarchyHistory{}
+				if err := K8sClient.Get(ctx, types.NamespacedName{Namespace: nm, Name: api.Singleton}, inst); err != nil {
+					return nil
+				}
+				reasons := []string{}
+				for _, e := range inst.Entries {
+					reasons = append(reasons, e.Reason)
+				}
+				return reasons
+			}
+		}
+		Eventually(getReasons(fooName)).Should(ContainElement(api.EventParentChanged))
+		Eventually(getReasons(barName)).Should(ContainElement(api.EventChildAdded))
+	})
+
+	It("should set the parent of a new namespace from its parent annotation", func() {
+		bazName := CreateNSWithLabelAnnotation(ctx, "baz", nil, map[string]string{api.AnnotationParent: fooName})
+		Eventually(HasChild(ctx, fooName, bazName)).Should(Equal(true))
+		Expect(GetHierarchy(ctx, bazName).Spec.Parent).Should(Equal(fooName))
+
+		// The annotation is ignored once the hierarchy exists.
+		SetParent(ctx, bazName, barName)
+		Eventually(HasChild(ctx, barName, bazName)).Should(Equal(true))
+		Consistently(HasChild(ctx, fooName, bazName)).Should(Equal(false))
+	})
+
 	It("should set IllegalParent condition if the parent is an excluded namespace", func() {
 		// Set bar's parent to the excluded-namespace "kube-system".
 		config.SetNamespaces("", "kube-system")
@@ -125,6 +158,32 @@ var _ = Describe("Hierarchy", func() {
 		Eventually(HasCondition(ctx, fooName, api.ConditionActivitiesHalted, api.ReasonAncestor)).Should(Equal(false))
 	})
 
+	It("should set AdminHalted condition if an administrator halts a namespace", func() {
+		SetParent(ctx, fooName, barName)
+		setHalted(ctx, barName, true)
+		Eventually(HasCondition(ctx, barName, api.ConditionActivitiesHalted, api.ReasonAdminHalted)).Should(Equal(true))
+		Eventually(HasCondition(ctx, fooName, api.ConditionActivitiesHalted, api.ReasonAncestor)).Should(Equal(true))
+
+		// Unhalt bar and ensure both conditions are resolved.
+		setHalted(ctx, barName, false)
+		Eventually(HasCondition(ctx, barName, api.ConditionActivitiesHalted, api.ReasonAdminHalted)).Should(Equal(false))
+		Eventually(HasCondition(ctx, fooName, api.ConditionActivitiesHalted, api.ReasonAncestor)).Should(Equal(false))
+	})
+
+	It("should set PendingDeletion condition if a namespace is pending deletion", func() {
+		SetParent(ctx, fooName, barName)
+		ns := GetNamespace(ctx, barName)
+		annots := ns.GetAnnotations()
+		if annots == nil {
+			annots = map[string]string{}
+		}
+		annots[api.AnnotationDeleteAfter] = "2000-01-01T00:00:00Z"
+		ns.SetAnnotations(annots)
+		UpdateNamespace(ctx, ns)
+		Eventually(HasCondition(ctx, barName, api.ConditionActivitiesHalted, api.ReasonPendingDeletion)).Should(Equal(true))
+		Eventually(HasCondition(ctx, fooName, api.ConditionActivitiesHalted, api.ReasonAncestor)).Should(Equal(true))
+	})
+
 	It("should set InCycle condition if a self-cycle is detected", func() {
 		fooHier := NewHierarchy(fooName)
 		fooHier.Spec.Parent = fooName
@@ -499,3 +558,11 @@ var _ = Describe("Hierarchy", func() {
 		// TODO: test external namespaces, multiple regexes, etc
 	})
 })
+
+func setHalted(ctx context.Context, nm string, halted bool) {
+	EventuallyWithOffset(1, func() error {
+		hier := GetHierarchy(ctx, nm)
+		hier.Spec.Halted = halted
+		return TryUpdateHierarchy(ctx, hier) // can fail if a reconciler updates the hierarchy
+	}).Should(Succeed())
+}
@@ -9,12 +9,13 @@ import (
 	"github.com/go-logr/logr"
 	k8sadm "k8s.io/api/admission/v1"
 	authnv1 "k8s.io/api/authentication/v1"
-	authzv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
@@ -57,8 +58,9 @@ type serverClient interface {
 
 // request defines the aspects of the admission.Request that we care about.
 type request struct {
-	hc *api.HierarchyConfiguration
-	ui *authnv1.UserInfo
+	hc    *api.HierarchyConfiguration
+	oldHC *api.HierarchyConfiguration
+	ui    *authnv1.UserInfo
 }
 
 // Handle implements the validation webhook.
@@ -132,13 +134,16 @@ func (v *Validator) handle(ctx context.Context, log logr.Logger, req *request) a
 	labelErrs := config.ValidateManagedLabels(req.hc.Spec.Labels)
 	annotationErrs := config.ValidateManagedAnnotations(req.hc.Spec.Annotations)
 	allErrs := append(labelErrs, annotationErrs...)
+	allErrs = append(allErrs, validateNetworkIsolation(req.hc)...)
+	allErrs = append(allErrs, validateSubnamespaceNaming(req.hc)...)
+	allErrs = append(allErrs, validateRequestedBy(req)...)
 	if len(allErrs) > 0 {
 		return webhooks.DenyInvalid(api.HierarchyConfigurationGK, req.hc.Name, allErrs)
 	}
 
 	// Do all checks that require holding the in-memory lock. Generate a list of server checks we
 	// should perform once the lock is released.
-	serverChecks, resp := v.checkForest(req.hc)
+	serverChecks, resp := v.checkForest(req.hc, req.ui)
 	if !resp.Allowed {
 		return resp
 	}
@@ -147,11 +152,66 @@ func (v *Validator) handle(ctx context.Context, log logr.Logger, req *request) a
 	return v.checkServer(ctx, log, req.ui, serverChecks)
 }
 
+// validateNetworkIsolation checks that the network isolation exceptions are valid selectors, and
+// are only set along with the network isolation mode that they're exceptions to.
+func validateNetworkIsolation(hc *api.HierarchyConfiguration) field.ErrorList {
+	allErrs := field.ErrorList{}
+	fldPath := field.NewPath("spec", "networkIsolationExceptions")
+	if len(hc.Spec.NetworkIsolationExceptions) > 0 && hc.Spec.NetworkIsolation == "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath, "exceptions can only be set along with spec.networkIsolation"))
+	}
+	for i := range hc.Spec.NetworkIsolationExceptions {
+		if _, err := metav1.LabelSelectorAsSelector(&hc.Spec.NetworkIsolationExceptions[i]); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), hc.Spec.NetworkIsolationExceptions[i], err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validateRequestedBy ensures that users can only set the requested-by annotation to their own
+// name. It's normally set by the mutator, but since that webhook fails open, this is what prevents
+// users from attributing their changes to someone else in HNC's events. When the parent changes,
+// the annotation can't be kept either, since it would attribute the new parent to whoever set the
+// old one; it must then be removed or set to the user who's changing the parent.
+func validateRequestedBy(req *request) field.ErrorList {
+	by := req.hc.Annotations[api.AnnotationRequestedBy]
+	oldBy := ""
+	if req.oldHC != nil && req.oldHC.Spec.Parent == req.hc.Spec.Parent {
+		oldBy = req.oldHC.Annotations[api.AnnotationRequestedBy]
+	}
+	if by == oldBy || by == req.ui.Username {
+		return nil
+	}
+	fldPath := field.NewPath("metadata", "annotations").Key(api.AnnotationRequestedBy)
+	return field.ErrorList{field.Forbidden(fldPath, "can only be set by HNC, to the name of the user who changed the parent")}
+}
+
+// validateSubnamespaceNaming checks that the subnamespace naming policy, if any, can be satisfied,
+// using placeholder values for the parent and root names.
+func validateSubnamespaceNaming(hc *api.HierarchyConfiguration) field.ErrorList {
+	allErrs := field.ErrorList{}
+	policy := hc.Spec.SubnamespaceNaming
+	if policy == nil {
+		return allErrs
+	}
+	fldPath := field.NewPath("spec", "subnamespaceNaming")
+	if prefix := policy.RequiredPrefix("a", "a"); prefix != "" {
+		if errStrs := validation.IsDNS1123Label(prefix + "a"); len(errStrs) != 0 {
+			msg := fmt.Sprintf("must be the prefix of a valid namespace name: %s", strings.Join(errStrs, "; "))
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("prefix"), policy.Prefix, msg))
+		}
+	}
+	if _, err := policy.CompileNameRegex("a", "a"); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("nameRegex"), policy.NameRegex, err.Error()))
+	}
+	return allErrs
+}
+
 // checkForest validates that the request is allowed based on the current in-memory state of the
 // forest. If it is, it returns a list of checks we need to perform against the apiserver in order
 // to be allowed to make the change; these checks are executed _after_ the in-memory lock is
 // released.
-func (v *Validator) checkForest(hc *api.HierarchyConfiguration) ([]serverCheck, admission.Response) {
+func (v *Validator) checkForest(hc *api.HierarchyConfiguration, ui *authnv1.UserInfo) ([]serverCheck, admission.Response) {
 	v.Forest.Lock()
 	defer v.Forest.Unlock()
 
@@ -165,15 +225,109 @@ func (v *Validator) checkForest(hc *api.HierarchyConfiguration) ([]serverCheck,
 		return nil, resp
 	}
 
-	// Check problems on the parents
-	if resp := v.checkParent(ns, curParent, newParent); !resp.Allowed {
+	// Prevent changing parent of a subnamespace; this can only be done by moving its anchor (see
+	// CheckMove).
+	if ns.IsSub && curParent != newParent {
+		err := fmt.Errorf("illegal parent: Cannot set the parent of %q to %q because it's a subnamespace of %q. To move it, create its anchor in %q instead (e.g. via 'kubectl hns move')", ns.Name(), newParent.Name(), curParent.Name(), newParent.Name())
+		return nil, webhooks.DenyConflict(api.HierarchyConfigurationGR, api.Singleton, err)
+	}
+
+	if resp := v.checkNewParent(hc, ui, ns, curParent, newParent); !resp.Allowed {
 		return nil, resp
 	}
 
 	// The structure looks good. Get the list of namespaces we need server checks on.
+	serverChecks := v.getServerChecks(curParent, newParent)
+
+	// Halting a namespace freezes its whole subtree, and is meant to be used by the administrators of
+	// the tree, so changing it requires the same permissions as making the namespace into a root.
+	if hc.Spec.Halted != ns.IsAdminHalted() {
+		serverChecks = append(serverChecks, serverCheck{nnm: ns.AncestryNames()[0], reason: "current root ancestor of the namespace being halted or unhalted", checkType: checkAuthz})
+	}
+	return serverChecks, allow("")
+}
+
+// CheckMove validates that the subnamespace nnm can be moved to the new parent pnm, as requested by
+// a new anchor in pnm (see the anchor validator). The move is subject to the same structural and
+// authz checks as a change to .spec.parent, except that the namespace is allowed to be a
+// subnamespace.
+func (v *Validator) CheckMove(ctx context.Context, log logr.Logger, ui *authnv1.UserInfo, nnm, pnm string) admission.Response {
+	serverChecks, resp := v.checkMoveForest(ui, nnm, pnm)
+	if !resp.Allowed {
+		return resp
+	}
+	return v.checkServer(ctx, log, ui, serverChecks)
+}
+
+// CheckAdopt validates that the full namespace nnm can be adopted as a subnamespace of pnm, as
+// requested by a new anchor in pnm (see the anchor validator). In addition to the checks in
+// CheckMove, the user must be an admin of the namespace itself, since its lifecycle will be
+// controlled by the anchor from now on.
+func (v *Validator) CheckAdopt(ctx context.Context, log logr.Logger, ui *authnv1.UserInfo, nnm, pnm string) admission.Response {
+	serverChecks, resp := v.checkMoveForest(ui, nnm, pnm)
+	if !resp.Allowed {
+		return resp
+	}
+	serverChecks = append(serverChecks, serverCheck{nnm: nnm, reason: "namespace being adopted", checkType: checkAuthz})
+	return v.checkServer(ctx, log, ui, serverChecks)
+}
+
+// checkMoveForest is the equivalent of checkForest for CheckMove and CheckAdopt.
+func (v *Validator) checkMoveForest(ui *authnv1.UserInfo, nnm, pnm string) ([]serverCheck, admission.Response) {
+	v.Forest.Lock()
+	defer v.Forest.Unlock()
+
+	ns := v.Forest.Get(nnm)
+	curParent := ns.Parent()
+	newParent := v.Forest.Get(pnm)
+
+	if resp := v.checkNS(ns); !resp.Allowed {
+		return nil, resp
+	}
+
+	// There's no HC in the request, so describe the move as one. The namespace keeps its own
+	// settings when it's moved.
+	hc := &api.HierarchyConfiguration{Spec: api.HierarchyConfigurationSpec{Parent: pnm, Halted: ns.IsAdminHalted()}}
+	hc.ObjectMeta.Name = api.Singleton
+	hc.ObjectMeta.Namespace = nnm
+	for k, v := range ns.ManagedLabels {
+		hc.Spec.Labels = append(hc.Spec.Labels, api.MetaKVP{Key: k, Value: v})
+	}
+	if resp := v.checkNewParent(hc, ui, ns, curParent, newParent); !resp.Allowed {
+		return nil, resp
+	}
+
 	return v.getServerChecks(curParent, newParent), allow("")
 }
 
+// checkNewParent runs the checks on the new parent of a namespace that are shared by changes to
+// .spec.parent (see checkForest) and moves (see checkMoveForest). The hc describes the settings
+// that the namespace will have once it's been moved.
+func (v *Validator) checkNewParent(hc *api.HierarchyConfiguration, ui *authnv1.UserInfo, ns, curParent, newParent *forest.Namespace) admission.Response {
+	// Check that the parent isn't being changed in or into a subtree halted by an administrator.
+	if resp := v.checkAdminHalted(hc, curParent, newParent); !resp.Allowed {
+		return resp
+	}
+
+	// Check problems on the parents
+	if resp := v.checkParent(ns, curParent, newParent); !resp.Allowed {
+		return resp
+	}
+
+	// Check that the user is allowed to make the namespace into a root.
+	if resp := v.checkRootPolicy(ui, ns, curParent, newParent); !resp.Allowed {
+		return resp
+	}
+
+	// Check that the monotonic labels aren't looser than the ones inherited from the new ancestors.
+	if resp := v.checkMonotonicLabels(hc, newParent); !resp.Allowed {
+		return resp
+	}
+
+	// Check that the network isolation isn't looser than the one inherited from the new ancestors.
+	return v.checkNetworkIsolation(hc, newParent)
+}
+
 // checkNS looks for problems with the current namespace that should prevent changes.
 func (v *Validator) checkNS(ns *forest.Namespace) admission.Response {
 	// Wait until the namespace has been synced
@@ -193,6 +347,28 @@ func (v *Validator) checkNS(ns *forest.Namespace) admission.Response {
 	return allow("")
 }
 
+// checkAdminHalted prevents the structure of a subtree from being changed while an administrator
+// has halted it. Halted namespaces can't be moved unless they're unhalted at the same time, and no
+// namespace can be moved into a halted subtree. Descendants of halted namespaces are already
+// covered by checkNS.
+func (v *Validator) checkAdminHalted(hc *api.HierarchyConfiguration, curParent, newParent *forest.Namespace) admission.Response {
+	if curParent == newParent {
+		return allow("parent unchanged")
+	}
+
+	if hc.Spec.Halted {
+		err := fmt.Errorf("namespace %q is halted, so its parent cannot be changed; please unset spec.halted first", hc.Namespace)
+		return webhooks.DenyForbidden(api.HierarchyConfigurationGR, api.Singleton, err)
+	}
+
+	if root := newParent.GetAdminHaltedRoot(); root != "" {
+		err := fmt.Errorf("cannot set the parent of %q to %q because namespace %q has been halted by an administrator", hc.Namespace, newParent.Name(), root)
+		return webhooks.DenyForbidden(api.HierarchyConfigurationGR, api.Singleton, err)
+	}
+
+	return allow("")
+}
+
 // checkParent validates if the parent is legal based on the current in-memory state of the forest.
 func (v *Validator) checkParent(ns, curParent, newParent *forest.Namespace) admission.Response {
 	if ns.IsExternal() && newParent != nil {
@@ -204,12 +380,6 @@ func (v *Validator) checkParent(ns, curParent, newParent *forest.Namespace) admi
 		return allow("parent unchanged")
 	}
 
-	// Prevent changing parent of a subnamespace
-	if ns.IsSub {
-		err := fmt.Errorf("illegal parent: Cannot set the parent of %q to %q because it's a subnamespace of %q", ns.Name(), newParent.Name(), curParent.Name())
-		return webhooks.DenyConflict(api.HierarchyConfigurationGR, api.Singleton, err)
-	}
-
 	// non existence of parent namespace -> not allowed
 	if newParent != nil && !newParent.Exists() {
 		err := fmt.Errorf("requested parent %q does not exist", newParent.Name())
@@ -238,6 +408,65 @@ func (v *Validator) checkParent(ns, curParent, newParent *forest.Namespace) admi
 	return allow("")
 }
 
+// checkRootPolicy validates that the user is allowed to make the namespace into a root, according
+// to the root policy in the HNCConfiguration.
+func (v *Validator) checkRootPolicy(ui *authnv1.UserInfo, ns, curParent, newParent *forest.Namespace) admission.Response {
+	if curParent == nil || newParent != nil {
+		return allow("")
+	}
+	if !webhooks.CanCreateRoot(v.Forest.GetRootPolicy(), ui) {
+		err := fmt.Errorf("cannot make %q a root namespace: the HNC configuration requires every namespace to have a parent, and only certain users and groups may create new roots", ns.Name())
+		return webhooks.DenyForbidden(api.HierarchyConfigurationGR, api.Singleton, err)
+	}
+	return allow("")
+}
+
+// checkMonotonicLabels validates that none of the monotonic labels set by the config is looser than
+// the value inherited from the new parent and its ancestors. Note that the reverse case - an
+// ancestor being tightened beyond the value set on a descendant - is allowed, in which case the
+// stricter value is simply inherited by the descendant.
+func (v *Validator) checkMonotonicLabels(hc *api.HierarchyConfiguration, newParent *forest.Namespace) admission.Response {
+	if newParent == nil {
+		return allow("")
+	}
+	if allErrs := config.ValidateMonotonicLabels(hc.Spec.Labels, newParent.GetMonotonicLabels()); len(allErrs) > 0 {
+		return webhooks.DenyInvalid(api.HierarchyConfigurationGK, hc.Name, allErrs)
+	}
+	return allow("")
+}
+
+// checkNetworkIsolation ensures that the network isolation mode set on the namespace, if any, isn't
+// looser than the one inherited from its new parent, and that its exceptions are all inherited as
+// well. Looser modes and extra exceptions would be ignored anyway (see
+// forest.Namespace.GetNetworkIsolation), so this is only to let the user know.
+func (v *Validator) checkNetworkIsolation(hc *api.HierarchyConfiguration, newParent *forest.Namespace) admission.Response {
+	mode := hc.Spec.NetworkIsolation
+	if mode == "" || newParent == nil {
+		return allow("")
+	}
+	inherited, src, inheritedExceptions := newParent.GetNetworkIsolation()
+	if mode.IsLooserThan(inherited) {
+		msg := fmt.Sprintf("cannot be looser than the %s isolation inherited from %q", inherited, src)
+		fldErr := field.Invalid(field.NewPath("spec", "networkIsolation"), mode, msg)
+		return webhooks.DenyInvalid(api.HierarchyConfigurationGK, hc.Name, field.ErrorList{fldErr})
+	}
+	if inherited == api.NetworkIsolationNone {
+		return allow("")
+	}
+	allErrs := field.ErrorList{}
+	fldPath := field.NewPath("spec", "networkIsolationExceptions")
+	for i, e := range hc.Spec.NetworkIsolationExceptions {
+		if !forest.HasNetworkIsolationException(inheritedExceptions, e) {
+			msg := fmt.Sprintf("must be one of the exceptions to the %s isolation inherited from %q", inherited, src)
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), e, msg))
+		}
+	}
+	if len(allErrs) > 0 {
+		return webhooks.DenyInvalid(api.HierarchyConfigurationGK, hc.Name, allErrs)
+	}
+	return allow("")
+}
+
 // getConflictingObjects returns a list of namespaced objects if there's any conflict.
 func (v *Validator) getConflictingObjects(newParent, ns *forest.Namespace) []string {
 	// If the new parent is nil,  early exit since it's impossible to introduce
@@ -440,9 +669,18 @@ func (v *Validator) decodeRequest(in admission.Request) (*request, error) {
 		return nil, err
 	}
 
+	var oldHC *api.HierarchyConfiguration
+	if in.Operation == k8sadm.Update {
+		oldHC = &api.HierarchyConfiguration{}
+		if err := v.decoder.DecodeRaw(in.OldObject, oldHC); err != nil {
+			return nil, err
+		}
+	}
+
 	return &request{
-		hc: hc,
-		ui: &in.UserInfo,
+		hc:    hc,
+		oldHC: oldHC,
+		ui:    &in.UserInfo,
 	}, nil
 }
 
@@ -456,7 +694,7 @@ func (v *Validator) InjectDecoder(d *admission.Decoder) error {
 	return nil
 }
 
-// realClient implements serverClient, and is not use during unit tests.
+// realClient implements serverClient, and is not used during unit tests.
 type realClient struct {
 	client client.Client
 }
@@ -476,34 +714,7 @@ func (r *realClient) Exists(ctx context.Context, nnm string) (bool, error) {
 
 // IsAdmin implements serverClient
 func (r *realClient) IsAdmin(ctx context.Context, ui *authnv1.UserInfo, nnm string) (bool, error) {
-	// Convert the Extra type
-	authzExtra := map[string]authzv1.ExtraValue{}
-	for k, v := range ui.Extra {
-		authzExtra[k] = (authzv1.ExtraValue)(v)
-	}
-
-	// Construct the request
-	sar := &authzv1.SubjectAccessReview{
-		Spec: authzv1.SubjectAccessReviewSpec{
-			ResourceAttributes: &authzv1.ResourceAttributes{
-				Namespace: nnm,
-				Verb:      "update",
-				Group:     "hnc.x-k8s.io",
-				Version:   "*",
-				Resource:  "hierarchyconfigurations",
-			},
-			User:   ui.Username,
-			Groups: ui.Groups,
-			UID:    ui.UID,
-			Extra:  authzExtra,
-		},
-	}
-
-	// Call the server
-	err := r.client.Create(ctx, sar)
-
-	// Extract the interesting result
-	return sar.Status.Allowed, err
+	return webhooks.IsAdmin(ctx, r.client, ui, nnm)
 }
 
 // allow is a replacement for controller-runtime's admission.Allowed() that allows you to set the
@@ -80,6 +80,309 @@ func TestManagedMeta(t *testing.T) {
 	}
 }
 
+func TestNetworkIsolation(t *testing.T) {
+	f := foresttest.Create("-a-") // a <- b; c
+	ingress := metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "ingress"}}
+	f.Get("c").UpdateNetworkIsolation(api.NetworkIsolationSubtree, []metav1.LabelSelector{ingress})
+	h := &Validator{Forest: f}
+	l := zap.New()
+
+	tests := []struct {
+		name       string
+		nnm        string
+		pnm        string
+		mode       api.NetworkIsolationMode
+		exceptions []metav1.LabelSelector
+		allowed    bool
+	}{
+		{name: "ok: same as ancestor", nnm: "b", pnm: "c", mode: api.NetworkIsolationSubtree, allowed: true},
+		{name: "ok: stricter than ancestor", nnm: "b", pnm: "c", mode: api.NetworkIsolationNamespace, allowed: true},
+		{name: "ok: inherited from ancestor", nnm: "b", pnm: "c", allowed: true},
+		{name: "looser than ancestor", nnm: "b", pnm: "c", mode: api.NetworkIsolationNone},
+		{name: "ok: exception inherited from ancestor", nnm: "b", pnm: "c", mode: api.NetworkIsolationNamespace, exceptions: []metav1.LabelSelector{ingress}, allowed: true},
+		{name: "exception not inherited from ancestor", nnm: "b", pnm: "c", mode: api.NetworkIsolationNamespace, exceptions: []metav1.LabelSelector{ingress, {}}},
+		{name: "ok: no isolation", allowed: true},
+		{name: "ok: subtree isolation", mode: api.NetworkIsolationSubtree, allowed: true},
+		{name: "ok: with exceptions", mode: api.NetworkIsolationNamespace, exceptions: []metav1.LabelSelector{{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "ingress"}}}, allowed: true},
+		{name: "invalid: exceptions without isolation", exceptions: []metav1.LabelSelector{{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "ingress"}}}},
+		{name: "invalid: bad exception", mode: api.NetworkIsolationSubtree, exceptions: []metav1.LabelSelector{{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "k", Operator: "bogus"}}}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			g := NewWithT(t)
+			nnm := tc.nnm
+			if nnm == "" {
+				nnm = "a"
+			}
+			hc := &api.HierarchyConfiguration{Spec: api.HierarchyConfigurationSpec{Parent: tc.pnm}}
+			hc.ObjectMeta.Name = api.Singleton
+			hc.ObjectMeta.Namespace = nnm
+			hc.Spec.NetworkIsolation = tc.mode
+			hc.Spec.NetworkIsolationExceptions = tc.exceptions
+			req := &request{hc: hc}
+
+			got := h.handle(context.Background(), l, req)
+
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).Should(Equal(tc.allowed))
+		})
+	}
+}
+
+func TestSubnamespaceNaming(t *testing.T) {
+	f := foresttest.Create("-") // a
+	h := &Validator{Forest: f}
+	l := zap.New()
+
+	tests := []struct {
+		name    string
+		policy  *api.SubnamespaceNamingPolicy
+		allowed bool
+	}{
+		{name: "ok: no policy", allowed: true},
+		{name: "ok: prefix", policy: &api.SubnamespaceNamingPolicy{Prefix: "{{parent}}-"}, allowed: true},
+		{name: "ok: regex", policy: &api.SubnamespaceNamingPolicy{NameRegex: "{{root}}-(dev|prod)-.*"}, allowed: true},
+		{name: "invalid: prefix", policy: &api.SubnamespaceNamingPolicy{Prefix: "{{parent}}_"}},
+		{name: "invalid: unknown variable", policy: &api.SubnamespaceNamingPolicy{Prefix: "{{team}}-"}},
+		{name: "invalid: regex", policy: &api.SubnamespaceNamingPolicy{NameRegex: "{{parent}}-("}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			g := NewWithT(t)
+			hc := &api.HierarchyConfiguration{Spec: api.HierarchyConfigurationSpec{}}
+			hc.ObjectMeta.Name = api.Singleton
+			hc.ObjectMeta.Namespace = "a"
+			hc.Spec.SubnamespaceNaming = tc.policy
+			req := &request{hc: hc}
+
+			got := h.handle(context.Background(), l, req)
+
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).Should(Equal(tc.allowed))
+		})
+	}
+}
+
+const psaLabel = "pod-security.kubernetes.io/enforce"
+
+func TestMonotonicLabels(t *testing.T) {
+	f := foresttest.Create("-a") // a <- b
+	f.Get("a").ManagedLabels = map[string]string{psaLabel: "baseline"}
+	h := &Validator{Forest: f}
+	l := zap.New()
+	if err := config.SetMonotonicLabels([]string{psaLabel + "=privileged,baseline,restricted"}); err != nil {
+		t.Fatal(err)
+	}
+	defer config.SetMonotonicLabels(nil)
+
+	tests := []struct {
+		name    string
+		nnm     string
+		pnm     string
+		value   string
+		allowed bool
+	}{
+		{name: "ok: same as ancestor", nnm: "b", pnm: "a", value: "baseline", allowed: true},
+		{name: "ok: stricter than ancestor", nnm: "b", pnm: "a", value: "restricted", allowed: true},
+		{name: "ok: root can set anything", nnm: "a", value: "privileged", allowed: true},
+		{name: "looser than ancestor", nnm: "b", pnm: "a", value: "privileged"},
+		{name: "unknown value", nnm: "a", value: "lax"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			g := NewWithT(t)
+			hc := &api.HierarchyConfiguration{Spec: api.HierarchyConfigurationSpec{Parent: tc.pnm}}
+			hc.ObjectMeta.Name = api.Singleton
+			hc.ObjectMeta.Namespace = tc.nnm
+			hc.Spec.Labels = []api.MetaKVP{{Key: psaLabel, Value: tc.value}}
+			req := &request{hc: hc}
+
+			got := h.handle(context.Background(), l, req)
+
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).Should(Equal(tc.allowed))
+		})
+	}
+}
+
+func TestRootPolicy(t *testing.T) {
+	f := foresttest.Create("-a-") // a <- b; c
+	f.SetRootPolicy(&api.RootPolicy{RequireParent: true, AllowedGroups: []string{"admins"}})
+	h := &Validator{Forest: f}
+	l := zap.New()
+
+	tests := []struct {
+		name    string
+		nnm     string
+		pnm     string
+		groups  []string
+		allowed bool
+	}{
+		{name: "ok: move to another parent", nnm: "b", pnm: "c", allowed: true},
+		{name: "ok: root stays a root", nnm: "a", allowed: true},
+		{name: "ok: make a root by allowed group", nnm: "b", groups: []string{"admins"}, allowed: true},
+		{name: "make a root", nnm: "b"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			g := NewWithT(t)
+			hc := &api.HierarchyConfiguration{Spec: api.HierarchyConfigurationSpec{Parent: tc.pnm}}
+			hc.ObjectMeta.Name = api.Singleton
+			hc.ObjectMeta.Namespace = tc.nnm
+			req := &request{hc: hc, ui: &authn.UserInfo{Username: "bob", Groups: tc.groups}}
+
+			got := h.handle(context.Background(), l, req)
+
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).Should(Equal(tc.allowed))
+		})
+	}
+}
+
+func TestRequestedBy(t *testing.T) {
+	f := foresttest.Create("-a-") // a <- b; c
+	h := &Validator{Forest: f}
+	l := zap.New()
+
+	tests := []struct {
+		name      string
+		oldBy     string
+		by        string
+		update    bool
+		oldParent string
+		allowed   bool
+	}{
+		{name: "ok: unset", allowed: true},
+		{name: "ok: set to self", by: "bob", allowed: true},
+		{name: "ok: unchanged", update: true, oldBy: "alice", by: "alice", allowed: true},
+		{name: "ok: changed to self", update: true, oldBy: "alice", by: "bob", allowed: true},
+		{name: "set to someone else", by: "alice"},
+		{name: "changed to someone else", update: true, oldBy: "bob", by: "alice"},
+		{name: "removed", update: true, oldBy: "alice"},
+		{name: "ok: changed to self with the parent", update: true, oldParent: "c", oldBy: "alice", by: "bob", allowed: true},
+		{name: "ok: removed with the parent", update: true, oldParent: "c", oldBy: "alice", allowed: true},
+		{name: "unchanged with the parent", update: true, oldParent: "c", oldBy: "alice", by: "alice"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			g := NewWithT(t)
+			hc := &api.HierarchyConfiguration{Spec: api.HierarchyConfigurationSpec{Parent: "a"}}
+			hc.ObjectMeta.Name = api.Singleton
+			hc.ObjectMeta.Namespace = "b"
+			if tc.by != "" {
+				hc.ObjectMeta.Annotations = map[string]string{api.AnnotationRequestedBy: tc.by}
+			}
+			req := &request{hc: hc, ui: &authn.UserInfo{Username: "bob"}}
+			if tc.update {
+				req.oldHC = hc.DeepCopy()
+				req.oldHC.ObjectMeta.Annotations = map[string]string{api.AnnotationRequestedBy: tc.oldBy}
+				if tc.oldParent != "" {
+					req.oldHC.Spec.Parent = tc.oldParent
+				}
+			}
+
+			got := h.handle(context.Background(), l, req)
+
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).Should(Equal(tc.allowed))
+		})
+	}
+}
+
+func TestAdminHalted(t *testing.T) {
+	f := foresttest.Create("-ab-") // a <- b <- c; d
+	f.Get("b").UpdateAdminHalted(true)
+	f.Get("b").SetCondition(api.ConditionActivitiesHalted, api.ReasonAdminHalted, "")
+	h := &Validator{Forest: f}
+	l := zap.New()
+
+	tests := []struct {
+		name    string
+		nnm     string
+		pnm     string
+		halted  bool
+		allowed bool
+	}{
+		{name: "ok: halted namespace keeps its parent", nnm: "b", pnm: "a", halted: true, allowed: true},
+		{name: "ok: halted namespace is unhalted and moved", nnm: "b", pnm: "d", allowed: true},
+		{name: "ok: move outside the halted subtree", nnm: "d", pnm: "a", allowed: true},
+		{name: "halted namespace is moved", nnm: "b", pnm: "d", halted: true},
+		{name: "namespace is halted and moved", nnm: "d", pnm: "a", halted: true},
+		{name: "move into halted namespace", nnm: "d", pnm: "b"},
+		{name: "move into halted subtree", nnm: "d", pnm: "c"},
+		{name: "move out of halted subtree", nnm: "c", pnm: "d"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			g := NewWithT(t)
+			hc := &api.HierarchyConfiguration{Spec: api.HierarchyConfigurationSpec{Parent: tc.pnm, Halted: tc.halted}}
+			hc.ObjectMeta.Name = api.Singleton
+			hc.ObjectMeta.Namespace = tc.nnm
+			req := &request{hc: hc}
+
+			got := h.handle(context.Background(), l, req)
+
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).Should(Equal(tc.allowed))
+		})
+	}
+}
+
+func TestCheckMove(t *testing.T) {
+	f := foresttest.Create("-AB----") // a <- b <- c (both subnamespaces); d; e; f; g
+	f.Get("e").UpdateAdminHalted(true)
+	f.Get("c").ManagedLabels = map[string]string{psaLabel: "baseline"}
+	f.Get("d").ManagedLabels = map[string]string{psaLabel: "baseline"}
+	f.Get("f").ManagedLabels = map[string]string{psaLabel: "restricted"}
+	f.Get("g").Manager = "other"
+	h := &Validator{Forest: f}
+	l := zap.New()
+	if err := config.SetMonotonicLabels([]string{psaLabel + "=privileged,baseline,restricted"}); err != nil {
+		t.Fatal(err)
+	}
+	defer config.SetMonotonicLabels(nil)
+
+	tests := []struct {
+		name    string
+		nnm     string
+		pnm     string
+		adopt   bool
+		allowed bool
+	}{
+		{name: "ok: move to an ancestor", nnm: "c", pnm: "a", allowed: true},
+		{name: "ok: adopt a root", nnm: "d", pnm: "c", adopt: true, allowed: true},
+		{name: "adopt an ancestor", nnm: "a", pnm: "c", adopt: true},
+		{name: "ok: move to another tree", nnm: "c", pnm: "d", allowed: true},
+		{name: "move to a descendant", nnm: "b", pnm: "c"},
+		{name: "move to a missing namespace", nnm: "b", pnm: "brumpf"},
+		{name: "move to a halted namespace", nnm: "b", pnm: "e"},
+		{name: "move under a stricter monotonic label", nnm: "c", pnm: "f"},
+		{name: "adopt under a stricter monotonic label", nnm: "d", pnm: "f", adopt: true},
+		{name: "adopt an external namespace", nnm: "g", pnm: "d", adopt: true},
+		{name: "adopt into a halted namespace", nnm: "d", pnm: "e", adopt: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ui := &authn.UserInfo{Username: "bob"}
+			got := h.CheckMove(context.Background(), l, ui, tc.nnm, tc.pnm)
+			if tc.adopt {
+				got = h.CheckAdopt(context.Background(), l, ui, tc.nnm, tc.pnm)
+			}
+
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).Should(Equal(tc.allowed))
+		})
+	}
+}
+
 func TestStructure(t *testing.T) {
 	f := foresttest.Create("-a-") // a <- b; c
 	h := &Validator{Forest: f}
@@ -281,6 +584,8 @@ func TestAuthz(t *testing.T) {
 		forest string
 		nm     string
 		to     string
+		halt   bool  // halts the namespace in the request
+		unhalt bool  // halts the namespace in the forest, but not in the request
 		code   int32 // defaults to 0 (success)
 	}{
 		{name: "no permission in tree", forest: "-aab", nm: "d", to: "c", code: 401},                                 // a <- (b <- d, c)
@@ -298,17 +603,25 @@ func TestAuthz(t *testing.T) {
 		{name: "member of cycle (all permission)", forest: "cab", nm: "c", to: "", server: "abc"},                    // a,b,c in cycle
 		{name: "member of cycle (no permission)", forest: "cab", nm: "c", to: "", server: "", code: 401},             // a,b,c in cycle
 		{name: "descendant of cycle", forest: "baa", nm: "c", to: "b", server: "ab", code: 403},                      // c -> a <-> b
+		{name: "halt with permission on root", forest: "-a", nm: "b", to: "a", halt: true, server: "a"},
+		{name: "halt with permission on namespace only", forest: "-a", nm: "b", to: "a", halt: true, server: "b", code: 401},
+		{name: "unhalt with permission on root", forest: "-a", nm: "b", to: "a", unhalt: true, server: "a"},
+		{name: "unhalt with permission on namespace only", forest: "-a", nm: "b", to: "a", unhalt: true, server: "b", code: 401},
+		{name: "halted namespace unchanged", forest: "-a", nm: "b", to: "a", halt: true, unhalt: true, server: "b"},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			// Setup
 			g := NewWithT(t)
 			f := foresttest.Create(tc.forest)
+			if tc.unhalt {
+				f.Get(tc.nm).UpdateAdminHalted(true)
+			}
 			h := &Validator{Forest: f, server: tc.server}
 			l := zap.New()
 
 			// Create request
-			hc := &api.HierarchyConfiguration{Spec: api.HierarchyConfigurationSpec{Parent: tc.to}}
+			hc := &api.HierarchyConfiguration{Spec: api.HierarchyConfigurationSpec{Parent: tc.to, Halted: tc.halt}}
 			hc.ObjectMeta.Name = api.Singleton
 			hc.ObjectMeta.Namespace = tc.nm
 			req := &request{hc: hc, ui: &authn.UserInfo{Username: "jen"}}
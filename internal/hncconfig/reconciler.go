@@ -53,17 +53,26 @@ type Reconciler struct {
 	// RefreshDuration is the maximum amount of time between refreshes
 	RefreshDuration time.Duration
 
+	// MetadataOnly is passed to all object reconcilers; see objects.Reconciler.MetadataOnly.
+	MetadataOnly bool
+
 	// activeGVKMode contains GRs that are configured in the Spec and their mapping
 	// GVKs and configured modes.
 	activeGVKMode gr2gvkMode
 
 	// activeGR contains the mapped GVKs of the GRs configured in the Spec.
 	activeGR gvk2gr
+
+	// configuredEnforced contains the enforced types whose update strategy has been configured in
+	// the Spec.
+	configuredEnforced map[schema.GroupVersionKind]bool
 }
 
 type gvkMode struct {
-	gvk  schema.GroupVersionKind
-	mode api.SynchronizationMode
+	gvk      schema.GroupVersionKind
+	mode     api.SynchronizationMode
+	strategy api.UpdateStrategy
+	fields   []string
 }
 
 // gr2gvkMode keeps track of a group of unique GRs and the mapping GVKs and modes.
@@ -82,6 +91,10 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	}
 	inst.Status.Conditions = nil
 
+	// The root policy and default parent rules are enforced by the namespace webhooks, which read
+	// them from the forest.
+	r.syncNamespacePolicies(inst)
+
 	if err := r.reconcileTypes(inst); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -109,6 +122,14 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	return ctrl.Result{}, syncErr
 }
 
+// syncNamespacePolicies records the root policy and the default parent rules in the forest.
+func (r *Reconciler) syncNamespacePolicies(inst *api.HNCConfiguration) {
+	r.Forest.Lock()
+	defer r.Forest.Unlock()
+	r.Forest.SetRootPolicy(inst.Spec.RootPolicy.DeepCopy())
+	r.Forest.SetDefaultParentRules(inst.DeepCopy().Spec.DefaultParents)
+}
+
 // reconcileTypes reconciles HNC enforced types and user-configured types to
 // make sure there's no dup and the types exist. Update the type set with GR to
 // GVK mappings.
@@ -116,6 +137,7 @@ func (r *Reconciler) reconcileTypes(inst *api.HNCConfiguration) error {
 	// Overwrite the type set each time. Initialize them with the enforced types.
 	r.activeGVKMode = gr2gvkMode{}
 	r.activeGR = gvk2gr{}
+	r.configuredEnforced = map[schema.GroupVersionKind]bool{}
 	if err := r.ensureEnforcedTypes(inst); err != nil {
 		// Early exit if any enforced types are not found for some reason to retry.
 		return err
@@ -140,14 +162,14 @@ func (r *Reconciler) ensureEnforcedTypes(inst *api.HNCConfiguration) error {
 			r.writeCondition(inst, api.ConditionBadTypeConfiguration, reasonForGVKError(err), err.Error())
 			return err
 		}
-		r.activeGVKMode[gr] = gvkMode{gvk, t.Mode}
+		r.activeGVKMode[gr] = gvkMode{gvk, t.Mode, t.UpdateStrategy, t.UnpropagatedFields}
 		r.activeGR[gvk] = gr
 	}
 	return nil
 }
 
 // reconcileConfigTypes reconciles user-configured types (excluding HNC enforced
-// types 'roles' and 'rolebindings'). It makes sure there's no dup and the types
+// types 'roles' and 'rolebindings', other than their update strategies). It makes sure there's no dup and the types
 // exist. Update the type set with GR to GVK mappings. We will not return errors
 // to retry but only set conditions since the configuration may be incorrect.
 func (r *Reconciler) reconcileConfigTypes(inst *api.HNCConfiguration) {
@@ -164,6 +186,18 @@ func (r *Reconciler) reconcileConfigTypes(inst *api.HNCConfiguration) {
 			continue
 		}
 
+		// The types enforced by HNC always use the 'Propagate' mode, but their update strategy can be
+		// configured by the first configuration that only sets the update strategy.
+		if gvkChecker.isEnforced(gvk) && !r.configuredEnforced[gvk] && (rsc.Mode == "" || rsc.Mode == api.Propagate) &&
+			rsc.UpdateStrategy != "" && len(rsc.UnpropagatedFields) == 0 {
+			enforcedGR := r.activeGR[gvk]
+			gm := r.activeGVKMode[enforcedGR]
+			gm.strategy = rsc.UpdateStrategy
+			r.activeGVKMode[enforcedGR] = gm
+			r.configuredEnforced[gvk] = true
+			continue
+		}
+
 		// If there are multiple configurations of the same GVK, we will follow the
 		// first configuration and ignore the rest.
 		if firstGR, exist := r.activeGR[gvk]; exist {
@@ -172,7 +206,7 @@ func (r *Reconciler) reconcileConfigTypes(inst *api.HNCConfiguration) {
 			msg := ""
 			// Set a different message if the type is enforced by HNC.
 			if gvkChecker.isEnforced(gvk) {
-				msg = fmt.Sprintf("The sync mode for %q is enforced by HNC as %q and cannot be overridden", gr, api.Propagate)
+				msg = fmt.Sprintf("The sync mode for %q is enforced by HNC as %q and cannot be overridden; only its update strategy can be configured, once", gr, api.Propagate)
 				log.Info("The sync mode for this resource is enforced by HNC and cannot be overridden")
 			} else {
 				log.Info("Multiple sync mode settings found; only one is allowed")
@@ -182,7 +216,7 @@ func (r *Reconciler) reconcileConfigTypes(inst *api.HNCConfiguration) {
 			continue
 		}
 
-		r.activeGVKMode[gr] = gvkMode{gvk, rsc.Mode}
+		r.activeGVKMode[gr] = gvkMode{gvk, rsc.Mode, rsc.UpdateStrategy, rsc.UnpropagatedFields}
 		r.activeGR[gvk] = gr
 	}
 }
@@ -283,6 +317,9 @@ func objectWebhookRules(mode gr2gvkMode) []apiadmissionregistrationv1.RuleWithOp
 //
 // If a type exists, the method will sync the mode of the existing object reconciler
 // and update corresponding objects if needed. An error will be return to trigger reconciliation if sync fails.
+//
+// For types that have been removed from the HNC configuration, the method will stop the corresponding
+// ObjectReconcilers and remove them from the in-memory forest.
 func (r *Reconciler) syncObjectReconcilers(ctx context.Context, inst *api.HNCConfiguration) error {
 	// This method is guarded by the forest mutex.
 	//
@@ -308,10 +345,7 @@ func (r *Reconciler) syncObjectReconcilers(ctx context.Context, inst *api.HNCCon
 		return err
 	}
 
-	if err := r.syncRemovedReconcilers(ctx); err != nil {
-		return err
-	}
-
+	r.syncRemovedReconcilers()
 	return nil
 }
 
@@ -321,26 +355,26 @@ func (r *Reconciler) syncObjectReconcilers(ctx context.Context, inst *api.HNCCon
 func (r *Reconciler) syncActiveReconcilers(ctx context.Context, inst *api.HNCConfiguration) error {
 	for _, gvkMode := range r.activeGVKMode {
 		if ts := r.Forest.GetTypeSyncer(gvkMode.gvk); ts != nil {
+			ts.SetUpdateStrategy(r.Log, gvkMode.strategy)
+			if err := ts.SetUnpropagatedFields(ctx, r.Log, gvkMode.fields); err != nil {
+				return err // retry the reconciliation
+			}
 			if err := ts.SetMode(ctx, r.Log, gvkMode.mode); err != nil {
 				return err // retry the reconciliation
 			}
 		} else {
-			r.createObjectReconciler(gvkMode.gvk, gvkMode.mode, inst)
+			r.createObjectReconciler(ctx, gvkMode, inst)
 		}
 	}
 	return nil
 }
 
-// syncRemovedReconcilers sets object reconcilers to "ignore" mode for types
-// that are removed from the Spec. No longer existing types in the Spec are also
-// considered as removed.
-func (r *Reconciler) syncRemovedReconcilers(ctx context.Context) error {
-	// If a type exists in the forest but not exists in the latest type set, we
-	// will set the mode of corresponding object reconciler to "ignore".
-	// TODO: Ideally, we should shut down the corresponding object
-	// reconciler. Gracefully terminating an object reconciler is still under
-	// development (https://github.com/kubernetes-sigs/controller-runtime/issues/764).
-	// We will revisit the code below once the feature is released.
+// syncRemovedReconcilers stops and removes object reconcilers for types that are removed from the
+// Spec. No longer existing types in the Spec are also considered as removed. Existing copies of
+// these types are left alone, just as they are in "Ignore" mode, but HNC stops watching (and
+// caching) objects of these types altogether. If the type's added back to the Spec later, a new
+// object reconciler will be created for it.
+func (r *Reconciler) syncRemovedReconcilers() {
 	for _, ts := range r.Forest.GetTypeSyncers() {
 		exist := false
 		for _, gvkMode := range r.activeGVKMode {
@@ -352,46 +386,56 @@ func (r *Reconciler) syncRemovedReconcilers(ctx context.Context) error {
 		if exist {
 			continue
 		}
-		// The type does not exist in the Spec. Ignore subsequent reconciliations.
-		r.Log.V(1).Info("Resource config removed, will no longer update objects", "gvk", ts.GetGVK())
-		if err := ts.SetMode(ctx, r.Log, api.Ignore); err != nil {
-			return err // retry the reconciliation
+		// The type does not exist in the Spec. Stop watching it. Stopping doesn't block, which is
+		// important since the object reconciler may be waiting for the forest lock that we hold.
+		r.Log.Info("Resource config removed, will no longer update objects", "gvk", ts.GetGVK())
+		if nl, ok := ts.(forest.NamespaceListener); ok {
+			r.Forest.RemoveListener(nl)
 		}
+		r.Forest.RemoveTypeSyncer(ts.GetGVK())
+		ts.Stop()
 	}
-	return nil
 }
 
 // createObjectReconciler creates an ObjectReconciler for the given GVK and
-// informs forest about the reconciler.
+// informs forest about the reconciler. The ObjectReconciler runs until it's
+// stopped by syncRemovedReconcilers or until ctx is done; since ctx is passed
+// down from Reconcile, the latter only happens when the manager shuts down.
 // After upgrading sigs.k8s.io/controller-runtime version to v0.5.0, we can
 // create reconciler successfully even when the resource does not exist in the
 // cluster. Therefore, the caller should check if the resource exists before
 // creating the reconciler.
-func (r *Reconciler) createObjectReconciler(gvk schema.GroupVersionKind, mode api.SynchronizationMode, inst *api.HNCConfiguration) {
-	r.Log.Info("Starting to sync objects", "gvk", gvk, "mode", mode)
+func (r *Reconciler) createObjectReconciler(ctx context.Context, gm gvkMode, inst *api.HNCConfiguration) {
+	gvk := gm.gvk
+	r.Log.Info("Starting to sync objects", "gvk", gvk, "mode", gm.mode, "updateStrategy", gm.strategy)
 
 	or := &objects.Reconciler{
 		Client: r.Client,
 		// This field will be shown as source.component=hnc.x-k8s.io in events.
-		EventRecorder: r.Manager.GetEventRecorderFor(api.MetaGroup),
-		Log:           ctrl.Log.WithName(gvk.Kind).WithName("reconcile"),
-		Forest:        r.Forest,
-		GVK:           gvk,
-		Mode:          objects.GetValidateMode(mode, r.Log),
-		Affected:      make(chan event.GenericEvent),
+		EventRecorder:      r.Manager.GetEventRecorderFor(api.MetaGroup),
+		Log:                ctrl.Log.WithName(gvk.Kind).WithName("reconcile"),
+		Forest:             r.Forest,
+		GVK:                gvk,
+		Mode:               objects.GetValidateMode(gm.mode, r.Log),
+		UpdateStrategy:     gm.strategy,
+		UnpropagatedFields: gm.fields,
+		Affected:           make(chan event.GenericEvent),
+		MetadataOnly:       r.MetadataOnly,
 	}
-	r.Forest.AddListener(or)
 
 	// TODO: figure out MaxConcurrentReconciles option - https://github.com/kubernetes-sigs/hierarchical-namespaces/issues/291
-	if err := or.SetupWithManager(r.Manager, 10); err != nil {
+	if err := or.SetupWithManager(ctx, r.Manager, 10); err != nil {
 		r.Log.Error(err, "Error while trying to create ObjectReconciler", "gvk", gvk)
 		msg := fmt.Sprintf("Cannot sync objects of type %s: %s", gvk, err)
 		r.writeCondition(inst, api.ConditionOutOfSync, api.ReasonUnknown, msg)
 		return
 	}
 
-	// Informs the in-memory forest about the new reconciler by adding it to the types list.
+	// Informs the in-memory forest about the new reconciler by adding it to the types list. Only
+	// listen to namespace changes once the reconciler is running, since nothing would read the
+	// objects it enqueues otherwise.
 	r.Forest.AddTypeSyncer(or)
+	r.Forest.AddListener(or)
 }
 
 func (r *Reconciler) writeCondition(inst *api.HNCConfiguration, tp, reason, msg string) {
@@ -405,7 +449,7 @@ func (r *Reconciler) writeCondition(inst *api.HNCConfiguration, tp, reason, msg
 }
 
 // setTypeStatuses adds Status.Resources for types configured in the spec. Only the status of types
-// in `Propagate`, `Remove` and `AllowPropagate` modes will be recorded. The Status.Resources is sorted in
+// in `Propagate`, `Remove`, `AllowPropagate` and `Audit` modes will be recorded. The Status.Resources is sorted in
 // alphabetical order based on Group and Resource.
 func (r *Reconciler) setTypeStatuses(inst *api.HNCConfiguration) {
 	// We lock the forest here so that other reconcilers cannot modify the
@@ -437,8 +481,8 @@ func (r *Reconciler) setTypeStatuses(inst *api.HNCConfiguration) {
 			status.NumPropagatedObjects = &numProp
 		}
 
-		// Only add NumSourceObjects if we are propagating objects of this type.
-		if ts.CanPropagate() {
+		// Only add NumSourceObjects if we are propagating (or auditing) objects of this type.
+		if ts.CanPropagate() || ts.GetMode() == api.Audit {
 			numSrc := 0
 			nms := r.Forest.GetNamespaceNames()
 			for _, nm := range nms {
@@ -448,6 +492,18 @@ func (r *Reconciler) setTypeStatuses(inst *api.HNCConfiguration) {
 			status.NumSourceObjects = &numSrc
 		}
 
+		// Only add NumStaleObjects if we're propagating objects of this type.
+		if ts.CanPropagate() {
+			numStale := ts.GetNumStaleObjects()
+			status.NumStaleObjects = &numStale
+		}
+
+		// Only add NumDriftedObjects if we're auditing this type.
+		if ts.GetMode() == api.Audit {
+			numDrifted := ts.GetNumDriftedObjects()
+			status.NumDriftedObjects = &numDrifted
+		}
+
 		// Record the status
 		statuses = append(statuses, status)
 	}
@@ -297,6 +297,53 @@ var _ = Describe("HNCConfiguration", func() {
 
 	})
 
+	It("should propagate objects again if a type is removed from the spec and then added back", func() {
+		AddToHNCConfig(ctx, "", "secrets", api.Propagate)
+		SetParent(ctx, barName, fooName)
+		MakeObject(ctx, "secrets", fooName, "foo-sec")
+		Eventually(HasObject(ctx, "secrets", barName, "foo-sec")).Should(BeTrue())
+
+		removeTypeConfig(ctx, "", "secrets")
+		Eventually(typeStatusMode(ctx, "", "secrets")).Should(Equal(testModeMisssing))
+
+		// The existing copy should be left alone, but new sources shouldn't be propagated.
+		MakeObject(ctx, "secrets", fooName, "foo-sec-2")
+		Consistently(HasObject(ctx, "secrets", barName, "foo-sec-2")).Should(BeFalse())
+		Expect(HasObject(ctx, "secrets", barName, "foo-sec")()).Should(BeTrue())
+
+		// Adding the type back should start a new reconciler that catches up on everything.
+		AddToHNCConfig(ctx, "", "secrets", api.Propagate)
+		Eventually(typeStatusMode(ctx, "", "secrets")).Should(Equal(api.Propagate))
+		Eventually(HasObject(ctx, "secrets", barName, "foo-sec-2")).Should(BeTrue())
+		Expect(ObjectInheritedFrom(ctx, "secrets", barName, "foo-sec-2")).Should(Equal(fooName))
+		Eventually(getNumSourceObjects(ctx, "", "secrets"), countUpdateTime).Should(Equal(2))
+	})
+
+	It("should release the memory used by a type's objects once the type is removed from the spec", func() {
+		// Create enough data that it clearly stands out from the rest of the heap. Each Secret is
+		// cached by the informer and also stored in the forest as a source.
+		const numSecrets = 20
+		const secretSize = 512 * 1024
+		const totalSize = numSecrets * secretSize
+		AddToHNCConfig(ctx, "", "secrets", api.Propagate)
+		Eventually(typeStatusMode(ctx, "", "secrets")).Should(Equal(api.Propagate))
+		payload := make([]byte, secretSize)
+		for i := 0; i < numSecrets; i++ {
+			MakeSecretWithData(ctx, fooName, fmt.Sprintf("large-sec-%d", i), map[string][]byte{"payload": payload})
+		}
+		Eventually(getNumSourceObjects(ctx, "", "secrets"), countUpdateTime).Should(Equal(numSecrets))
+		before := HeapAlloc()
+
+		removeTypeConfig(ctx, "", "secrets")
+		Eventually(typeStatusMode(ctx, "", "secrets")).Should(Equal(testModeMisssing))
+
+		// Once the informer's stopped and nothing refers to its objects anymore, the garbage collector
+		// should reclaim at least most of that memory.
+		Eventually(func() int64 {
+			return int64(before) - int64(HeapAlloc())
+		}, countUpdateTime).Should(BeNumerically(">", totalSize/2))
+	})
+
 	It("should reconcile after adding a new crd to the apiserver", func() {
 		// Add a config for a type that hasn't been defined yet.
 		AddToHNCConfig(ctx, "stable.example.com", "crontabs", api.Propagate)
@@ -393,6 +440,20 @@ var _ = Describe("HNCConfiguration", func() {
 
 		Eventually(hasNumSourceObjects(ctx, "", "limitranges"), countUpdateTime).Should(BeFalse())
 	})
+
+	It("should set NumDriftedObjects for a type in audit mode without propagating anything", func() {
+		AddToHNCConfig(ctx, "", "limitranges", api.Audit)
+		SetParent(ctx, barName, fooName)
+		MakeObject(ctx, "limitranges", fooName, "foo-lr")
+
+		// The missing copy in bar is a drift.
+		Eventually(getNumDriftedObjects(ctx, "", "limitranges"), countUpdateTime).Should(Equal(1))
+		Consistently(HasObject(ctx, "limitranges", barName, "foo-lr")).Should(BeFalse())
+
+		// Once the source is gone, there's nothing to propagate.
+		DeleteObject(ctx, "limitranges", fooName, "foo-lr")
+		Eventually(getNumDriftedObjects(ctx, "", "limitranges"), countUpdateTime).Should(Equal(0))
+	})
 })
 
 func typeSpecMode(ctx context.Context, group, resource string) func() api.SynchronizationMode {
@@ -616,6 +677,27 @@ func getNumPropagatedObjects(ctx context.Context, group, resource string) func()
 	}
 }
 
+// getNumDriftedObjects returns NumDriftedObjects status for a given type. If NumDriftedObjects is
+// not set or if type does not exist in status, it returns -1 and an error.
+func getNumDriftedObjects(ctx context.Context, group, resource string) func() (int, error) {
+	return func() (int, error) {
+		c, err := GetHNCConfig(ctx)
+		if err != nil {
+			return -1, err
+		}
+		for _, t := range c.Status.Resources {
+			if t.Group == group && t.Resource == resource {
+				if t.NumDriftedObjects != nil {
+					return *t.NumDriftedObjects, nil
+				}
+				return -1, fmt.Errorf("NumDriftedObjects field is not set for "+
+					"group %s, resource %s", group, resource)
+			}
+		}
+		return -1, fmt.Errorf("group %s, resource %s is not found in status", group, resource)
+	}
+}
+
 // hasNumSourceObjects returns true if NumSourceObjects is set (not nil) for a specific type and returns false
 // if NumSourceObjects is not set. It returns false and an error if the type does not exist in the status.
 func hasNumSourceObjects(ctx context.Context, group, resource string) func() (bool, error) {
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/go-logr/logr"
 	k8sadm "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/rest"
@@ -77,6 +79,10 @@ func (v *Validator) Handle(ctx context.Context, req admission.Request) admission
 // handle implements the validation logic of this validator for Create and Update operations,
 // allowing it to be more easily unit tested (ie without constructing a full admission.Request).
 func (v *Validator) handle(inst *api.HNCConfiguration) admission.Response {
+	if allErrs := validateDefaultParents(inst); len(allErrs) > 0 {
+		return webhooks.DenyInvalid(api.HNCConfigurationGK, api.HNCConfigSingleton, allErrs)
+	}
+
 	ts := gvkSet{}
 	// Convert all valid types from GR to GVK. If any type is invalid, e.g. not
 	// exist in the apiserver, wrong configuration, deny the request.
@@ -105,10 +111,22 @@ func (v *Validator) validateTypes(inst *api.HNCConfiguration, ts gvkSet) admissi
 			allErrs = append(allErrs, fldErr)
 		}
 
-		// Validate the type configured is not an HNC enforced type.
+		// The types enforced by HNC always use the 'Propagate' mode, so only their update strategy can
+		// be configured.
 		if gvkChecker.isEnforced(gvk) {
-			fldErr := field.Invalid(fldPath, gr, "always uses the 'Propagate' mode and cannot be configured")
-			allErrs = append(allErrs, fldErr)
+			if (r.Mode != "" && r.Mode != api.Propagate) || r.UpdateStrategy == "" || len(r.UnpropagatedFields) > 0 {
+				fldErr := field.Invalid(fldPath, gr, "always uses the 'Propagate' mode; only its updateStrategy can be configured")
+				allErrs = append(allErrs, fldErr)
+			}
+			r.Mode = api.Propagate
+		}
+
+		// Validate the unpropagated fields. HNC needs the type and metadata of every object it
+		// propagates; labels and annotations can be excluded with command-line args instead.
+		for j, path := range r.UnpropagatedFields {
+			if msg := validateFieldPath(path); msg != "" {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("unpropagatedFields").Index(j), path, msg))
+			}
 		}
 
 		// Validate if the configuration of a type already exists. Each type should
@@ -125,6 +143,51 @@ func (v *Validator) validateTypes(inst *api.HNCConfiguration, ts gvkSet) admissi
 	return webhooks.Allow("")
 }
 
+// validateDefaultParents checks that the default parent rules have unique names, valid regexes and
+// selectors, and a parent.
+func validateDefaultParents(inst *api.HNCConfiguration) field.ErrorList {
+	allErrs := field.ErrorList{}
+	names := map[string]bool{}
+	for i, rule := range inst.Spec.DefaultParents {
+		fldPath := field.NewPath("spec", "defaultParents").Index(i)
+		switch {
+		case rule.Name == "":
+			allErrs = append(allErrs, field.Required(fldPath.Child("name"), "every rule must have a name"))
+		case names[rule.Name]:
+			allErrs = append(allErrs, field.Duplicate(fldPath.Child("name"), rule.Name))
+		}
+		names[rule.Name] = true
+
+		if _, err := regexp.Compile("^" + rule.NameRegex + "$"); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("nameRegex"), rule.NameRegex, err.Error()))
+		}
+		if rule.Selector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(rule.Selector); err != nil {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("selector"), rule.Selector, err.Error()))
+			}
+		}
+		if rule.Parent == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("parent"), "every rule must have a parent"))
+		}
+	}
+	return allErrs
+}
+
+// validateFieldPath returns an error message if the path can't be used as an unpropagated field.
+func validateFieldPath(path string) string {
+	segs := strings.Split(path, ".")
+	for _, seg := range segs {
+		if seg == "" {
+			return "must be a dot-separated list of field names"
+		}
+	}
+	switch segs[0] {
+	case "apiVersion", "kind", "metadata":
+		return fmt.Sprintf("%q is always propagated", segs[0])
+	}
+	return ""
+}
+
 func (v *Validator) checkForest(ts gvkSet) admission.Response {
 	v.Forest.Lock()
 	defer v.Forest.Unlock()
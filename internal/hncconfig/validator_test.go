@@ -99,6 +99,36 @@ func TestRBACTypes(t *testing.T) {
 			},
 			allow: false,
 		},
+		{
+			name: "Configure the update strategy of enforced resources",
+			configs: []api.ResourceSpec{
+				{Group: api.RBACGroup, Resource: api.RoleBindingResource, UpdateStrategy: api.UpdateStrategyFail},
+				{Group: api.RBACGroup, Resource: api.RoleResource, Mode: api.Propagate, UpdateStrategy: api.UpdateStrategyUpdate},
+			},
+			allow: true,
+		},
+		{
+			name: "Configure the update strategy of enforced resources with another mode",
+			configs: []api.ResourceSpec{
+				{Group: api.RBACGroup, Resource: api.RoleBindingResource, Mode: api.Ignore, UpdateStrategy: api.UpdateStrategyFail},
+			},
+			allow: false,
+		},
+		{
+			name: "Configure unpropagated fields of enforced resources",
+			configs: []api.ResourceSpec{
+				{Group: api.RBACGroup, Resource: api.RoleBindingResource, UpdateStrategy: api.UpdateStrategyFail, UnpropagatedFields: []string{"subjects"}},
+			},
+			allow: false,
+		},
+		{
+			name: "Configure the update strategy of enforced resources twice",
+			configs: []api.ResourceSpec{
+				{Group: api.RBACGroup, Resource: api.RoleBindingResource, UpdateStrategy: api.UpdateStrategyFail},
+				{Group: api.RBACGroup, Resource: api.RoleBindingResource, UpdateStrategy: api.UpdateStrategyUpdate},
+			},
+			allow: false,
+		},
 		{
 			name: "Configure redundant enforced resources without specifying group",
 			configs: []api.ResourceSpec{
@@ -164,6 +194,27 @@ func TestNonRBACTypes(t *testing.T) {
 			},
 			validator: f,
 			allow:     false,
+		}, {
+			name: "Valid unpropagated fields",
+			configs: []api.ResourceSpec{
+				{Group: "", Resource: "secrets", UnpropagatedFields: []string{"data.token", "spec.items.id"}},
+			},
+			validator: f,
+			allow:     true,
+		}, {
+			name: "Unpropagated fields with empty field names",
+			configs: []api.ResourceSpec{
+				{Group: "", Resource: "secrets", UnpropagatedFields: []string{"data..token"}},
+			},
+			validator: f,
+			allow:     false,
+		}, {
+			name: "Unpropagated metadata",
+			configs: []api.ResourceSpec{
+				{Group: "", Resource: "secrets", UnpropagatedFields: []string{"metadata.labels"}},
+			},
+			validator: f,
+			allow:     false,
 		}}
 
 	for _, tc := range tests {
@@ -185,6 +236,42 @@ func TestNonRBACTypes(t *testing.T) {
 	}
 }
 
+func TestDefaultParents(t *testing.T) {
+	sel := &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}
+	badSel := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "bogus"}}}
+	tests := []struct {
+		name  string
+		rules []api.DefaultParentRule
+		allow bool
+	}{
+		{name: "ok: regex", rules: []api.DefaultParentRule{{Name: "teams", NameRegex: "team-([a-z]+)-.*", Parent: "team-$1"}}, allow: true},
+		{name: "ok: selector", rules: []api.DefaultParentRule{{Name: "team-a", Selector: sel, Parent: "team-a"}}, allow: true},
+		{name: "ok: catch-all", rules: []api.DefaultParentRule{{Name: "all", Parent: "unsorted"}}, allow: true},
+		{name: "missing name", rules: []api.DefaultParentRule{{NameRegex: "a", Parent: "b"}}},
+		{name: "duplicate name", rules: []api.DefaultParentRule{{Name: "r", NameRegex: "a", Parent: "b"}, {Name: "r", NameRegex: "c", Parent: "d"}}},
+		{name: "bad regex", rules: []api.DefaultParentRule{{Name: "r", NameRegex: "(", Parent: "b"}}},
+		{name: "bad selector", rules: []api.DefaultParentRule{{Name: "r", Selector: badSel, Parent: "b"}}},
+		{name: "missing parent", rules: []api.DefaultParentRule{{Name: "r", NameRegex: "a"}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			c := &api.HNCConfiguration{Spec: api.HNCConfigurationSpec{DefaultParents: tc.rules}}
+			c.Name = api.HNCConfigSingleton
+			validator := &Validator{
+				mapper: fakeResourceMapper{},
+				Forest: forest.NewForest(),
+				Log:    zap.New(),
+			}
+
+			got := validator.handle(c)
+
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).Should(Equal(tc.allow))
+		})
+	}
+}
+
 func TestPropagateConflict(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -3,7 +3,9 @@ package integtest
 import (
 	"context"
 	"crypto/rand"
+	"encoding/base64"
 	"fmt"
+	"runtime"
 	"strings"
 
 	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 Ignoring this for now
@@ -272,6 +274,25 @@ func AddToHNCConfig(ctx context.Context, group, resource string, mode api.Synchr
 	}).Should(Succeed(), "While adding %s/%s=%s to HNC config", group, resource, mode)
 }
 
+// SetResourceInHNCConfigWithOffset adds the spec to the HNC config, replacing any existing spec for
+// the same resource.
+func SetResourceInHNCConfigWithOffset(ctx context.Context, offset int, spec api.ResourceSpec) {
+	EventuallyWithOffset(offset+1, func() error {
+		c, err := GetHNCConfig(ctx)
+		if err != nil {
+			return err
+		}
+		rscs := []api.ResourceSpec{}
+		for _, rsc := range c.Spec.Resources {
+			if rsc.Group != spec.Group || rsc.Resource != spec.Resource {
+				rscs = append(rscs, rsc)
+			}
+		}
+		c.Spec.Resources = append(rscs, spec)
+		return UpdateHNCConfig(ctx, c)
+	}).Should(Succeed(), "While setting %s/%s=%s to HNC config", spec.Group, spec.Resource, spec.Mode)
+}
+
 // HasObject returns true if a namespace contains a specific object of the given kind.
 //
 //	The kind and its corresponding GVK should be included in the GVKs map.
@@ -339,6 +360,21 @@ func MakeSecrettWithType(ctx context.Context, nsName, name, scType string) {
 	createdObjects = append(createdObjects, inst)
 }
 
+// MakeSecretWithData creates a Secret with the given data in a specific namespace.
+func MakeSecretWithData(ctx context.Context, nsName, name string, data map[string][]byte) {
+	inst := &unstructured.Unstructured{}
+	inst.SetGroupVersionKind(GVKs["secrets"])
+	inst.SetNamespace(nsName)
+	inst.SetName(name)
+	encoded := map[string]interface{}{}
+	for k, v := range data {
+		encoded[k] = base64.StdEncoding.EncodeToString(v)
+	}
+	inst.UnstructuredContent()["data"] = encoded
+	ExpectWithOffset(1, K8sClient.Create(ctx, inst)).Should(Succeed())
+	createdObjects = append(createdObjects, inst)
+}
+
 // UpdateObjectWithAnnotations gets an object given it's kind, nsName and name, adds the annotation
 // and updates this object
 func UpdateObjectWithAnnotations(ctx context.Context, resource, nsName, name string, a map[string]string) {
@@ -376,6 +412,16 @@ func CleanupObjects(ctx context.Context) {
 	createdObjects = []*unstructured.Unstructured{}
 }
 
+// HeapAlloc forces a garbage collection and returns the number of bytes that are still allocated on
+// the heap afterwards. Since the reconcilers run in the same process as the tests, this can be used
+// to measure how much memory they use.
+func HeapAlloc() uint64 {
+	runtime.GC()
+	ms := runtime.MemStats{}
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc
+}
+
 // ObjectInheritedFrom returns the name of the namespace where a specific object of a given kind
 // is propagated from or an empty string if the object is not a propagated object. The kind and
 // its corresponding GVK should be included in the GVKs map.
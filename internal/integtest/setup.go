@@ -17,6 +17,7 @@ package integtest
 
 import (
 	"context"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -141,6 +142,11 @@ func HNCBeforeSuite() {
 		UseFakeClient: true,
 		HNCCfgRefresh: 1 * time.Second, // so we don't have to wait as long
 		HRQ:           true,
+
+		HierarchyHistoryLimit: api.DefaultHierarchyHistoryLimit,
+
+		// Allows the whole suite to be run in metadata-only mode as well.
+		CacheObjectMetadataOnly: os.Getenv("HNC_TEST_CACHE_METADATA_ONLY") != "",
 	}
 	TestForest = forest.NewForest()
 	err = setup.CreateReconcilers(k8sManager, TestForest, opts)
@@ -0,0 +1,65 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt -n PARENT CHILD",
+	Short: "Turns an existing full namespace into a subnamespace of the given parent.",
+	Example: `# Make the existing namespace 'foo' a subnamespace of 'bar'
+	kubectl hns adopt foo -n bar`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		parent, _ := cmd.Flags().GetString("namespace")
+		if parent == "" {
+			fmt.Println("Error: parent must be set via --namespace or -n")
+			os.Exit(1)
+		}
+		client.createAdoptingAnchor(parent, args[0])
+	},
+}
+
+var releaseCmd = &cobra.Command{
+	Use:   "release -n PARENT CHILD",
+	Short: "Turns a subnamespace into a full namespace, deleting its anchor but not the namespace.",
+	Example: `# Turn the 'foo' subnamespace of 'bar' into a full namespace
+	kubectl hns release foo -n bar`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		parent, _ := cmd.Flags().GetString("namespace")
+		if parent == "" {
+			fmt.Println("Error: parent must be set via --namespace or -n")
+			os.Exit(1)
+		}
+		client.releaseAnchor(parent, args[0])
+	},
+}
+
+func newAdoptCmd(defaultNs string) *cobra.Command {
+	adoptCmd.Flags().StringVarP(&namespace, "namespace", "n", defaultNs, "The parent namespace that adopts the namespace")
+	return adoptCmd
+}
+
+func newReleaseCmd(defaultNs string) *cobra.Command {
+	releaseCmd.Flags().StringVarP(&namespace, "namespace", "n", defaultNs, "The parent namespace of the subnamespace")
+	return releaseCmd
+}
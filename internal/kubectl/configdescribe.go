@@ -39,10 +39,19 @@ var configDescribeCmd = &cobra.Command{
 				action = "Removing"
 			case api.AllowPropagate:
 				action = "AllowPropagate"
+			case api.Audit:
+				action = "Auditing"
 			default:
 				action = "Ignoring"
 			}
-			fmt.Printf("* %s: %s (%s/%s)\n", action, r.Resource, r.Group, r.Version)
+			details := ""
+			if r.NumDriftedObjects != nil {
+				details = fmt.Sprintf(" - %d drifted object(s)", *r.NumDriftedObjects)
+			}
+			if r.NumStaleObjects != nil && *r.NumStaleObjects > 0 {
+				details += fmt.Sprintf(" - %d stale object(s)", *r.NumStaleObjects)
+			}
+			fmt.Printf("* %s: %s (%s/%s)%s\n", action, r.Resource, r.Group, r.Version, details)
 		}
 		fmt.Print("\nConditions:\n")
 		for _, c := range config.Status.Conditions {
@@ -27,13 +27,18 @@ import (
 )
 
 var setResourceCmd = &cobra.Command{
-	Use: fmt.Sprintf("set-resource RESOURCE [--group GROUP] [--force] --mode <%s|%s|%s|%s>",
-		api.Propagate, api.Remove, api.Ignore, api.AllowPropagate),
+	Use: fmt.Sprintf("set-resource RESOURCE [--group GROUP] [--force] --mode <%s|%s|%s|%s|%s> [--update-strategy <%s|%s|%s>]",
+		api.Propagate, api.Remove, api.Ignore, api.AllowPropagate, api.Audit,
+		api.UpdateStrategyUpdate, api.UpdateStrategyRecreate, api.UpdateStrategyFail),
 	Short: "Sets the HNC configuration of a specific resource",
 	Example: fmt.Sprintf("  # Set configuration of a core type\n" +
 		"  kubectl hns config set-resource secrets --mode Ignore\n\n" +
 		"  # Set configuration of a custom type\n" +
-		"  kubectl hns config set-resource crontabs --group stable.example.com --mode Propagate"),
+		"  kubectl hns config set-resource crontabs --group stable.example.com --mode Propagate\n\n" +
+		"  # Recreate copies whose immutable fields have changed\n" +
+		"  kubectl hns config set-resource secrets --mode Propagate --update-strategy Recreate\n\n" +
+		"  # Stop recreating RoleBindings whose roleRef has changed (their mode can't be set)\n" +
+		"  kubectl hns config set-resource rolebindings --group rbac.authorization.k8s.io --update-strategy Fail"),
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		resource := args[0]
@@ -42,6 +47,8 @@ var setResourceCmd = &cobra.Command{
 		modeStr, _ := flags.GetString("mode")
 		mode := normalizeMode(modeStr)
 		force, _ := flags.GetBool("force")
+		strategyStr, _ := flags.GetString("update-strategy")
+		strategy := api.UpdateStrategy(cases.Title(language.English).String(strategyStr))
 		config := client.getHNCConfig()
 
 		exist := false
@@ -58,6 +65,9 @@ var setResourceCmd = &cobra.Command{
 					os.Exit(1)
 				}
 				r.Mode = mode
+				if strategy != "" {
+					r.UpdateStrategy = strategy
+				}
 				exist = true
 				break
 			}
@@ -66,9 +76,10 @@ var setResourceCmd = &cobra.Command{
 		if !exist {
 			config.Spec.Resources = append(config.Spec.Resources,
 				api.ResourceSpec{
-					Group:    group,
-					Resource: resource,
-					Mode:     mode,
+					Group:          group,
+					Resource:       resource,
+					Mode:           mode,
+					UpdateStrategy: strategy,
 				})
 		}
 
@@ -78,7 +89,8 @@ var setResourceCmd = &cobra.Command{
 
 func newSetResourceCmd() *cobra.Command {
 	setResourceCmd.Flags().String("group", "", "The group of the resource; may be omitted for core resources (or explicitly set to the empty string)")
-	setResourceCmd.Flags().String("mode", "", "The synchronization mode: one of Propagate, Remove, Ignore and AllowPropagate")
+	setResourceCmd.Flags().String("mode", "", "The synchronization mode: one of Propagate, Remove, Ignore, AllowPropagate and Audit")
+	setResourceCmd.Flags().String("update-strategy", "", "What to do if a propagated object can't be updated because of its immutable fields: one of Update, Recreate and Fail. Unchanged if omitted")
 	setResourceCmd.Flags().BoolP("force", "f", false, "Allow the synchronization mode to be changed directly from Ignore to Propagate or AllowPropagate despite the dangers of doing so")
 	return setResourceCmd
 }
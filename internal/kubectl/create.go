@@ -18,26 +18,76 @@ package kubectl
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
 )
 
 var createCmd = &cobra.Command{
 	Use:   "create -n PARENT CHILD",
 	Short: "Creates a subnamespace under the given parent.",
-	Args:  cobra.ExactArgs(1),
+	Example: `# Create the 'foo' subnamespace in the parent 'bar' namespace
+	kubectl hns create foo -n bar
+
+	# Create 'bar-foo' instead, if the naming policy requires subnamespace names to start with '{{parent}}-'
+	kubectl hns create foo -n bar --auto-prefix`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		parent, _ := cmd.Flags().GetString("namespace")
 		if parent == "" {
 			fmt.Println("Error: parent must be set via --namespace or -n")
 			os.Exit(1)
 		}
+		nnm := args[0]
+		if autoPrefix, _ := cmd.Flags().GetBool("auto-prefix"); autoPrefix {
+			if prefix := getRequiredPrefix(parent); !strings.HasPrefix(nnm, prefix) {
+				nnm = prefix + nnm
+			}
+		}
 		// Create the anchor, the custom resource representing the subnamespace.
-		client.createAnchor(parent, args[0])
+		client.createAnchor(parent, nnm)
 	},
 }
 
+// getRequiredPrefix returns the prefix required by the naming policies (if any) that apply to new
+// subnamespaces of the parent; see api.SubnamespaceNamingPolicy. The policies of the parent and all
+// its ancestors apply, so this is the longest of their prefixes, as long as the others are prefixes
+// of it; otherwise, no name can satisfy all of them, and the nearest one is returned.
+func getRequiredPrefix(parent string) string {
+	policies := []*api.SubnamespaceNamingPolicy{}
+	root := parent
+	visited := map[string]bool{}
+	for nm := parent; nm != "" && !visited[nm]; {
+		visited[nm] = true
+		root = nm
+		hc := client.getHierarchy(nm)
+		if hc.Spec.SubnamespaceNaming != nil {
+			policies = append(policies, hc.Spec.SubnamespaceNaming)
+		}
+		nm = hc.Spec.Parent
+	}
+	if len(policies) == 0 {
+		return ""
+	}
+
+	longest := ""
+	for _, policy := range policies {
+		prefix := policy.RequiredPrefix(parent, root)
+		switch {
+		case strings.HasPrefix(longest, prefix):
+		case strings.HasPrefix(prefix, longest):
+			longest = prefix
+		default:
+			return policies[0].RequiredPrefix(parent, root)
+		}
+	}
+	return longest
+}
+
 func newCreateCmd(defaultNs string) *cobra.Command {
 	createCmd.Flags().StringVarP(&namespace, "namespace", "n", defaultNs, "The parent namespace for the new subnamespace")
+	createCmd.Flags().Bool("auto-prefix", false, "Adds the prefix required by the subnamespace naming policy to the name, if it doesn't already have it")
 	return createCmd
 }
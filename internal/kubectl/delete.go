@@ -29,7 +29,10 @@ var deleteCmd = &cobra.Command{
 	kubectl hns delete foo -n bar
 
 	# Allow 'foo' to be cascading deleted and delete it
-	kubectl hns delete foo -n bar --allowCascadingDeletion`,
+	kubectl hns delete foo -n bar --allowCascadingDeletion
+
+	# Show which namespaces and workloads would be deleted along with 'foo', without deleting anything
+	kubectl hns delete foo -n bar --dry-run`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		nnm := args[0]
@@ -41,6 +44,14 @@ var deleteCmd = &cobra.Command{
 		}
 
 		allowCD, _ := cmd.Flags().GetBool("allowCascadingDeletion")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if dryRun {
+			if allowCD {
+				fmt.Println("Dry run: ignoring --allowCascadingDeletion; showing what would be deleted once it's set")
+			}
+			client.dryRunDeleteAnchor(parent, nnm)
+			return
+		}
 		if allowCD {
 			hc := client.getHierarchy(nnm)
 			if hc.Spec.AllowCascadingDeletion {
@@ -59,5 +70,6 @@ var deleteCmd = &cobra.Command{
 func newDeleteCmd(defaultNs string) *cobra.Command {
 	deleteCmd.Flags().StringVarP(&namespace, "namespace", "n", defaultNs, "The parent namespace for the new subnamespace")
 	deleteCmd.Flags().BoolP("allowCascadingDeletion", "a", false, "Allows cascading deletion of its subnamespaces.")
+	deleteCmd.Flags().Bool("dry-run", false, "Only shows what would be deleted, without deleting anything.")
 	return deleteCmd
 }
@@ -77,9 +77,26 @@ var describeCmd = &cobra.Command{
 		// AllowCascadingDeletion
 		fmt.Printf("  Allows Cascading Deletion: %t\n", hier.Spec.AllowCascadingDeletion)
 
+		// NetworkIsolation
+		if hier.Spec.NetworkIsolation != "" {
+			fmt.Printf("  Network Isolation: %s\n", hier.Spec.NetworkIsolation)
+			for _, sel := range hier.Spec.NetworkIsolationExceptions {
+				fmt.Printf("  - Except from: %s\n", metav1.FormatLabelSelector(&sel))
+			}
+		}
+
+		// Halted
+		if hier.Spec.Halted {
+			fmt.Printf("  Halted by an administrator\n")
+		}
+
 		// Conditions
 		describeConditions(hier.Status.Conditions)
 
+		// Propagated objects
+		describeObjects(nnm)
+		describeFailedPropagation(nnm, hier)
+
 		// Events
 		describeEvents(nnm)
 	},
@@ -112,6 +129,11 @@ func describeEvents(nnm string) {
 		return
 	}
 	fmt.Printf("\nEvents from the objects in namespace %s\n", nnm)
+	printEvents(hncEvents)
+}
+
+// printEvents prints a table of the events, showing only the latest one for each reason and object.
+func printEvents(hncEvents []v1.Event) {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
 	fmt.Fprintln(w, "Last Seen\tReason\tObject\tMessage")
 	set := make(map[string]bool)
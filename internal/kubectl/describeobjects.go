@@ -0,0 +1,236 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	"sigs.k8s.io/hierarchical-namespaces/internal/selectors"
+)
+
+// describeObjects lists the objects that this namespace inherits from its ancestors, and the source
+// objects that it propagates to its descendants. Only the types that HNC propagates, according to
+// the status of the HNCConfiguration, are included, and only the metadata of the objects is read.
+// Sources are only listed if they have copies, or if they're eligible to be propagated to at least
+// one descendant according to their exception annotations (e.g. they're not excluded by
+// propagate.hnc.x-k8s.io/none, and their selectors match a descendant).
+func describeObjects(nnm string) {
+	types, err := getPropagatedTypes()
+	if err != nil {
+		fmt.Printf("  Could not read the propagated types from the HNC configuration: %s\n", err)
+		return
+	}
+
+	inherited := []string{}
+	propagated := []string{}
+	var descLabels []labels.Set
+	for _, rs := range types {
+		gvr := schema.GroupVersionResource{Group: rs.Group, Version: rs.Version, Resource: rs.Resource}
+		var copies map[string]int
+		err := listObjectMetadata(gvr, nnm, "", func(obj *metav1.PartialObjectMetadata) {
+			name := gvr.GroupResource().String() + "/" + obj.Name
+			if from, ok := obj.Labels[api.LabelInheritedFrom]; ok {
+				inherited = append(inherited, fmt.Sprintf("%s (from %s)", name, from))
+				return
+			}
+			// Only count the copies of this type once we know there's a source object.
+			if copies == nil {
+				copies = countCopies(gvr, nnm)
+			}
+			if n := copies[obj.Name]; n > 0 {
+				propagated = append(propagated, fmt.Sprintf("%s (%d copies)", name, n))
+				return
+			}
+			if descLabels == nil {
+				descLabels = getDescendantLabels(nnm)
+			}
+			if isEligible(gvr, rs.Mode, obj, descLabels) {
+				propagated = append(propagated, fmt.Sprintf("%s (0 copies)", name))
+			}
+		})
+		if err != nil {
+			fmt.Printf("  Could not list %s: %s\n", gvr.GroupResource(), err)
+		}
+	}
+
+	if len(inherited) > 0 {
+		sort.Strings(inherited)
+		fmt.Printf("  Inherited objects:\n  - %s\n", strings.Join(inherited, "\n  - "))
+	} else {
+		fmt.Printf("  No inherited objects\n")
+	}
+	if len(propagated) > 0 {
+		sort.Strings(propagated)
+		fmt.Printf("  Source objects propagated to descendants:\n  - %s\n", strings.Join(propagated, "\n  - "))
+	} else {
+		fmt.Printf("  No source objects propagated to descendants\n")
+	}
+}
+
+// isEligible returns true if the source object would be propagated to at least one of the
+// descendants with the given labels, according to the propagation mode of its type and its
+// exception annotations. Since only the metadata of the object is known, exclusions that depend on
+// its contents (e.g. the types of Secrets that are never propagated) aren't considered.
+func isEligible(gvr schema.GroupVersionResource, mode api.SynchronizationMode, obj *metav1.PartialObjectMetadata, descLabels []labels.Set) bool {
+	gvk, err := mapper.KindFor(gvr)
+	if err != nil {
+		return false
+	}
+	inst := &unstructured.Unstructured{}
+	inst.SetGroupVersionKind(gvk)
+	inst.SetName(obj.Name)
+	inst.SetLabels(obj.Labels)
+	inst.SetAnnotations(obj.Annotations)
+	for _, l := range descLabels {
+		if ok, _ := selectors.ShouldPropagate(inst, l, mode); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// describeFailedPropagation lists the recent events about objects that couldn't be propagated into
+// this namespace: the CannotUpdateObject events on the copies in this namespace, and the
+// CannotPropagateObject events on the sources in its ancestors that refer to this namespace.
+func describeFailedPropagation(nnm string, hier *api.HierarchyConfiguration) {
+	failed := listEvents(nnm, api.EventCannotUpdate)
+	dest := fmt.Sprintf("destination namespace %q", nnm)
+	visited := map[string]bool{nnm: true}
+	for pnm := hier.Spec.Parent; pnm != "" && !visited[pnm]; pnm = client.getHierarchy(pnm).Spec.Parent {
+		visited[pnm] = true
+		for _, event := range listEvents(pnm, api.EventCannotPropagate) {
+			if strings.Contains(event.Message, dest) {
+				failed = append(failed, event)
+			}
+		}
+	}
+
+	if len(failed) == 0 {
+		fmt.Printf("  No recent failures to propagate objects into this namespace\n")
+		return
+	}
+	fmt.Printf("\nRecent failures to propagate objects into namespace %s\n", nnm)
+	printEvents(failed)
+}
+
+// getPropagatedTypes returns the statuses of the types that HNC may propagate, according to the
+// status of the HNCConfiguration. Unlike getHNCConfig, it returns an error rather than exiting,
+// since most users aren't allowed to read cluster-wide objects.
+func getPropagatedTypes() ([]api.ResourceStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	config := &api.HNCConfiguration{}
+	if err := hncClient.Get().Resource(api.HNCConfigSingletons).Name(api.HNCConfigSingleton).Do(ctx).Into(config); err != nil {
+		return nil, err
+	}
+	types := []api.ResourceStatus{}
+	for _, rs := range config.Status.Resources {
+		if rs.Mode == api.Ignore || rs.Mode == api.Remove {
+			continue
+		}
+		types = append(types, rs)
+	}
+	return types, nil
+}
+
+// countCopies returns the number of propagated copies of each source object of the given type in
+// the namespace, by name. It lists the copies across the cluster if possible, and otherwise in each
+// descendant of the namespace.
+func countCopies(gvr schema.GroupVersionResource, nnm string) map[string]int {
+	copies := map[string]int{}
+	count := func(obj *metav1.PartialObjectMetadata) {
+		copies[obj.Name]++
+	}
+	selector := api.LabelInheritedFrom + "=" + nnm
+	err := listObjectMetadata(gvr, "", selector, count)
+	if errors.IsForbidden(err) {
+		for _, dnm := range getDescendantNames(nnm) {
+			if err := listObjectMetadata(gvr, dnm, selector, count); err != nil {
+				fmt.Printf("  Could not list %s in %s: %s\n", gvr.GroupResource(), dnm, err)
+			}
+		}
+	} else if err != nil {
+		fmt.Printf("  Could not list copies of %s: %s\n", gvr.GroupResource(), err)
+	}
+	return copies
+}
+
+// getDescendantNames returns the names of all descendants of the namespace.
+func getDescendantNames(nnm string) []string {
+	names := []string{}
+	visited := map[string]bool{nnm: true}
+	queue := client.getHierarchy(nnm).Status.Children
+	for len(queue) > 0 {
+		cnm := queue[0]
+		queue = queue[1:]
+		if visited[cnm] {
+			continue
+		}
+		visited[cnm] = true
+		names = append(names, cnm)
+		queue = append(queue, client.getHierarchy(cnm).Status.Children...)
+	}
+	return names
+}
+
+// getDescendantLabels returns the labels of each descendant of the namespace that the user is
+// allowed to read. The result is never nil, so that callers can tell it's already been computed.
+func getDescendantLabels(nnm string) []labels.Set {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := []labels.Set{}
+	for _, dnm := range getDescendantNames(nnm) {
+		ns, err := k8sClient.CoreV1().Namespaces().Get(ctx, dnm, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		result = append(result, labels.Set(ns.Labels))
+	}
+	return result
+}
+
+// listEvents returns the HNC events in the namespace with the given reason. Errors are ignored,
+// since the events are only informational.
+func listEvents(nnm, reason string) []v1.Event {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := k8sClient.CoreV1().Events(nnm).List(ctx, metav1.ListOptions{FieldSelector: "reason=" + reason})
+	if err != nil {
+		return nil
+	}
+	hncEvents := []v1.Event{}
+	for _, event := range events.Items {
+		if event.Source.Component == api.MetaGroup {
+			hncEvents = append(hncEvents, event)
+		}
+	}
+	return hncEvents
+}
@@ -0,0 +1,59 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history NAMESPACE",
+	Short: "Displays the recorded changes to the hierarchy around a namespace",
+	Long: `Displays the changes to the hierarchy that affected a namespace, oldest first, such as changes
+to its parent, children being added or removed, and conditions being added or resolved.
+The Parent column shows the parent of the namespace after each change.`,
+	Example: `# Show the history of the 'foo' namespace
+	kubectl hns history foo`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		nnm := args[0]
+		hist := client.getHierarchyHistory(nnm)
+		if len(hist.Entries) == 0 {
+			fmt.Printf("No history recorded for namespace %s\n", nnm)
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tREASON\tPARENT\tMESSAGE")
+		for _, e := range hist.Entries {
+			parent := e.Parent
+			if parent == "" {
+				parent = "<none>"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Time.Local().Format(time.RFC3339), e.Reason, parent, e.Message)
+		}
+		w.Flush()
+	},
+}
+
+func newHistoryCmd() *cobra.Command {
+	return historyCmd
+}
@@ -0,0 +1,70 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// moveTimeout is how long to wait for HNC to delete the old anchor of a moved subnamespace.
+const moveTimeout = 30 * time.Second
+
+var moveCmd = &cobra.Command{
+	Use:   "move CHILD NEW_PARENT",
+	Short: "Moves a subnamespace to a new parent without recreating it.",
+	Example: `# Move the 'foo' subnamespace from its current parent to 'bar'
+	kubectl hns move foo bar`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		nnm := args[0]
+		pnm := args[1]
+
+		from := client.getHierarchy(nnm).Spec.Parent
+		if from == "" || client.getAnchorStatus(from)[nnm] == "" {
+			fmt.Printf("Error: %q is not a subnamespace; use 'kubectl hns set' to change the parent of a full namespace\n", nnm)
+			os.Exit(1)
+		}
+		if from == pnm {
+			fmt.Printf("%q is already a subnamespace of %q; unchanged\n", nnm, pnm)
+			return
+		}
+
+		// Create the new anchor, which takes over the subnamespace from the old one.
+		client.createMovingAnchor(pnm, nnm, from)
+
+		// Wait for HNC to move the subnamespace and delete its old anchor.
+		deadline := time.Now().Add(moveTimeout)
+		for {
+			if _, ok := client.getAnchorStatus(from)[nnm]; !ok {
+				break
+			}
+			if time.Now().After(deadline) {
+				fmt.Printf("Timed out waiting for HNC to move %q: its old anchor in %q still exists\n", nnm, from)
+				os.Exit(1)
+			}
+			time.Sleep(time.Second)
+		}
+		fmt.Printf("Successfully moved %q from %q to %q\n", nnm, from, pnm)
+	},
+}
+
+func newMoveCmd() *cobra.Command {
+	return moveCmd
+}
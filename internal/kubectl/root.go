@@ -32,6 +32,7 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/metadata"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
 
@@ -44,6 +45,7 @@ var hncClient *rest.RESTClient
 var rootCmd *cobra.Command
 var client Client
 var dynamicClient dynamic.Interface
+var metadataClient metadata.Interface
 var mapper meta.RESTMapper
 
 type realClient struct{}
@@ -51,13 +53,24 @@ type anchorStatus map[string]string
 
 type Client interface {
 	getHierarchy(nnm string) *api.HierarchyConfiguration
+	getHierarchyHistory(nnm string) *api.HierarchyHistory
+	// listHierarchies returns nil if the user isn't allowed to list hierarchies across the cluster.
+	listHierarchies() *api.HierarchyConfigurationList
 	updateHierarchy(hier *api.HierarchyConfiguration, reason string)
 	createAnchor(nnm string, hnnm string)
+	createMovingAnchor(nnm string, hnnm string, from string)
+	createAdoptingAnchor(nnm string, hnnm string)
+	releaseAnchor(nnm string, hnnm string)
+	restoreAnchor(nnm string, hnnm string)
 	deleteAnchor(nnm string, hnnm string)
+	dryRunDeleteAnchor(nnm string, hnnm string)
 	getAnchorStatus(nnm string) anchorStatus
 	getHNCConfig() *api.HNCConfiguration
 	updateHNCConfig(*api.HNCConfiguration)
 	getHRQ(names []string, nnm string) *api.HierarchicalResourceQuotaList
+	// listHRQs lists the HRQs in the namespace, or across the cluster if it's empty. In the latter
+	// case, it returns nil if the user isn't allowed to list them.
+	listHRQs(nnm string) *api.HierarchicalResourceQuotaList
 }
 
 func init() {
@@ -112,6 +125,11 @@ func init() {
 				return err
 			}
 
+			metadataClient, err = metadata.NewForConfig(config)
+			if err != nil {
+				return err
+			}
+
 			return nil
 		},
 	}
@@ -119,9 +137,14 @@ func init() {
 
 	rootCmd.AddCommand(newSetCmd())
 	rootCmd.AddCommand(newDescribeCmd())
+	rootCmd.AddCommand(newHistoryCmd())
 	rootCmd.AddCommand(newTreeCmd())
 	rootCmd.AddCommand(newCreateCmd(defaultNs))
 	rootCmd.AddCommand(newDeleteCmd(defaultNs))
+	rootCmd.AddCommand(newMoveCmd())
+	rootCmd.AddCommand(newAdoptCmd(defaultNs))
+	rootCmd.AddCommand(newReleaseCmd(defaultNs))
+	rootCmd.AddCommand(newRestoreCmd(defaultNs))
 	rootCmd.AddCommand(newConfigCmd())
 	rootCmd.AddCommand(newVersionCmd())
 	rootCmd.AddCommand(newHrqCmd())
@@ -154,6 +177,38 @@ func (cl *realClient) getHierarchy(nnm string) *api.HierarchyConfiguration {
 	return hier
 }
 
+func (cl *realClient) listHierarchies() *api.HierarchyConfigurationList {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	hierList := &api.HierarchyConfigurationList{}
+	if err := hncClient.Get().Resource(api.HierarchyConfigurations).Do(ctx).Into(hierList); err != nil {
+		if errors.IsForbidden(err) {
+			return nil
+		}
+		fmt.Printf("Error listing hierarchies: %s\n", err)
+		os.Exit(1)
+	}
+	return hierList
+}
+
+func (cl *realClient) getHierarchyHistory(nnm string) *api.HierarchyHistory {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := k8sClient.CoreV1().Namespaces().Get(ctx, nnm, metav1.GetOptions{}); err != nil {
+		fmt.Printf("Error reading namespace %s: %s\n", nnm, err)
+		os.Exit(1)
+	}
+	hist := &api.HierarchyHistory{}
+	err := hncClient.Get().Resource(api.HierarchyHistories).Namespace(nnm).Name(api.Singleton).Do(ctx).Into(hist)
+	if err != nil && !errors.IsNotFound(err) {
+		fmt.Printf("Error reading history for %s: %s\n", nnm, err)
+		os.Exit(1)
+	}
+	return hist
+}
+
 func (cl *realClient) getAnchorStatus(nnm string) anchorStatus {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -193,18 +248,71 @@ func (cl *realClient) updateHierarchy(hier *api.HierarchyConfiguration, reason s
 }
 
 func (cl *realClient) createAnchor(nnm string, hnnm string) {
+	postAnchor(nnm, hnnm, nil)
+	fmt.Printf("Successfully created %q subnamespace anchor in %q namespace\n", hnnm, nnm)
+}
+
+func (cl *realClient) createMovingAnchor(nnm string, hnnm string, from string) {
+	postAnchor(nnm, hnnm, map[string]string{api.AnnotationMoveFrom: from})
+	fmt.Printf("Successfully created %q subnamespace anchor in %q namespace to move it from %q\n", hnnm, nnm, from)
+}
+
+func (cl *realClient) createAdoptingAnchor(nnm string, hnnm string) {
+	postAnchor(nnm, hnnm, map[string]string{api.AnnotationAdopt: "true"})
+	fmt.Printf("Successfully created %q subnamespace anchor in %q namespace to adopt the existing namespace\n", hnnm, nnm)
+}
+
+func postAnchor(nnm string, hnnm string, annotations map[string]string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	anchor := &api.SubnamespaceAnchor{}
 	anchor.Name = hnnm
 	anchor.Namespace = nnm
+	anchor.Annotations = annotations
 	err := hncClient.Post().Resource(api.Anchors).Namespace(nnm).Name(hnnm).Body(anchor).Do(ctx).Error()
 	if err != nil {
 		fmt.Printf("\nCould not create subnamespace anchor.\nReason: %s\n", err)
 		os.Exit(1)
 	}
-	fmt.Printf("Successfully created %q subnamespace anchor in %q namespace\n", hnnm, nnm)
+}
+
+func (cl *realClient) releaseAnchor(nnm string, hnnm string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	anchor := &api.SubnamespaceAnchor{}
+	if err := hncClient.Get().Resource(api.Anchors).Namespace(nnm).Name(hnnm).Do(ctx).Into(anchor); err != nil {
+		fmt.Printf("\nCould not read subnamespace anchor.\nReason: %s\n", err)
+		os.Exit(1)
+	}
+	if anchor.Annotations == nil {
+		anchor.Annotations = map[string]string{}
+	}
+	anchor.Annotations[api.AnnotationRelease] = "true"
+	if err := hncClient.Put().Resource(api.Anchors).Namespace(nnm).Name(hnnm).Body(anchor).Do(ctx).Error(); err != nil {
+		fmt.Printf("\nCould not release subnamespace anchor.\nReason: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Successfully released %q subnamespace anchor in %q namespace; %q is now a full namespace\n", hnnm, nnm, hnnm)
+}
+
+func (cl *realClient) restoreAnchor(nnm string, hnnm string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ns, err := k8sClient.CoreV1().Namespaces().Get(ctx, hnnm, metav1.GetOptions{})
+	if err != nil {
+		fmt.Printf("Error reading namespace %s: %s\n", hnnm, err)
+		os.Exit(1)
+	}
+	deleteAfter := ns.Annotations[api.AnnotationDeleteAfter]
+	if deleteAfter == "" || ns.Annotations[api.SubnamespaceOf] != nnm {
+		fmt.Printf("Error: %q is not a subnamespace of %q that is pending deletion\n", hnnm, nnm)
+		os.Exit(1)
+	}
+	postAnchor(nnm, hnnm, nil)
+	fmt.Printf("Successfully restored %q subnamespace in %q namespace (it would have been deleted after %s)\n", hnnm, nnm, deleteAfter)
 }
 
 func (cl *realClient) deleteAnchor(nnm string, hnnm string) {
@@ -219,6 +327,33 @@ func (cl *realClient) deleteAnchor(nnm string, hnnm string) {
 	fmt.Printf("Successfully deleted %q subnamespace anchor in %q namespace\n", hnnm, nnm)
 }
 
+func (cl *realClient) dryRunDeleteAnchor(nnm string, hnnm string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// HNC describes what would be deleted via admission warnings, which are returned even if the
+	// deletion is denied.
+	warnings := &warningCollector{}
+	err := hncClient.Delete().Resource(api.Anchors).Namespace(nnm).Name(hnnm).Param("dryRun", metav1.DryRunAll).WarningHandler(warnings).Do(ctx).Error()
+	for _, w := range *warnings {
+		fmt.Println(w)
+	}
+	if err != nil {
+		fmt.Printf("\nThe subnamespace anchor could not be deleted.\nReason: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Dry run: %q subnamespace anchor in %q namespace can be deleted\n", hnnm, nnm)
+}
+
+// warningCollector is a rest.WarningHandler that collects warnings instead of logging them.
+type warningCollector []string
+
+func (w *warningCollector) HandleWarningHeader(code int, _ string, text string) {
+	if code == 299 && text != "" {
+		*w = append(*w, text)
+	}
+}
+
 func (cl *realClient) getHNCConfig() *api.HNCConfiguration {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -272,3 +407,18 @@ func (cl *realClient) getHRQ(names []string, nnm string) *api.HierarchicalResour
 	}
 	return hrqList
 }
+
+func (cl *realClient) listHRQs(nnm string) *api.HierarchicalResourceQuotaList {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	hrqList := &api.HierarchicalResourceQuotaList{}
+	if err := hncClient.Get().Resource("hierarchicalresourcequotas").Namespace(nnm).Do(ctx).Into(hrqList); err != nil {
+		if errors.IsForbidden(err) && nnm == "" {
+			return nil
+		}
+		fmt.Printf("Error reading hierarchicalresourcequotas: %s\n", err)
+		os.Exit(1)
+	}
+	return hrqList
+}
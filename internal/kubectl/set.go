@@ -30,6 +30,9 @@ type hcUpdates struct {
 	parent   string
 	allowCD  bool
 	forbidCD bool
+	netIso   string
+	halt     bool
+	unhalt   bool
 }
 
 var setCmd = &cobra.Command{
@@ -53,7 +56,20 @@ var setCmd = &cobra.Command{
 	# Forbids cascading deletion on 'foo' and its subtree (unless specifically
 	# allowed on any descendants).
 	kubectl hns set foo --forbidCascadingDeletion
-	kubectl hns set foo -f`,
+	kubectl hns set foo -f
+
+	# Only allow ingress into 'foo' and its descendants from within the subtree
+	# of 'foo'
+	kubectl hns set foo --networkIsolation Subtree
+
+	# Inherit the network isolation of the ancestors of 'foo' again
+	kubectl hns set foo --networkIsolation Inherit
+
+	# Halt all activities in 'foo' and its descendants, e.g. during a migration
+	kubectl hns set foo --halt
+
+	# Resume all activities in 'foo' and its descendants
+	kubectl hns set foo --unhalt`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		nnm := args[0]
@@ -61,12 +77,18 @@ var setCmd = &cobra.Command{
 		parent, _ := flags.GetString("parent")
 		allowCD, _ := flags.GetBool("allowCascadingDeletion")
 		forbidCD, _ := flags.GetBool("forbidCascadingDeletion")
+		netIso, _ := flags.GetString("networkIsolation")
+		halt, _ := flags.GetBool("halt")
+		unhalt, _ := flags.GetBool("unhalt")
 
 		updates := hcUpdates{
 			root:     flags.Changed("root"),
 			parent:   parent,
 			allowCD:  allowCD,
 			forbidCD: forbidCD,
+			netIso:   netIso,
+			halt:     halt,
+			unhalt:   unhalt,
 		}
 
 		updateHC(client, updates, nnm)
@@ -93,6 +115,12 @@ func updateHC(cl Client, updates hcUpdates, nnm string) {
 
 	setAllowCascadingDeletion(hc, nnm, updates.allowCD, updates.forbidCD, &numChanges)
 
+	if updates.netIso != "" {
+		setNetworkIsolation(hc, nnm, updates.netIso, &numChanges)
+	}
+
+	setHalted(hc, nnm, updates.halt, updates.unhalt, &numChanges)
+
 	if numChanges > 0 {
 		cl.updateHierarchy(hc, fmt.Sprintf("update the hierarchical configuration of %s", nnm))
 		word := "property"
@@ -110,6 +138,7 @@ func setRoot(hc *api.HierarchyConfiguration, oldpnm, nnm string, numChanges *int
 		fmt.Printf("%s is already a root namespace; unchanged \n", nnm)
 	} else {
 		hc.Spec.Parent = ""
+		clearRequestedBy(hc)
 		fmt.Printf("Unsetting the parent of %s (was previously %s)\n", nnm, oldpnm)
 		*numChanges++
 	}
@@ -120,6 +149,7 @@ func setParent(hc *api.HierarchyConfiguration, oldpnm, pnm, nnm string, numChang
 		fmt.Printf("Parent of %s is already %s; unchanged\n", nnm, pnm)
 	} else {
 		hc.Spec.Parent = pnm
+		clearRequestedBy(hc)
 		if oldpnm == "" {
 			fmt.Printf("Setting the parent of %s to %s\n", nnm, pnm)
 		} else {
@@ -129,6 +159,12 @@ func setParent(hc *api.HierarchyConfiguration, oldpnm, pnm, nnm string, numChang
 	}
 }
 
+// clearRequestedBy removes the user who set the previous parent, which HNC doesn't allow to be kept
+// when the parent changes. HNC records the current user instead.
+func clearRequestedBy(hc *api.HierarchyConfiguration) {
+	delete(hc.Annotations, api.AnnotationRequestedBy)
+}
+
 func setAllowCascadingDeletion(hc *api.HierarchyConfiguration, nnm string, allow, forbid bool, numChanges *int) {
 	if allow && forbid {
 		fmt.Printf("Cannot set both --allowCascadingDeletion and --forbidCascadingDeletion\n")
@@ -154,10 +190,62 @@ func setAllowCascadingDeletion(hc *api.HierarchyConfiguration, nnm string, allow
 	}
 }
 
+func setNetworkIsolation(hc *api.HierarchyConfiguration, nnm, mode string, numChanges *int) {
+	newMode := api.NetworkIsolationMode(mode)
+	switch newMode {
+	case api.NetworkIsolationSubtree, api.NetworkIsolationNamespace, api.NetworkIsolationNone:
+	case "Inherit":
+		newMode = ""
+	default:
+		fmt.Printf("Unknown network isolation %q; must be one of Subtree, Namespace, None or Inherit\n", mode)
+		os.Exit(1)
+	}
+
+	if hc.Spec.NetworkIsolation == newMode {
+		fmt.Printf("Network isolation for '%s' is already set to %s; unchanged\n", nnm, mode)
+		return
+	}
+	hc.Spec.NetworkIsolation = newMode
+	if newMode == "" {
+		// The exceptions are meaningless without a mode.
+		hc.Spec.NetworkIsolationExceptions = nil
+	}
+	fmt.Printf("Setting network isolation on '%s' to %s\n", nnm, mode)
+	*numChanges++
+}
+
+func setHalted(hc *api.HierarchyConfiguration, nnm string, halt, unhalt bool, numChanges *int) {
+	if halt && unhalt {
+		fmt.Printf("Cannot set both --halt and --unhalt\n")
+		os.Exit(1)
+	}
+
+	if !halt && !unhalt {
+		// nothing specified
+		return
+	}
+
+	// We now know that halt != unhalt, so we can just look at halt
+	if hc.Spec.Halted == halt {
+		fmt.Printf("Halted for '%s' is already set to %t; unchanged\n", nnm, halt)
+	} else {
+		hc.Spec.Halted = halt
+		if halt {
+			fmt.Printf("Halting all activities in '%s' and its descendants\n", nnm)
+		} else {
+			fmt.Printf("Resuming all activities in '%s' and its descendants\n", nnm)
+		}
+		*numChanges++
+	}
+}
+
 func newSetCmd() *cobra.Command {
 	setCmd.Flags().BoolP("root", "r", false, "Removes the current parent namespace, making this namespace a root")
 	setCmd.Flags().StringP("parent", "p", "", "Sets the parent namespace")
 	setCmd.Flags().BoolP("allowCascadingDeletion", "a", false, "Allows cascading deletion of its subnamespaces.")
 	setCmd.Flags().BoolP("forbidCascadingDeletion", "f", false, "Protects cascading deletion of its subnamespaces.")
+	setCmd.Flags().String("networkIsolation", "", "Sets the network isolation of the namespace and its descendants: Subtree, Namespace, None or Inherit.")
+	setCmd.Flags().Bool("halt", false, "Halts all activities in the namespace and its descendants.")
+	setCmd.Flags().Bool("unhalt", false, "Resumes all activities in the namespace and its descendants.")
 	return setCmd
 }
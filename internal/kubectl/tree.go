@@ -25,6 +25,8 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
@@ -36,95 +38,240 @@ var (
 	hasSubnamespace bool
 )
 
+// treeOutputFormats are the valid values of the --output flag, other than the default text format.
+var treeOutputFormats = []string{"json", "yaml", "dot", "mermaid"}
+
 var treeCmd = &cobra.Command{
 	Use:   "tree TREE",
 	Short: "Display one or more hierarchy trees",
+	Example: `# Display the tree rooted at 'foo'
+	kubectl hns tree foo
+
+	# Display all trees on the cluster as JSON
+	kubectl hns tree -A -o json`,
 	Run: func(cmd *cobra.Command, args []string) {
 		flags := cmd.Flags()
-		footnotesByMsg = map[string]int{}
-		footnotes = []string{}
-		defaultList := len(args) == 0
-		nsList := args
-		if flags.Changed("all-namespaces") {
-			nsList = getAllNamespaces()
+		output, _ := flags.GetString("output")
+		if output != "" && !isTreeOutputFormat(output) {
+			fmt.Printf("Error: unknown output format %q; must be one of: %s\n", output, strings.Join(treeOutputFormats, ", "))
+			os.Exit(1)
 		}
+		defaultList := len(args) == 0
 		if defaultList && !flags.Changed("all-namespaces") {
 			fmt.Printf("Error: Must specify the root of the tree(s) to display or else specify --all-namespaces\n")
 			os.Exit(1)
 		}
 
+		// Read the entire hierarchy at once if possible, rather than one namespace at a time, which is
+		// very slow for large trees.
+		snap := newHierarchySnapshot()
+		nsList := args
+		if flags.Changed("all-namespaces") {
+			nsList = snap.names()
+		}
+
+		roots := []*treeNode{}
 		for _, nnm := range nsList {
-			hier := client.getHierarchy(nnm)
+			if snap.getNamespace(nnm) == nil {
+				fmt.Printf("Error reading namespace %s: not found\n", nnm)
+				os.Exit(1)
+			}
 			// If we're showing the default list, skip all non-root namespaces since they'll be displayed
-			// as part of another namespace's tree. Get the text first though because that will reveal if
-			// the tree's in a cycle, in which case, even though the NS isn't a root, we'll display it
-			// anyway.
-			txt, cycle := nameAndFootnotes(hier)
+			// as part of another namespace's tree, unless the tree's in a cycle, in which case, even
+			// though the NS isn't a root, we'll display it anyway.
+			hier := snap.getHierarchy(nnm)
+			cycle := isInCycle(hier)
 			if defaultList && (!cycle && hier.Spec.Parent != "") {
 				continue
 			}
-			fmt.Println(txt)
-			printSubtree("", hier, cycle)
+			roots = append(roots, snap.buildTree(nnm, cycle))
 		}
 
-		if hasSubnamespace {
-			fmt.Printf("\n[s] indicates subnamespaces\n")
+		showHRQ, _ := flags.GetBool("show-hrq")
+		showLabels, _ := flags.GetBool("show-labels")
+		objectTypes, _ := flags.GetStringSlice("show-objects")
+		newTreeDetails(showHRQ, showLabels, objectTypes).annotate(snap, roots)
+
+		switch output {
+		case "":
+			printTextTrees(roots)
+		case "json":
+			printJSON(roots)
+		case "yaml":
+			printYAML(roots)
+		case "dot":
+			printDot(roots)
+		case "mermaid":
+			printMermaid(roots)
 		}
+	},
+}
 
-		if len(footnotes) > 0 {
-			fmt.Printf("\nConditions:\n")
+// treeNode is a namespace in the output of the tree command. It's also the schema of the JSON and
+// YAML output formats.
+type treeNode struct {
+	Name               string             `json:"name"`
+	Subnamespace       bool               `json:"subnamespace,omitempty"`
+	Conditions         []metav1.Condition `json:"conditions,omitempty"`
+	ManagedLabels      map[string]string  `json:"managedLabels,omitempty"`
+	ManagedAnnotations map[string]string  `json:"managedAnnotations,omitempty"`
 
-			for i, n := range footnotes {
-				fmt.Printf("%d) %s\n", i+1, n)
-			}
+	// These are only set if they're requested by the --show-* flags.
+	HRQs    []hrqSummary            `json:"hrqs,omitempty"`
+	Objects map[string]objectCounts `json:"objects,omitempty"`
+	Labels  map[string]string       `json:"labels,omitempty"`
+
+	Children []*treeNode `json:"children,omitempty"`
+}
+
+// hierarchySnapshot holds every namespace and hierarchy configuration on the cluster, each read
+// with a single call to the apiserver. If the user isn't allowed to list them, they're read one at a
+// time instead, as they're needed.
+type hierarchySnapshot struct {
+	namespaces  map[string]*corev1.Namespace
+	hierarchies map[string]*api.HierarchyConfiguration
+
+	listedNamespaces  bool
+	listedHierarchies bool
+}
+
+func newHierarchySnapshot() *hierarchySnapshot {
+	s := &hierarchySnapshot{
+		namespaces:  map[string]*corev1.Namespace{},
+		hierarchies: map[string]*api.HierarchyConfiguration{},
+	}
+	if nsList := listNamespaces(); nsList != nil {
+		s.listedNamespaces = true
+		for i := range nsList {
+			s.namespaces[nsList[i].Name] = &nsList[i]
 		}
-	},
+	}
+	if hierList := client.listHierarchies(); hierList != nil {
+		s.listedHierarchies = true
+		for i := range hierList.Items {
+			s.hierarchies[hierList.Items[i].Namespace] = &hierList.Items[i]
+		}
+	}
+	return s
 }
 
-func printSubtree(prefix string, hier *api.HierarchyConfiguration, inCycle bool) {
-	for i, cn := range hier.Status.Children {
-		ch := client.getHierarchy(cn)
-		txt, cycle := nameAndFootnotes(ch)
+// names returns the sorted names of all namespaces.
+func (s *hierarchySnapshot) names() []string {
+	if !s.listedNamespaces {
+		fmt.Printf("Could not list namespaces: forbidden\n")
+		os.Exit(1)
+	}
+	result := []string{}
+	for nm := range s.namespaces {
+		result = append(result, nm)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// getNamespace returns the namespace, or nil if it doesn't exist.
+func (s *hierarchySnapshot) getNamespace(nnm string) *corev1.Namespace {
+	if ns, ok := s.namespaces[nnm]; ok || s.listedNamespaces {
+		return ns
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ns, err := k8sClient.CoreV1().Namespaces().Get(ctx, nnm, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		ns = nil
+	} else if err != nil {
+		fmt.Printf("Could not get namespaces: %s\n", err)
+		os.Exit(1)
+	}
+	s.namespaces[nnm] = ns
+	return ns
+}
+
+// getHierarchy returns the hierarchy configuration of the namespace. If it doesn't have one, an
+// empty one is returned instead.
+func (s *hierarchySnapshot) getHierarchy(nnm string) *api.HierarchyConfiguration {
+	if hier, ok := s.hierarchies[nnm]; ok {
+		return hier
+	}
+	if !s.listedHierarchies {
+		s.hierarchies[nnm] = client.getHierarchy(nnm)
+		return s.hierarchies[nnm]
+	}
+	hier := &api.HierarchyConfiguration{}
+	hier.Name = api.Singleton
+	hier.Namespace = nnm
+	return hier
+}
+
+// buildTree returns the tree rooted at the given namespace. If both a namespace and one of its
+// children are in a cycle, we don't recurse into the child.
+func (s *hierarchySnapshot) buildTree(nnm string, inCycle bool) *treeNode {
+	hier := s.getHierarchy(nnm)
+	n := &treeNode{
+		Name:               nnm,
+		Conditions:         hier.Status.Conditions,
+		ManagedLabels:      kvpsToMap(hier.Spec.Labels),
+		ManagedAnnotations: kvpsToMap(hier.Spec.Annotations),
+	}
+	if ns := s.getNamespace(nnm); ns != nil {
+		_, n.Subnamespace = ns.Annotations[api.SubnamespaceOf]
+	}
+	for _, cn := range hier.Status.Children {
+		cycle := isInCycle(s.getHierarchy(cn))
 		if cycle && inCycle {
 			continue
 		}
+		n.Children = append(n.Children, s.buildTree(cn, cycle))
+	}
+	return n
+}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+func printTextTrees(roots []*treeNode) {
+	footnotesByMsg = map[string]int{}
+	footnotes = []string{}
+	for _, root := range roots {
+		fmt.Println(nameAndFootnotes(root) + formatDetails(root))
+		printSubtree("", root)
+	}
 
-		ns, err := k8sClient.CoreV1().Namespaces().Get(ctx, cn, metav1.GetOptions{})
-		if err != nil {
-			fmt.Printf("Could not get namespaces: %s\n", err)
-			os.Exit(1)
+	if hasSubnamespace {
+		fmt.Printf("\n[s] indicates subnamespaces\n")
+	}
+
+	if len(footnotes) > 0 {
+		fmt.Printf("\nConditions:\n")
+
+		for i, n := range footnotes {
+			fmt.Printf("%d) %s\n", i+1, n)
 		}
+	}
+}
 
-		if _, ok := ns.ObjectMeta.Annotations[api.SubnamespaceOf]; ok {
+func printSubtree(prefix string, n *treeNode) {
+	for i, ch := range n.Children {
+		txt := nameAndFootnotes(ch)
+		if ch.Subnamespace {
 			txt = "[s] " + txt
 			hasSubnamespace = true
 		}
+		txt += formatDetails(ch)
 
-		if i < len(hier.Status.Children)-1 {
+		if i < len(n.Children)-1 {
 			fmt.Printf("%s├── %s\n", prefix, txt)
-			printSubtree(prefix+"│   ", ch, cycle)
+			printSubtree(prefix+"│   ", ch)
 		} else {
 			fmt.Printf("%s└── %s\n", prefix, txt)
-			printSubtree(prefix+"    ", ch, cycle)
+			printSubtree(prefix+"    ", ch)
 		}
 	}
 }
 
 // nameAndFootnotes returns the text to print to describe the namespace, in the form of the
 // namespace's name along with references to any footnotes. Example: default (1).
-//
-// The second param is true if this namespace is part of a cycle, in which case we shouldn't
-// recurse.
-func nameAndFootnotes(hier *api.HierarchyConfiguration) (string, bool) {
-	cycle := false
+func nameAndFootnotes(n *treeNode) string {
 	notes := []int{}
-	for _, cond := range hier.Status.Conditions {
-		if cond.Reason == api.ReasonInCycle {
-			cycle = true
-		}
+	for _, cond := range n.Conditions {
 		txt := cond.Type + " (" + cond.Reason + "): " + cond.Message
 		if idx, ok := footnotesByMsg[txt]; ok {
 			notes = append(notes, idx)
@@ -136,34 +283,77 @@ func nameAndFootnotes(hier *api.HierarchyConfiguration) (string, bool) {
 	}
 
 	if len(notes) == 0 {
-		return hier.Namespace, false
+		return n.Name
 	}
 	sort.Ints(notes)
 	ns := []string{}
 	for _, n := range notes {
 		ns = append(ns, strconv.Itoa(n))
 	}
-	return fmt.Sprintf("%s (%s)", hier.Namespace, strings.Join(ns, ",")), cycle
+	return fmt.Sprintf("%s (%s)", n.Name, strings.Join(ns, ","))
+}
+
+// formatDetails returns the details requested by the --show-* flags, e.g.
+// " [hrq quota: pods 3/10] [secrets: 1 source, 0 propagated]", or the empty string if there are none.
+func formatDetails(n *treeNode) string {
+	lines := detailLines(n)
+	if len(lines) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(lines, "] [") + "]"
+}
+
+// isInCycle returns true if this namespace is part of a cycle, in which case we shouldn't recurse.
+func isInCycle(hier *api.HierarchyConfiguration) bool {
+	for _, cond := range hier.Status.Conditions {
+		if cond.Reason == api.ReasonInCycle {
+			return true
+		}
+	}
+	return false
+}
+
+func isTreeOutputFormat(output string) bool {
+	for _, f := range treeOutputFormats {
+		if output == f {
+			return true
+		}
+	}
+	return false
+}
+
+func kvpsToMap(kvps []api.MetaKVP) map[string]string {
+	if len(kvps) == 0 {
+		return nil
+	}
+	m := map[string]string{}
+	for _, kvp := range kvps {
+		m[kvp.Key] = kvp.Value
+	}
+	return m
 }
 
 func newTreeCmd() *cobra.Command {
 	treeCmd.Flags().BoolP("all-namespaces", "A", false, "Displays all trees on the cluster")
+	treeCmd.Flags().StringP("output", "o", "", "Output format. One of: "+strings.Join(treeOutputFormats, "|")+". Defaults to a human-readable tree.")
+	treeCmd.Flags().Bool("show-hrq", false, "Displays the usage of the HierarchicalResourceQuotas in each namespace, which covers its entire subtree")
+	treeCmd.Flags().StringSlice("show-objects", nil, "Displays the number of source and propagated objects of the given types (e.g. secrets or roles.rbac.authorization.k8s.io) in each namespace. May be repeated or comma-separated.")
+	treeCmd.Flags().Bool("show-labels", false, "Displays the labels of each namespace, other than the ones HNC adds to all namespaces")
 	return treeCmd
 }
 
-func getAllNamespaces() []string {
+// listNamespaces returns all namespaces, or nil if the user isn't allowed to list them.
+func listNamespaces() []corev1.Namespace {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	nsList, err := k8sClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if errors.IsForbidden(err) {
+		return nil
+	}
 	if err != nil {
 		fmt.Printf("Could not list namespaces: %s\n", err)
 		os.Exit(1)
 	}
-	result := []string{}
-	for _, each := range nsList.Items {
-		result = append(result, each.Name)
-	}
-	sort.Strings(result)
-	return result
+	return nsList.Items
 }
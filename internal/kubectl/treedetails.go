@@ -0,0 +1,250 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+)
+
+// listPageSize is the maximum number of objects read from the apiserver by each list call in
+// listObjectMetadata.
+const listPageSize = 500
+
+// hrqSummary is the usage of a HierarchicalResourceQuota across its subtree.
+type hrqSummary struct {
+	Name string              `json:"name"`
+	Hard corev1.ResourceList `json:"hard,omitempty"`
+	Used corev1.ResourceList `json:"used,omitempty"`
+}
+
+// objectCounts is the number of objects of a type in a namespace.
+type objectCounts struct {
+	Source     int `json:"source"`
+	Propagated int `json:"propagated"`
+}
+
+// treeDetails adds the details requested by the --show-* flags to the nodes of a tree. Like the
+// hierarchySnapshot, it reads each kind of object across the whole cluster once, and only falls back
+// to reading them one namespace at a time if the user isn't allowed to list them.
+type treeDetails struct {
+	showHRQ     bool
+	showLabels  bool
+	objectTypes []schema.GroupVersionResource
+
+	// hrqs contains the HRQs in each namespace, if they were listed across the cluster.
+	hrqs map[string][]hrqSummary
+	// objects contains the counts of each type in each namespace, if they were listed across the
+	// cluster.
+	objects map[schema.GroupVersionResource]map[string]objectCounts
+}
+
+func newTreeDetails(showHRQ, showLabels bool, objectTypes []string) *treeDetails {
+	d := &treeDetails{
+		showHRQ:    showHRQ,
+		showLabels: showLabels,
+		objects:    map[schema.GroupVersionResource]map[string]objectCounts{},
+	}
+	for _, tp := range objectTypes {
+		gvr, err := mapper.ResourceFor(schema.ParseGroupResource(tp).WithVersion(""))
+		if err != nil {
+			fmt.Printf("Error: unknown object type %q: %s\n", tp, err)
+			os.Exit(1)
+		}
+		d.objectTypes = append(d.objectTypes, gvr)
+	}
+
+	if d.showHRQ {
+		if hrqList := client.listHRQs(""); hrqList != nil {
+			d.hrqs = map[string][]hrqSummary{}
+			for _, hrq := range hrqList.Items {
+				d.hrqs[hrq.Namespace] = append(d.hrqs[hrq.Namespace], hrqSummary{Name: hrq.Name, Hard: hrq.Status.Hard, Used: hrq.Status.Used})
+			}
+		}
+	}
+	for _, gvr := range d.objectTypes {
+		if counts := countObjects(gvr, ""); counts != nil {
+			d.objects[gvr] = counts
+		}
+	}
+	return d
+}
+
+// annotate adds the requested details to every node in the trees.
+func (d *treeDetails) annotate(snap *hierarchySnapshot, roots []*treeNode) {
+	walkTrees(roots, func(n, _ *treeNode) {
+		if d.showHRQ {
+			n.HRQs = d.getHRQs(n.Name)
+		}
+		for _, gvr := range d.objectTypes {
+			if n.Objects == nil {
+				n.Objects = map[string]objectCounts{}
+			}
+			n.Objects[gvr.GroupResource().String()] = d.getObjectCounts(gvr, n.Name)
+		}
+		if d.showLabels {
+			n.Labels = getDisplayedLabels(snap.getNamespace(n.Name))
+		}
+	})
+}
+
+func (d *treeDetails) getHRQs(nnm string) []hrqSummary {
+	if d.hrqs != nil {
+		return d.hrqs[nnm]
+	}
+	hrqs := []hrqSummary{}
+	if hrqList := client.listHRQs(nnm); hrqList != nil {
+		for _, hrq := range hrqList.Items {
+			hrqs = append(hrqs, hrqSummary{Name: hrq.Name, Hard: hrq.Status.Hard, Used: hrq.Status.Used})
+		}
+	}
+	return hrqs
+}
+
+func (d *treeDetails) getObjectCounts(gvr schema.GroupVersionResource, nnm string) objectCounts {
+	if counts, ok := d.objects[gvr]; ok {
+		return counts[nnm]
+	}
+	return countObjects(gvr, nnm)[nnm]
+}
+
+// countObjects counts the source and propagated objects of the given type in each namespace. If nnm
+// is empty, they're counted across the cluster, and nil is returned if the user isn't allowed to
+// list them; otherwise, only the given namespace is counted. Only the metadata of the objects is
+// read, one page at a time, since there may be a lot of them.
+func countObjects(gvr schema.GroupVersionResource, nnm string) map[string]objectCounts {
+	counts := map[string]objectCounts{}
+	err := listObjectMetadata(gvr, nnm, "", func(obj *metav1.PartialObjectMetadata) {
+		c := counts[obj.Namespace]
+		if _, ok := obj.Labels[api.LabelInheritedFrom]; ok {
+			c.Propagated++
+		} else {
+			c.Source++
+		}
+		counts[obj.Namespace] = c
+	})
+	if err != nil {
+		if errors.IsForbidden(err) && nnm == "" {
+			return nil
+		}
+		fmt.Printf("Error listing %s: %s\n", gvr.GroupResource(), err)
+		os.Exit(1)
+	}
+	return counts
+}
+
+// listObjectMetadata calls fn with the metadata of each object of the given type in the namespace
+// (or across the cluster, if it's empty) that matches the label selector. The objects are listed in
+// pages of listPageSize, so that large types don't have to be held in memory all at once.
+func listObjectMetadata(gvr schema.GroupVersionResource, nnm, selector string, fn func(*metav1.PartialObjectMetadata)) error {
+	opts := metav1.ListOptions{LabelSelector: selector, Limit: listPageSize}
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		list, err := metadataClient.Resource(gvr).Namespace(nnm).List(ctx, opts)
+		cancel()
+		if err != nil {
+			return err
+		}
+		for i := range list.Items {
+			fn(&list.Items[i])
+		}
+		if list.Continue == "" {
+			return nil
+		}
+		opts.Continue = list.Continue
+	}
+}
+
+// getDisplayedLabels returns the labels of the namespace, except for the ones that HNC adds to every
+// namespace (the tree labels and the included-namespace label), since they'd only repeat the
+// structure of the tree itself.
+func getDisplayedLabels(ns *corev1.Namespace) map[string]string {
+	if ns == nil {
+		return nil
+	}
+	labels := map[string]string{}
+	for k, v := range ns.Labels {
+		if strings.HasSuffix(k, api.LabelTreeDepthSuffix) || k == api.LabelIncludedNamespace {
+			continue
+		}
+		labels[k] = v
+	}
+	return labels
+}
+
+// detailLines returns the details of the node to display after its name, e.g.
+// "hrq quota: pods 3/10" or "secrets: 1 source, 2 propagated".
+func detailLines(n *treeNode) []string {
+	lines := []string{}
+	for _, hrq := range n.HRQs {
+		lines = append(lines, fmt.Sprintf("hrq %s: %s", hrq.Name, formatHRQUsage(hrq)))
+	}
+	types := []string{}
+	for tp := range n.Objects {
+		types = append(types, tp)
+	}
+	sort.Strings(types)
+	for _, tp := range types {
+		c := n.Objects[tp]
+		lines = append(lines, fmt.Sprintf("%s: %d source, %d propagated", tp, c.Source, c.Propagated))
+	}
+	if n.Labels != nil {
+		keys := []string{}
+		for k := range n.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		kvps := []string{}
+		for _, k := range keys {
+			kvps = append(kvps, k+"="+n.Labels[k])
+		}
+		if len(kvps) == 0 {
+			kvps = append(kvps, "<none>")
+		}
+		lines = append(lines, "labels: "+strings.Join(kvps, ","))
+	}
+	return lines
+}
+
+// formatHRQUsage returns the usage of each resource against its limit, e.g. "cpu 1/2, pods 3/10".
+func formatHRQUsage(hrq hrqSummary) string {
+	resources := make([]corev1.ResourceName, 0, len(hrq.Hard))
+	for resource := range hrq.Hard {
+		resources = append(resources, resource)
+	}
+	sort.Sort(SortableResourceNames(resources))
+	usage := []string{}
+	for _, resource := range resources {
+		used := hrq.Used[resource]
+		hard := hrq.Hard[resource]
+		usage = append(usage, fmt.Sprintf("%s %s/%s", resource, used.String(), hard.String()))
+	}
+	if len(usage) == 0 {
+		return "<none>"
+	}
+	return strings.Join(usage, ", ")
+}
@@ -0,0 +1,127 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+func printJSON(roots []*treeNode) {
+	out, err := json.MarshalIndent(roots, "", "  ")
+	if err != nil {
+		fmt.Printf("Error: could not convert the trees to JSON: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+}
+
+func printYAML(roots []*treeNode) {
+	out, err := yaml.Marshal(roots)
+	if err != nil {
+		fmt.Printf("Error: could not convert the trees to YAML: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(string(out))
+}
+
+// printDot prints the trees as a Graphviz digraph, e.g. for `dot -Tsvg`. Subnamespaces are drawn
+// with dashed borders, and namespaces with conditions are drawn in red.
+func printDot(roots []*treeNode) {
+	fmt.Println("digraph hierarchy {")
+	fmt.Println("  node [shape=box];")
+	walkTrees(roots, func(n, parent *treeNode) {
+		attrs := []string{"label=" + dotQuote(strings.Join(nodeLines(n), "\n"))}
+		if n.Subnamespace {
+			attrs = append(attrs, "style=dashed")
+		}
+		if len(n.Conditions) > 0 {
+			attrs = append(attrs, "color=red")
+		}
+		fmt.Printf("  %s [%s];\n", dotQuote(n.Name), strings.Join(attrs, ", "))
+		if parent != nil {
+			fmt.Printf("  %s -> %s;\n", dotQuote(parent.Name), dotQuote(n.Name))
+		}
+	})
+	fmt.Println("}")
+}
+
+// printMermaid prints the trees as a Mermaid flowchart, e.g. for embedding in Markdown. Namespace
+// names can't be used as Mermaid IDs (they may contain hyphens, or be keywords like "end"), so each
+// namespace is given an ID such as "ns0" and labelled with its name. Subnamespaces are drawn with
+// rounded borders.
+func printMermaid(roots []*treeNode) {
+	fmt.Println("graph TD")
+	ids := map[string]string{}
+	walkTrees(roots, func(n, parent *treeNode) {
+		id, ok := ids[n.Name]
+		if !ok {
+			id = fmt.Sprintf("ns%d", len(ids))
+			ids[n.Name] = id
+		}
+		lines := []string{}
+		for _, line := range nodeLines(n) {
+			lines = append(lines, mermaidEscaper.Replace(line))
+		}
+		label := strings.Join(lines, "<br/>")
+		if n.Subnamespace {
+			fmt.Printf("  %s(\"%s\")\n", id, label)
+		} else {
+			fmt.Printf("  %s[\"%s\"]\n", id, label)
+		}
+		if parent != nil {
+			fmt.Printf("  %s --> %s\n", ids[parent.Name], id)
+		}
+	})
+}
+
+// mermaidEscaper escapes the characters that Mermaid would otherwise interpret in labels.
+var mermaidEscaper = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+
+// walkTrees calls fn on every node in the trees, parents first, along with its parent (or nil, for
+// the roots).
+func walkTrees(roots []*treeNode, fn func(n, parent *treeNode)) {
+	var walk func(n, parent *treeNode)
+	walk = func(n, parent *treeNode) {
+		fn(n, parent)
+		for _, ch := range n.Children {
+			walk(ch, n)
+		}
+	}
+	for _, root := range roots {
+		walk(root, nil)
+	}
+}
+
+// nodeLines returns the lines of text used to label a namespace in a graph: its name, followed by
+// its conditions and the details requested by the --show-* flags, if any.
+func nodeLines(n *treeNode) []string {
+	lines := []string{n.Name}
+	for _, cond := range n.Conditions {
+		lines = append(lines, cond.Type+" ("+cond.Reason+")")
+	}
+	return append(lines, detailLines(n)...)
+}
+
+// dotQuote quotes a string for use as a Graphviz ID. Graphviz uses the same escapes as Go for
+// double quotes and newlines.
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(s, `"`, `\"`), "\n", `\n`) + `"`
+}
@@ -3,13 +3,18 @@ package namespaces
 import (
 	"context"
 	"encoding/json"
+	"regexp"
 
 	"github.com/go-logr/logr"
+	k8sadm "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
 	"sigs.k8s.io/hierarchical-namespaces/internal/config"
+	"sigs.k8s.io/hierarchical-namespaces/internal/forest"
 	"sigs.k8s.io/hierarchical-namespaces/internal/webhooks"
 )
 
@@ -27,6 +32,7 @@ const (
 
 type Mutator struct {
 	Log     logr.Logger
+	Forest  *forest.Forest
 	decoder *admission.Decoder
 }
 
@@ -40,6 +46,12 @@ func (m *Mutator) Handle(ctx context.Context, req admission.Request) admission.R
 	}
 
 	m.handle(log, ns)
+	if req.Operation == k8sadm.Create {
+		m.setDefaultParent(log, ns)
+		if webhooks.IsHNCServiceAccount(&req.AdmissionRequest.UserInfo) {
+			m.setSubnamespaceLabels(log, ns)
+		}
+	}
 	marshaledNS, err := json.Marshal(ns)
 	if err != nil {
 		return webhooks.DenyInternalError(err)
@@ -66,6 +78,98 @@ func (m *Mutator) handle(log logr.Logger, ns *corev1.Namespace) {
 	}
 }
 
+// setDefaultParent sets the parent annotation on a new full namespace that doesn't have a parent,
+// using the first default parent rule in the HNCConfiguration that matches it, and records the name
+// of the rule. Rules whose parent doesn't exist (yet) are skipped. The validator then checks that
+// the user is allowed to set the parent, just as if they'd set the annotation themselves.
+func (m *Mutator) setDefaultParent(log logr.Logger, ns *corev1.Namespace) {
+	if !config.IsManagedNamespace(ns.Name) {
+		return
+	}
+	if ns.Annotations[api.AnnotationParent] != "" || ns.Annotations[api.SubnamespaceOf] != "" {
+		return
+	}
+	if mgr := ns.Annotations[api.AnnotationManagedBy]; mgr != "" && mgr != api.MetaGroup {
+		return
+	}
+
+	m.Forest.Lock()
+	defer m.Forest.Unlock()
+	for _, rule := range m.Forest.GetDefaultParentRules() {
+		pnm, ok := matchDefaultParentRule(rule, ns)
+		if !ok {
+			continue
+		}
+		if pnm == ns.Name || !config.IsManagedNamespace(pnm) || !m.Forest.Get(pnm).Exists() {
+			log.Info("Skipping default parent rule with an illegal or missing parent", "rule", rule.Name, "parent", pnm)
+			continue
+		}
+
+		log.Info("Assigning default parent", "rule", rule.Name, "parent", pnm)
+		if ns.Annotations == nil {
+			ns.Annotations = map[string]string{}
+		}
+		ns.Annotations[api.AnnotationParent] = pnm
+		ns.Annotations[api.AnnotationDefaultParentRule] = rule.Name
+		return
+	}
+}
+
+// setSubnamespaceLabels sets the tree labels and the inherited managed labels on a new subnamespace
+// that's being created by HNC's anchor reconciler, using the parent's labels in the forest. The
+// HierarchyConfiguration reconciler sets the same labels once it syncs the new namespace, but until
+// then, anything that selects namespaces by their labels (such as tree-label NetworkPolicies) would
+// otherwise see an unlabelled namespace.
+func (m *Mutator) setSubnamespaceLabels(log logr.Logger, ns *corev1.Namespace) {
+	pnm := ns.Annotations[api.SubnamespaceOf]
+	if pnm == "" || !config.IsManagedNamespace(ns.Name) {
+		return
+	}
+
+	m.Forest.Lock()
+	defer m.Forest.Unlock()
+	parent := m.Forest.Get(pnm)
+	// If the parent is in a bad state, leave it to the reconciler to figure out the right labels.
+	if !parent.Exists() || parent.IsHalted() {
+		return
+	}
+
+	lbs := parent.GetHierarchyLabels(1)
+	lbs[ns.Name+api.LabelTreeDepthSuffix] = "0"
+	log.Info("Setting tree and managed labels on new subnamespace", "parent", pnm, "labels", lbs)
+	if ns.Labels == nil {
+		ns.Labels = map[string]string{}
+	}
+	for k, v := range lbs {
+		ns.Labels[k] = v
+	}
+}
+
+// matchDefaultParentRule returns the parent assigned by the rule to the namespace, and true if the
+// rule matches the namespace. Rules that aren't valid never match; invalid rules are rejected by
+// the HNCConfiguration validator.
+func matchDefaultParentRule(rule api.DefaultParentRule, ns *corev1.Namespace) (string, bool) {
+	if rule.Selector != nil {
+		sel, err := metav1.LabelSelectorAsSelector(rule.Selector)
+		if err != nil || !sel.Matches(labels.Set(ns.Labels)) {
+			return "", false
+		}
+	}
+	if rule.NameRegex == "" {
+		return rule.Parent, true
+	}
+
+	re, err := regexp.Compile("^" + rule.NameRegex + "$")
+	if err != nil {
+		return "", false
+	}
+	match := re.FindStringSubmatchIndex(ns.Name)
+	if match == nil {
+		return "", false
+	}
+	return string(re.ExpandString(nil, rule.Parent, ns.Name, match)), true
+}
+
 // InjectDecoder injects the decoder.
 func (m *Mutator) InjectDecoder(d *admission.Decoder) error {
 	m.decoder = d
@@ -5,10 +5,12 @@ import (
 
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
 	"sigs.k8s.io/hierarchical-namespaces/internal/config"
+	"sigs.k8s.io/hierarchical-namespaces/internal/foresttest"
 )
 
 func TestMutateNamespaceIncludedLabel(t *testing.T) {
@@ -47,3 +49,102 @@ func TestMutateNamespaceIncludedLabel(t *testing.T) {
 		})
 	}
 }
+
+func TestMutateNamespaceDefaultParent(t *testing.T) {
+	f := foresttest.Create("---") // a, b, c
+	f.SetDefaultParentRules([]api.DefaultParentRule{
+		{Name: "labelled", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}}, Parent: "b"},
+		{Name: "prefixed", NameRegex: "([a-z])-.*", Parent: "$1"},
+	})
+	m := &Mutator{Forest: f}
+	l := zap.New()
+	config.SetNamespaces("", "excluded")
+
+	tests := []struct {
+		name       string
+		nsn        string
+		labels     map[string]string
+		annots     map[string]string
+		wantParent string
+		wantRule   string
+	}{
+		{name: "match regex", nsn: "a-foo", wantParent: "a", wantRule: "prefixed"},
+		{name: "match selector first", nsn: "a-foo", labels: map[string]string{"team": "b"}, wantParent: "b", wantRule: "labelled"},
+		{name: "no match", nsn: "foo"},
+		{name: "missing parent", nsn: "z-foo"},
+		{name: "excluded namespace", nsn: "excluded"},
+		{name: "parent already set", nsn: "a-foo", annots: map[string]string{api.AnnotationParent: "c"}, wantParent: "c"},
+		{name: "subnamespace", nsn: "a-foo", annots: map[string]string{api.SubnamespaceOf: "c"}},
+		{name: "external namespace", nsn: "a-foo", annots: map[string]string{api.AnnotationManagedBy: "other"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			nsInst := &corev1.Namespace{}
+			nsInst.Name = tc.nsn
+			nsInst.SetLabels(tc.labels)
+			nsInst.SetAnnotations(tc.annots)
+
+			// Test
+			m.setDefaultParent(l, nsInst)
+
+			// Report
+			g.Expect(nsInst.Annotations[api.AnnotationParent]).Should(Equal(tc.wantParent))
+			g.Expect(nsInst.Annotations[api.AnnotationDefaultParentRule]).Should(Equal(tc.wantRule))
+		})
+	}
+}
+
+func TestMutateSubnamespaceLabels(t *testing.T) {
+	const psaLabel = "pod-security.kubernetes.io/enforce"
+	f := foresttest.Create("-a-") // a <- b; c
+	f.Get("a").ManagedLabels = map[string]string{"team": "a", psaLabel: "restricted"}
+	f.Get("b").ManagedLabels = map[string]string{"team": "b", psaLabel: "baseline"}
+	f.Get("c").SetCondition(api.ConditionActivitiesHalted, api.ReasonInCycle, "")
+	if err := config.SetMonotonicLabels([]string{psaLabel + "=privileged,baseline,restricted"}); err != nil {
+		t.Fatal(err)
+	}
+	defer config.SetMonotonicLabels(nil)
+	m := &Mutator{Forest: f}
+	l := zap.New()
+	config.SetNamespaces("")
+
+	tests := []struct {
+		name   string
+		parent string
+		want   map[string]string
+	}{
+		{name: "not a subnamespace"},
+		{name: "missing parent", parent: "brumpf"},
+		{name: "halted parent", parent: "c"},
+		{name: "subnamespace", parent: "b", want: map[string]string{
+			"new" + api.LabelTreeDepthSuffix: "0",
+			"b" + api.LabelTreeDepthSuffix:   "1",
+			"a" + api.LabelTreeDepthSuffix:   "2",
+			"team":                           "a", // ancestors override descendants
+			psaLabel:                         "restricted",
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			nsInst := &corev1.Namespace{}
+			nsInst.Name = "new"
+			if tc.parent != "" {
+				nsInst.SetAnnotations(map[string]string{api.SubnamespaceOf: tc.parent})
+			}
+
+			// Test
+			m.setSubnamespaceLabels(l, nsInst)
+
+			// Report
+			if tc.want == nil {
+				g.Expect(nsInst.Labels).Should(BeEmpty())
+			} else {
+				g.Expect(nsInst.Labels).Should(Equal(tc.want))
+			}
+		})
+	}
+}
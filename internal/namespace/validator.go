@@ -8,7 +8,9 @@ import (
 
 	"github.com/go-logr/logr"
 	k8sadm "k8s.io/api/admission/v1"
+	authnv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
@@ -33,16 +35,32 @@ var (
 // +kubebuilder:webhook:admissionReviewVersions=v1,path=/validate-v1-namespace,mutating=false,failurePolicy=fail,groups="",resources=namespaces,sideEffects=None,verbs=delete;create;update,versions=v1,name=namespaces.hnc.x-k8s.io
 
 type Validator struct {
-	Log     logr.Logger
-	Forest  *forest.Forest
+	Log    logr.Logger
+	Forest *forest.Forest
+
+	// Reader is used to count the workloads that would be deleted by a dry-run deletion. It should
+	// bypass the manager's cache, since HNC doesn't otherwise watch workloads. If it's nil, workloads
+	// aren't counted.
+	Reader client.Reader
+
+	server  serverClient
 	decoder *admission.Decoder
 }
 
+// serverClient represents the checks that should typically be performed against the apiserver, but
+// need to be stubbed out during unit testing.
+type serverClient interface {
+	// IsAdmin takes a UserInfo and the name of a namespace, and returns true if the user is an admin
+	// of that namespace (ie, can update the hierarchical config).
+	IsAdmin(ctx context.Context, ui *authnv1.UserInfo, nnm string) (bool, error)
+}
+
 // nsRequest defines the aspects of the admission.Request that we care about.
 type nsRequest struct {
 	ns    *corev1.Namespace
 	oldns *corev1.Namespace
 	op    k8sadm.Operation
+	ui    *authnv1.UserInfo
 }
 
 // Handle implements the validation webhook.
@@ -61,6 +79,14 @@ func (v *Validator) Handle(ctx context.Context, req admission.Request) admission
 	}
 
 	resp := v.handle(decoded)
+	if resp.Allowed {
+		resp = v.checkServer(ctx, log, decoded)
+	}
+	// Describe what would be deleted, even if the deletion is denied, so users can see the impact of
+	// setting allowCascadingDeletion before they do so.
+	if decoded.op == k8sadm.Delete && req.DryRun != nil && *req.DryRun && config.IsManagedNamespace(decoded.ns.Name) {
+		resp = resp.WithWarnings(webhooks.DryRunDeletion(ctx, log, v.Forest, v.Reader, decoded.ns.Name)...)
+	}
 	if !resp.Allowed {
 		log.Info("Denied", "code", resp.Result.Code, "reason", resp.Result.Reason, "message", resp.Result.Message)
 	} else {
@@ -92,6 +118,10 @@ func (v *Validator) handle(req *nsRequest) admission.Response {
 			return rsp
 		}
 
+		if rsp := v.illegalRoot(req); !rsp.Allowed {
+			return rsp
+		}
+
 	case k8sadm.Update:
 		if rsp := v.illegalIncludedNamespaceLabel(req); !rsp.Allowed {
 			return rsp
@@ -107,6 +137,14 @@ func (v *Validator) handle(req *nsRequest) admission.Response {
 			return rsp
 		}
 
+		if rsp := v.illegalMonotonicLabel(req, ns); !rsp.Allowed {
+			return rsp
+		}
+
+		if rsp := v.illegalParentAnnotationUpdate(req); !rsp.Allowed {
+			return rsp
+		}
+
 	case k8sadm.Delete:
 		if rsp := v.cannotDeleteSubnamespace(req); !rsp.Allowed {
 			return rsp
@@ -153,6 +191,142 @@ func (v *Validator) illegalTreeLabel(req *nsRequest) admission.Response {
 	return webhooks.Allow("")
 }
 
+// illegalRoot only applies to the Create operation. It checks that a new managed namespace is
+// given a legal parent via the parent annotation, or that the user is allowed to create a root
+// namespace according to the root policy in the HNCConfiguration. The user's permissions in the
+// requested parent are checked later by checkServer, once the forest lock is released.
+func (v *Validator) illegalRoot(req *nsRequest) admission.Response {
+	if !config.IsManagedNamespace(req.ns.Name) {
+		return webhooks.Allow("")
+	}
+
+	// Subnamespaces are created by HNC itself and never get this far, but a subnamespace can also be
+	// recreated manually as long as its anchor still exists. Otherwise, the annotation would let
+	// users pick any parent without the checks below, since HNC treats it as the parent.
+	if snm := req.ns.Annotations[api.SubnamespaceOf]; snm != "" {
+		if rsp := v.illegalParent(req, snm); !rsp.Allowed {
+			return rsp
+		}
+		if !v.Forest.Get(snm).HasAnchor(req.ns.Name) {
+			err := fmt.Errorf("the %q annotation can only be set by HNC, or when recreating a subnamespace whose anchor still exists in %q", api.SubnamespaceOf, snm)
+			return webhooks.DenyForbidden(namespaceGR, req.ns.Name, err)
+		}
+	}
+
+	pnm := req.ns.Annotations[api.AnnotationParent]
+	if pnm == "" {
+		if req.ns.Annotations[api.SubnamespaceOf] != "" {
+			return webhooks.Allow("")
+		}
+		if !webhooks.CanCreateRoot(v.Forest.GetRootPolicy(), req.ui) {
+			err := fmt.Errorf("the HNC configuration requires every new namespace to have a parent. Please create it as a subnamespace, or set the %q annotation to the name of its parent", api.AnnotationParent)
+			return webhooks.DenyForbidden(namespaceGR, req.ns.Name, err)
+		}
+		return webhooks.Allow("")
+	}
+
+	return v.illegalParent(req, pnm)
+}
+
+// illegalParent checks that the requested parent of a new namespace is a managed namespace that
+// exists, and that the new namespace itself isn't managed by another system.
+func (v *Validator) illegalParent(req *nsRequest, pnm string) admission.Response {
+	if why := config.WhyUnmanaged(pnm); why != "" {
+		err := fmt.Errorf("the requested parent %q is not managed by HNC (%s)", pnm, why)
+		return webhooks.DenyForbidden(namespaceGR, req.ns.Name, err)
+	}
+	parent := v.Forest.Get(pnm)
+	if !parent.Exists() {
+		err := fmt.Errorf("the requested parent %q does not exist", pnm)
+		return webhooks.DenyForbidden(namespaceGR, req.ns.Name, err)
+	}
+	if mgr := req.ns.Annotations[api.AnnotationManagedBy]; mgr != "" && mgr != api.MetaGroup {
+		err := fmt.Errorf("is managed by %q, not HNC, so it cannot have a parent in HNC", mgr)
+		return webhooks.DenyForbidden(namespaceGR, req.ns.Name, err)
+	}
+	return webhooks.Allow("")
+}
+
+// illegalParentAnnotationUpdate only applies to the Update operation. The parent annotation is only
+// read when the namespace is created, so it can't be added or modified later, since that wouldn't
+// have any effect.
+func (v *Validator) illegalParentAnnotationUpdate(req *nsRequest) admission.Response {
+	if req.oldns == nil {
+		return webhooks.Allow("")
+	}
+	val, ok := req.ns.Annotations[api.AnnotationParent]
+	oldVal, oldOK := req.oldns.Annotations[api.AnnotationParent]
+	// Removing the annotation is always fine.
+	if !ok || (oldOK && oldVal == val) {
+		return webhooks.Allow("")
+	}
+	err := fmt.Errorf("the %q annotation can only be set when a namespace is created. To change the parent of an existing namespace, use its HierarchyConfiguration instead (e.g. via 'kubectl hns set')", api.AnnotationParent)
+	return webhooks.DenyForbidden(namespaceGR, req.ns.Name, err)
+}
+
+// checkServer checks that the user is an admin of the parent requested for a new namespace, either
+// via the parent annotation or the subnamespace-of annotation, since all its descendants, including
+// the new namespace, inherit its policies and permissions. This mirrors the checks made by the
+// HierarchyConfiguration validator when a parent is set.
+func (v *Validator) checkServer(ctx context.Context, log logr.Logger, req *nsRequest) admission.Response {
+	if req.op != k8sadm.Create || !config.IsManagedNamespace(req.ns.Name) {
+		return webhooks.Allow("")
+	}
+	if v.server == nil {
+		return webhooks.Allow("") // unit test
+	}
+
+	pnms := []string{}
+	for _, key := range []string{api.AnnotationParent, api.SubnamespaceOf} {
+		if pnm := req.ns.Annotations[key]; pnm != "" && (len(pnms) == 0 || pnms[0] != pnm) {
+			pnms = append(pnms, pnm)
+		}
+	}
+	for _, pnm := range pnms {
+		log.Info("Checking authz", "object", pnm, "reason", "requested parent")
+		allowed, err := v.server.IsAdmin(ctx, req.ui, pnm)
+		if err != nil {
+			err = fmt.Errorf("while checking authz for %q, the requested parent: %w", pnm, err)
+			return webhooks.DenyInternalError(err)
+		}
+		if !allowed {
+			return webhooks.DenyUnauthorized(fmt.Sprintf("User %s is not authorized to modify the subtree of %s, which is the requested parent",
+				req.ui.Username, pnm))
+		}
+	}
+	return webhooks.Allow("")
+}
+
+// illegalMonotonicLabel checks that no monotonic label (see config.IsMonotonicLabel) is being
+// loosened or removed, compared to the strictest value set on the namespace or its ancestors. It
+// only checks an Update request, since HNC doesn't know the ancestors of a new namespace.
+func (v *Validator) illegalMonotonicLabel(req *nsRequest, ns *forest.Namespace) admission.Response {
+	// External namespaces set their own managed labels.
+	if ns.IsExternal() || !config.IsManagedNamespace(req.ns.Name) {
+		return webhooks.Allow("")
+	}
+
+	for key, want := range ns.GetMonotonicLabels() {
+		val, ok := req.ns.Labels[key]
+		// Only check labels that are being changed, so unrelated updates are never blocked.
+		if req.oldns != nil {
+			if oldVal, oldOK := req.oldns.Labels[key]; oldOK == ok && oldVal == val {
+				continue
+			}
+		}
+		if !ok {
+			err := fmt.Errorf("cannot remove the monotonic label %q from namespace %q; its value %q is set by HNC", key, req.ns.Name, want)
+			return webhooks.DenyForbidden(namespaceGR, req.ns.Name, err)
+		}
+		if config.MonotonicLabelRank(key, val) < config.MonotonicLabelRank(key, want) {
+			err := fmt.Errorf("cannot set the monotonic label %q in namespace %q to %q, which is looser than the value %q set on the namespace or its ancestors", key, req.ns.Name, val, want)
+			return webhooks.DenyForbidden(namespaceGR, req.ns.Name, err)
+		}
+	}
+
+	return webhooks.Allow("")
+}
+
 // illegalIncludedNamespaceLabel checks if there's any illegal use of the
 // included-namespace label on namespaces. It only checks a Create or an Update
 // request.
@@ -288,10 +462,26 @@ func (v *Validator) decodeRequest(log logr.Logger, in admission.Request) (*nsReq
 		ns:    ns,
 		oldns: oldns,
 		op:    in.Operation,
+		ui:    &in.UserInfo,
 	}, nil
 }
 
+func (v *Validator) InjectClient(c client.Client) error {
+	v.server = &realClient{client: c}
+	return nil
+}
+
 func (v *Validator) InjectDecoder(d *admission.Decoder) error {
 	v.decoder = d
 	return nil
 }
+
+// realClient implements serverClient, and is not used during unit tests.
+type realClient struct {
+	client client.Client
+}
+
+// IsAdmin implements serverClient
+func (r *realClient) IsAdmin(ctx context.Context, ui *authnv1.UserInfo, nnm string) (bool, error) {
+	return webhooks.IsAdmin(ctx, r.client, ui, nnm)
+}
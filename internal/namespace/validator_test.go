@@ -1,12 +1,16 @@
 package namespaces
 
 import (
+	"context"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/gomega"
 	k8sadm "k8s.io/api/admission/v1"
+	authnv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
 	"sigs.k8s.io/hierarchical-namespaces/internal/config"
@@ -386,3 +390,214 @@ func TestIllegalTreeOperations(t *testing.T) {
 	}
 
 }
+
+func TestIllegalMonotonicLabel(t *testing.T) {
+	const psaLabel = "pod-security.kubernetes.io/enforce"
+	f := foresttest.Create("-a") // a <- b
+	f.Get("a").ManagedLabels = map[string]string{psaLabel: "baseline"}
+	vns := &Validator{Forest: f}
+	if err := config.SetMonotonicLabels([]string{psaLabel + "=privileged,baseline,restricted"}); err != nil {
+		t.Fatal(err)
+	}
+	defer config.SetMonotonicLabels(nil)
+
+	tests := []struct {
+		name    string
+		nnm     string
+		oldVal  string
+		newVal  string
+		allowed bool
+	}{
+		{name: "ok: unchanged", nnm: "b", oldVal: "baseline", newVal: "baseline", allowed: true},
+		{name: "ok: tighten", nnm: "b", oldVal: "baseline", newVal: "restricted", allowed: true},
+		{name: "ok: unrelated update of a loose namespace", nnm: "b", oldVal: "privileged", newVal: "privileged", allowed: true},
+		{name: "loosen", nnm: "b", oldVal: "baseline", newVal: "privileged"},
+		{name: "loosen the root", nnm: "a", oldVal: "baseline", newVal: "privileged"},
+		{name: "remove", nnm: "b", oldVal: "baseline"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			oldns := &corev1.Namespace{}
+			oldns.Name = tc.nnm
+			oldns.SetLabels(map[string]string{api.LabelIncludedNamespace: "true", psaLabel: tc.oldVal})
+			ns := oldns.DeepCopy()
+			if tc.newVal == "" {
+				delete(ns.Labels, psaLabel)
+			} else {
+				ns.Labels[psaLabel] = tc.newVal
+			}
+			req := &nsRequest{
+				ns:    ns,
+				oldns: oldns,
+				op:    k8sadm.Update,
+			}
+
+			// Test
+			got := vns.handle(req)
+
+			// Report
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).Should(Equal(tc.allowed))
+		})
+	}
+}
+
+func TestRootPolicy(t *testing.T) {
+	f := foresttest.Create("--") // a, b
+	f.Get("a").SetAnchors([]string{"new"})
+	f.SetRootPolicy(&api.RootPolicy{RequireParent: true, AllowedUsers: []string{"admin"}, AllowedGroups: []string{"admins"}})
+	vns := &Validator{Forest: f}
+	config.SetNamespaces("", "kube-system")
+
+	tests := []struct {
+		name    string
+		parent  string
+		subnsOf string
+		user    string
+		groups  []string
+		allowed bool
+	}{
+		{name: "ok: with parent", parent: "a", user: "bob", allowed: true},
+		{name: "ok: recreated subnamespace with anchor", subnsOf: "a", user: "bob", allowed: true},
+		{name: "subnamespace-of without anchor", subnsOf: "b", user: "bob"},
+		{name: "subnamespace-of missing parent", subnsOf: "brumpf", user: "bob"},
+		{name: "subnamespace-of unmanaged parent", subnsOf: "kube-system", user: "admin"},
+		{name: "subnamespace-of without anchor by allowed user", subnsOf: "b", user: "admin"},
+		{name: "ok: root by allowed user", user: "admin", allowed: true},
+		{name: "ok: root by allowed group", user: "bob", groups: []string{"admins"}, allowed: true},
+		{name: "root by other user", user: "bob"},
+		{name: "missing parent", parent: "brumpf", user: "bob"},
+		{name: "unmanaged parent", parent: "kube-system", user: "bob"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ns := &corev1.Namespace{}
+			ns.Name = "new"
+			ns.SetLabels(map[string]string{api.LabelIncludedNamespace: "true"})
+			ns.SetAnnotations(map[string]string{})
+			if tc.parent != "" {
+				ns.Annotations[api.AnnotationParent] = tc.parent
+			}
+			if tc.subnsOf != "" {
+				ns.Annotations[api.SubnamespaceOf] = tc.subnsOf
+			}
+			req := &nsRequest{
+				ns: ns,
+				op: k8sadm.Create,
+				ui: &authnv1.UserInfo{Username: tc.user, Groups: tc.groups},
+			}
+
+			// Test
+			got := vns.handle(req)
+
+			// Report
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).Should(Equal(tc.allowed))
+		})
+	}
+}
+
+func TestCheckServerRequestedParent(t *testing.T) {
+	// The user is only an admin of "a".
+	vns := &Validator{server: fakeServer("a")}
+	config.SetNamespaces("")
+
+	tests := []struct {
+		name    string
+		parent  string
+		subnsOf string
+		allowed bool
+	}{
+		{name: "ok: root", allowed: true},
+		{name: "ok: parent annotation", parent: "a", allowed: true},
+		{name: "ok: subnamespace-of annotation", subnsOf: "a", allowed: true},
+		{name: "parent annotation", parent: "b"},
+		{name: "subnamespace-of annotation", subnsOf: "b"},
+		{name: "subnamespace-of annotation with a different parent annotation", parent: "a", subnsOf: "b"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ns := &corev1.Namespace{}
+			ns.Name = "new"
+			ns.SetAnnotations(map[string]string{})
+			if tc.parent != "" {
+				ns.Annotations[api.AnnotationParent] = tc.parent
+			}
+			if tc.subnsOf != "" {
+				ns.Annotations[api.SubnamespaceOf] = tc.subnsOf
+			}
+			req := &nsRequest{
+				ns: ns,
+				op: k8sadm.Create,
+				ui: &authnv1.UserInfo{Username: "bob"},
+			}
+
+			// Test
+			got := vns.checkServer(context.Background(), zap.New(), req)
+
+			// Report
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).Should(Equal(tc.allowed))
+		})
+	}
+}
+
+// fakeServer implements serverClient. It's a list of the namespaces that the user is an admin of,
+// separated by commas.
+type fakeServer string
+
+func (f fakeServer) IsAdmin(_ context.Context, _ *authnv1.UserInfo, nnm string) (bool, error) {
+	for _, anm := range strings.Split(string(f), ",") {
+		if anm == nnm {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestIllegalParentAnnotationUpdate(t *testing.T) {
+	f := foresttest.Create("--") // a, b
+	vns := &Validator{Forest: f}
+
+	tests := []struct {
+		name    string
+		oldVal  string
+		newVal  string
+		allowed bool
+	}{
+		{name: "ok: unchanged", oldVal: "a", newVal: "a", allowed: true},
+		{name: "ok: removed", oldVal: "a", allowed: true},
+		{name: "added", newVal: "a"},
+		{name: "changed", oldVal: "a", newVal: "b"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			oldns := &corev1.Namespace{}
+			oldns.Name = "new"
+			if tc.oldVal != "" {
+				oldns.SetAnnotations(map[string]string{api.AnnotationParent: tc.oldVal})
+			}
+			ns := &corev1.Namespace{}
+			ns.Name = "new"
+			if tc.newVal != "" {
+				ns.SetAnnotations(map[string]string{api.AnnotationParent: tc.newVal})
+			}
+			req := &nsRequest{
+				ns:    ns,
+				oldns: oldns,
+				op:    k8sadm.Update,
+			}
+
+			// Test
+			got := vns.illegalParentAnnotationUpdate(req)
+
+			// Report
+			logResult(t, got.AdmissionResponse.Result)
+			g.Expect(got.AdmissionResponse.Allowed).Should(Equal(tc.allowed))
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package netpol
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	"sigs.k8s.io/hierarchical-namespaces/internal/foresttest"
+)
+
+func TestPolicySpec(t *testing.T) {
+	ingress := metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "ingress"}}
+
+	// a <- b <- c, d <- e <- f, and a <- g, a <- h
+	f := foresttest.Create("-ab-deaa")
+	f.Get("a").UpdateNetworkIsolation(api.NetworkIsolationSubtree, []metav1.LabelSelector{ingress})
+	f.Get("c").UpdateNetworkIsolation(api.NetworkIsolationNone, nil)
+	f.Get("d").UpdateNetworkIsolation(api.NetworkIsolationNone, nil)
+	f.Get("e").UpdateNetworkIsolation(api.NetworkIsolationNamespace, nil)
+	f.Get("f").UpdateNetworkIsolation(api.NetworkIsolationSubtree, nil)
+	f.Get("g").UpdateNetworkIsolation(api.NetworkIsolationSubtree, []metav1.LabelSelector{{}})
+	f.Get("h").UpdateNetworkIsolation(api.NetworkIsolationNamespace, []metav1.LabelSelector{{}, ingress})
+
+	tests := []struct {
+		ns          string
+		wantNone    bool
+		wantNSSel   string
+		wantPodSel  bool
+		wantNumPeer int
+	}{
+		{ns: "a", wantNSSel: "a" + api.LabelTreeDepthSuffix, wantNumPeer: 2},
+		{ns: "b", wantNSSel: "a" + api.LabelTreeDepthSuffix, wantNumPeer: 2},
+		{ns: "c", wantNSSel: "a" + api.LabelTreeDepthSuffix, wantNumPeer: 2}, // can't loosen its ancestor's isolation
+		{ns: "d", wantNone: true},
+		{ns: "e", wantPodSel: true, wantNumPeer: 1},
+		{ns: "f", wantPodSel: true, wantNumPeer: 1},                          // can't loosen its ancestor's isolation
+		{ns: "g", wantNSSel: "g" + api.LabelTreeDepthSuffix, wantNumPeer: 1}, // can't add exceptions
+		{ns: "h", wantPodSel: true, wantNumPeer: 2},                          // can only keep inherited exceptions
+	}
+	for _, tc := range tests {
+		t.Run(tc.ns, func(t *testing.T) {
+			g := NewWithT(t)
+			got := policySpec(f.Get(tc.ns).GetNetworkIsolation())
+			if tc.wantNone {
+				g.Expect(got).Should(BeNil())
+				return
+			}
+			g.Expect(got).ShouldNot(BeNil())
+			g.Expect(got.PodSelector.Size()).Should(Equal(0)) // selects all pods
+			g.Expect(got.Ingress).Should(HaveLen(1))
+			peers := got.Ingress[0].From
+			g.Expect(peers).Should(HaveLen(tc.wantNumPeer))
+			if tc.wantNSSel != "" {
+				g.Expect(peers[0].NamespaceSelector.MatchExpressions[0].Key).Should(Equal(tc.wantNSSel))
+			}
+			if tc.wantPodSel {
+				g.Expect(peers[0].NamespaceSelector).Should(BeNil())
+				g.Expect(peers[0].PodSelector).ShouldNot(BeNil())
+			}
+			if tc.wantNumPeer > 1 {
+				g.Expect(peers[1].NamespaceSelector).Should(Equal(&ingress))
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+// Package netpol contains the reconciler that implements the network isolation settings of the
+// HierarchyConfiguration.
+package netpol
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	"sigs.k8s.io/hierarchical-namespaces/internal/config"
+	"sigs.k8s.io/hierarchical-namespaces/internal/forest"
+	"sigs.k8s.io/hierarchical-namespaces/internal/logutils"
+	"sigs.k8s.io/hierarchical-namespaces/internal/metadata"
+)
+
+const (
+	// NetworkPolicySingleton is the name of the NetworkPolicy object created by the Reconciler in
+	// each isolated namespace.
+	NetworkPolicySingleton = "network-isolation." + api.MetaGroup
+)
+
+// Reconciler reconciles the singleton NetworkPolicy per namespace, which implements the strictest
+// network isolation settings of the namespace and its ancestors. The reconciler is
+// called on two occasions:
+//  1. The settings or the ancestors of this namespace have changed, in which case the
+//     HierarchyConfiguration reconciler calls OnChangeNamespace on this namespace or one of its
+//     ancestors.
+//  2. Someone else has modified or deleted the singleton, in which case the change is reverted.
+type Reconciler struct {
+	client.Client
+	Log logr.Logger
+
+	// Forest is the in-memory data structure that is shared with all other reconcilers.
+	Forest *forest.Forest
+
+	// reader reads the singletons from a cache that only contains the singletons (see
+	// SetupWithManager).
+	reader client.Reader
+
+	// trigger is a channel of event.GenericEvent (see "Watching Channels" in
+	// https://book-v1.book.kubebuilder.io/beyond_basics/controller_watches.html)
+	// that is used to enqueue the singleton to trigger reconciliation.
+	trigger chan event.GenericEvent
+}
+
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// The reconciler only reconciles NetworkPolicy objects created by itself.
+	if req.NamespacedName.Name != NetworkPolicySingleton {
+		return ctrl.Result{}, nil
+	}
+	ns := req.NamespacedName.Namespace
+	if !config.IsManagedNamespace(ns) {
+		return ctrl.Result{}, nil
+	}
+	log := logutils.WithRID(r.Log).WithValues("trigger", req.NamespacedName)
+
+	inst, err := r.getSingleton(ctx, ns)
+	if err != nil {
+		log.Error(err, "Couldn't read singleton")
+		return ctrl.Result{}, err
+	}
+
+	want, skip := r.syncWithForest(ns)
+	if skip {
+		return ctrl.Result{}, nil
+	}
+
+	// Delete the obsolete singleton and early exit if the namespace is no longer isolated.
+	if want == nil {
+		return ctrl.Result{}, r.deleteSingleton(ctx, log, inst)
+	}
+
+	// We only need to write back to the apiserver if the spec has changed.
+	if !inst.CreationTimestamp.IsZero() && equality.Semantic.DeepEqual(inst.Spec, *want) {
+		return ctrl.Result{}, nil
+	}
+	inst.Spec = *want
+	return ctrl.Result{}, r.writeSingleton(ctx, log, inst)
+}
+
+// getSingleton returns the singleton if it exists, or creates an empty one if it doesn't.
+func (r *Reconciler) getSingleton(ctx context.Context, ns string) (*networkingv1.NetworkPolicy, error) {
+	nnm := types.NamespacedName{Namespace: ns, Name: NetworkPolicySingleton}
+	inst := &networkingv1.NetworkPolicy{}
+	if err := r.reader.Get(ctx, nnm, inst); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		inst.ObjectMeta.Name = NetworkPolicySingleton
+		inst.ObjectMeta.Namespace = ns
+	}
+	return inst, nil
+}
+
+// writeSingleton creates the singleton on the apiserver if it does not exist. Otherwise, it updates
+// the existing singleton on the apiserver.
+func (r *Reconciler) writeSingleton(ctx context.Context, log logr.Logger, inst *networkingv1.NetworkPolicy) error {
+	if inst.CreationTimestamp.IsZero() {
+		// Set the label so the singleton can be found by selector later.
+		metadata.SetLabel(inst, api.LabelNetworkIsolation, "true")
+		// Make sure the singleton is never overwritten by ancestors if network policies are propagated.
+		metadata.SetAnnotation(inst, api.NonPropagateAnnotation, "true")
+		log.V(1).Info("Creating a singleton on apiserver")
+		if err := r.Create(ctx, inst); err != nil {
+			return fmt.Errorf("while creating network policy: %w", err)
+		}
+		return nil
+	}
+
+	log.V(1).Info("Updating the singleton on apiserver")
+	if err := r.Update(ctx, inst); err != nil {
+		return fmt.Errorf("while updating network policy: %w", err)
+	}
+	return nil
+}
+
+// deleteSingleton deletes the singleton on the apiserver if it exists. Otherwise, do nothing.
+func (r *Reconciler) deleteSingleton(ctx context.Context, log logr.Logger, inst *networkingv1.NetworkPolicy) error {
+	// Early exit if the singleton doesn't already exist.
+	if inst.CreationTimestamp.IsZero() {
+		return nil
+	}
+
+	log.V(1).Info("Deleting obsolete singleton on apiserver")
+	if err := r.Delete(ctx, inst); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("while deleting network policy: %w", err)
+	}
+	return nil
+}
+
+// syncWithForest returns the desired spec of the singleton in the given namespace, or nil if there
+// shouldn't be a singleton. The second return value is true if the singleton should be left alone,
+// because activities are halted in the namespace or it no longer exists.
+func (r *Reconciler) syncWithForest(nsnm string) (*networkingv1.NetworkPolicySpec, bool) {
+	r.Forest.Lock()
+	defer r.Forest.Unlock()
+	ns := r.Forest.Get(nsnm)
+	if !ns.Exists() || ns.IsHalted() {
+		return nil, true
+	}
+	return policySpec(ns.GetNetworkIsolation()), false
+}
+
+// policySpec returns the spec of a NetworkPolicy that implements the given network isolation mode,
+// set on the namespace root, or nil if no policy is needed.
+func policySpec(mode api.NetworkIsolationMode, root string, exceptions []metav1.LabelSelector) *networkingv1.NetworkPolicySpec {
+	var peers []networkingv1.NetworkPolicyPeer
+	switch mode {
+	case api.NetworkIsolationSubtree:
+		// Every namespace in the subtree of the root has the root's tree label.
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      root + api.LabelTreeDepthSuffix,
+					Operator: metav1.LabelSelectorOpExists,
+				}},
+			},
+		})
+	case api.NetworkIsolationNamespace:
+		// A pod selector without a namespace selector only selects pods in the same namespace.
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			PodSelector: &metav1.LabelSelector{},
+		})
+	default:
+		return nil
+	}
+	for i := range exceptions {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			NamespaceSelector: exceptions[i].DeepCopy(),
+		})
+	}
+
+	return &networkingv1.NetworkPolicySpec{
+		// Select all pods in the namespace.
+		PodSelector: metav1.LabelSelector{},
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		Ingress:     []networkingv1.NetworkPolicyIngressRule{{From: peers}},
+	}
+}
+
+// OnChangeNamespace enqueues the singletons in the namespace and all its descendants, since they
+// all inherit its network isolation settings. This occurs in a goroutine so the caller doesn't
+// block; since the reconciler is never garbage-collected, this is safe.
+//
+// The caller holds the forest lock.
+func (r *Reconciler) OnChangeNamespace(log logr.Logger, ns *forest.Namespace) {
+	nsnms := append([]string{ns.Name()}, ns.DescendantNames()...)
+	go func() {
+		for _, nsnm := range nsnms {
+			// The watch handler doesn't care about anything except the metadata.
+			inst := &networkingv1.NetworkPolicy{}
+			inst.ObjectMeta.Name = NetworkPolicySingleton
+			inst.ObjectMeta.Namespace = nsnm
+			r.trigger <- event.GenericEvent{Object: inst}
+		}
+	}()
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.trigger = make(chan event.GenericEvent)
+
+	// Only watch the singletons rather than every NetworkPolicy in the cluster. This needs its own
+	// cache, since the selector would otherwise also apply to the manager's cache, which is used to
+	// propagate NetworkPolicies. We select the singletons by name rather than by label since users
+	// can't change the name.
+	c, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme: mgr.GetScheme(),
+		Mapper: mgr.GetRESTMapper(),
+		SelectorsByObject: cache.SelectorsByObject{
+			&networkingv1.NetworkPolicy{}: {Field: fields.OneTermEqualSelector("metadata.name", NetworkPolicySingleton)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err := mgr.Add(c); err != nil {
+		return err
+	}
+	r.reader = c
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("networkpolicy").
+		Watches(source.NewKindWithCache(&networkingv1.NetworkPolicy{}, c), &handler.EnqueueRequestForObject{}).
+		Watches(&source.Channel{Source: r.trigger}, &handler.EnqueueRequestForObject{}).
+		Complete(r)
+}
@@ -0,0 +1,123 @@
+package netpol_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	. "sigs.k8s.io/hierarchical-namespaces/internal/integtest"
+	"sigs.k8s.io/hierarchical-namespaces/internal/netpol"
+)
+
+func TestInteg(t *testing.T) {
+	HNCRun(t, "NetworkPolicy reconciler")
+}
+
+var _ = BeforeSuite(HNCBeforeSuite)
+var _ = AfterSuite(HNCAfterSuite)
+
+var _ = Describe("Network isolation", func() {
+	ctx := context.Background()
+
+	var (
+		fooName string
+		barName string
+		bazName string
+	)
+
+	BeforeEach(func() {
+		fooName = CreateNS(ctx, "foo")
+		barName = CreateNS(ctx, "bar")
+		bazName = CreateNS(ctx, "baz")
+		SetParent(ctx, barName, fooName)
+		SetParent(ctx, bazName, barName)
+	})
+
+	It("should isolate the whole subtree", func() {
+		setNetworkIsolation(ctx, fooName, api.NetworkIsolationSubtree)
+
+		for _, nm := range []string{fooName, barName, bazName} {
+			Eventually(allowedTree(ctx, nm)).Should(Equal(fooName))
+		}
+	})
+
+	It("should let descendants tighten, but not loosen, the isolation of their ancestors", func() {
+		setNetworkIsolation(ctx, fooName, api.NetworkIsolationSubtree)
+		Eventually(allowedTree(ctx, bazName)).Should(Equal(fooName))
+
+		// Isolate bar's subtree further.
+		setNetworkIsolation(ctx, barName, api.NetworkIsolationSubtree)
+		Eventually(allowedTree(ctx, bazName)).Should(Equal(barName))
+		Eventually(allowedTree(ctx, fooName)).Should(Equal(fooName))
+
+		// Try to turn off isolation in bar's subtree; foo's isolation still applies.
+		setNetworkIsolation(ctx, barName, api.NetworkIsolationNone)
+		Eventually(allowedTree(ctx, bazName)).Should(Equal(fooName))
+		Eventually(allowedTree(ctx, barName)).Should(Equal(fooName))
+	})
+
+	It("should recalculate the policies when the tree changes", func() {
+		setNetworkIsolation(ctx, fooName, api.NetworkIsolationSubtree)
+		Eventually(allowedTree(ctx, bazName)).Should(Equal(fooName))
+
+		// Move baz out of foo's subtree.
+		SetParent(ctx, bazName, "")
+		Eventually(hasPolicy(ctx, bazName)).Should(BeFalse())
+		Expect(hasPolicy(ctx, barName)()).Should(BeTrue())
+	})
+
+	It("should restore a modified policy", func() {
+		setNetworkIsolation(ctx, fooName, api.NetworkIsolationSubtree)
+		Eventually(allowedTree(ctx, barName)).Should(Equal(fooName))
+
+		inst := &networkingv1.NetworkPolicy{}
+		Expect(K8sClient.Get(ctx, types.NamespacedName{Namespace: barName, Name: netpol.NetworkPolicySingleton}, inst)).Should(Succeed())
+		Expect(K8sClient.Delete(ctx, inst)).Should(Succeed())
+
+		Eventually(allowedTree(ctx, barName)).Should(Equal(fooName))
+	})
+})
+
+func setNetworkIsolation(ctx context.Context, nm string, mode api.NetworkIsolationMode) {
+	EventuallyWithOffset(1, func() error {
+		hier := GetHierarchy(ctx, nm)
+		hier.Spec.NetworkIsolation = mode
+		return TryUpdateHierarchy(ctx, hier) // can fail if a reconciler updates the hierarchy
+	}).Should(Succeed())
+}
+
+func getPolicy(ctx context.Context, nm string) *networkingv1.NetworkPolicy {
+	inst := &networkingv1.NetworkPolicy{}
+	if err := K8sClient.Get(ctx, types.NamespacedName{Namespace: nm, Name: netpol.NetworkPolicySingleton}, inst); err != nil {
+		return nil
+	}
+	return inst
+}
+
+func hasPolicy(ctx context.Context, nm string) func() bool {
+	return func() bool {
+		return getPolicy(ctx, nm) != nil
+	}
+}
+
+// allowedTree returns the name of the namespace whose subtree is allowed ingress into the given
+// namespace, or the empty string if there's no such policy.
+func allowedTree(ctx context.Context, nm string) func() string {
+	return func() string {
+		inst := getPolicy(ctx, nm)
+		if inst == nil || len(inst.Spec.Ingress) != 1 || len(inst.Spec.Ingress[0].From) == 0 {
+			return ""
+		}
+		sel := inst.Spec.Ingress[0].From[0].NamespaceSelector
+		if sel == nil || len(sel.MatchExpressions) != 1 {
+			return ""
+		}
+		key := sel.MatchExpressions[0].Key
+		return key[:len(key)-len(api.LabelTreeDepthSuffix)]
+	}
+}
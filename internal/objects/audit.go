@@ -0,0 +1,88 @@
+package objects
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	"sigs.k8s.io/hierarchical-namespaces/internal/stats"
+)
+
+// syncAudit is called in the Audit mode with the action that would be taken on the object in the
+// Propagate mode. Rather than taking the action, it records (and reports) whether the object has
+// drifted from what would be propagated, and always returns actionNop. It must be called while the
+// forest lock is held.
+func (r *Reconciler) syncAudit(log logr.Logger, inst, srcInst *unstructured.Unstructured, act syncAction) syncAction {
+	nnm := types.NamespacedName{Namespace: inst.GetNamespace(), Name: inst.GetName()}
+	exists := inst.GetCreationTimestamp() != metav1.Time{}
+	if hasPropagatedLabel(inst) {
+		if exists {
+			r.recordPropagatedObject(nnm.Namespace, nnm.Name)
+		} else {
+			r.recordRemovedObject(nnm.Namespace, nnm.Name)
+		}
+	}
+
+	// There's nothing to delete if the object doesn't exist.
+	if act == actionRemove && !exists {
+		act = actionNop
+	}
+	if act != actionWrite && act != actionRemove {
+		r.forgetDrift(nnm)
+		return actionNop
+	}
+
+	// Only report drifts once, unless they change, to avoid flooding the events.
+	if !r.recordDrift(nnm, act) {
+		return actionNop
+	}
+	log.Info("Object has drifted from what would be propagated", "wouldAction", act)
+	stats.DriftObject(r.GVK)
+
+	switch {
+	case act == actionRemove:
+		r.EventRecorder.Event(inst, "Warning", api.EventDriftDetected, "Audit: this object would be deleted since it no longer has a source.")
+	case !exists:
+		msg := fmt.Sprintf("Audit: this object would be propagated to namespace %q, where it doesn't exist.", nnm.Namespace)
+		r.EventRecorder.Event(srcInst, "Warning", api.EventDriftDetected, msg)
+	default:
+		msg := fmt.Sprintf("Audit: this object would be overwritten by the object from source namespace %q.", srcInst.GetNamespace())
+		r.EventRecorder.Event(inst, "Warning", api.EventDriftDetected, msg)
+		msg = fmt.Sprintf("Audit: the object in namespace %q differs from this object and would be overwritten.", nnm.Namespace)
+		r.EventRecorder.Event(srcInst, "Warning", api.EventDriftDetected, msg)
+	}
+	return actionNop
+}
+
+// recordDrift records the action that would be taken on a drifted object. It returns true if this
+// is a new drift.
+func (r *Reconciler) recordDrift(nnm types.NamespacedName, act syncAction) bool {
+	r.driftsLock.Lock()
+	defer r.driftsLock.Unlock()
+
+	if r.drifts[nnm] == act {
+		return false
+	}
+	r.drifts[nnm] = act
+	return true
+}
+
+// forgetDrift records that an object has not drifted.
+func (r *Reconciler) forgetDrift(nnm types.NamespacedName) {
+	r.driftsLock.Lock()
+	defer r.driftsLock.Unlock()
+
+	delete(r.drifts, nnm)
+}
+
+// forgetDrifts forgets all drifted objects, e.g. when leaving the Audit mode.
+func (r *Reconciler) forgetDrifts() {
+	r.driftsLock.Lock()
+	defer r.driftsLock.Unlock()
+
+	r.drifts = map[types.NamespacedName]syncAction{}
+}
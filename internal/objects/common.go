@@ -1,13 +1,28 @@
 package objects
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
 
 	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
 )
 
+// fieldManager is the field manager that HNC uses when it applies propagated objects. HNC only owns
+// the fields that it copies from the source; other controllers are free to set other fields on the
+// copies.
+const fieldManager = "hnc"
+
 // metaPrefix returns the prefix (if any) of a label key.
 // Reference https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#syntax-and-character-set
 func metaPrefix(s string) string {
@@ -20,11 +35,12 @@ func metaPrefix(s string) string {
 
 // canonical returns a canonicalized version of the object - that is, one that has the same name,
 // spec and non-HNC labels and annotations, but with the status and all other metadata cleared
-// (including, notably, the namespace). The resulting object is suitable to be copied into a new
-// namespace, or two canonicalized objects are suitable for being compared via reflect.DeepEqual.
+// (including, notably, the namespace). Any fields removed by the sanitizers of this type are also
+// removed (see sanitize()). The resulting object is suitable to be copied into a new namespace, or
+// two canonicalized objects are suitable for being compared via reflect.DeepEqual.
 //
 // As a side effect, the label and annotation maps are always initialized in the returned value.
-func canonical(inst *unstructured.Unstructured) *unstructured.Unstructured {
+func canonical(inst *unstructured.Unstructured, ss []sanitizer) *unstructured.Unstructured {
 	// Start with a copy and clear the status and metadata
 	c := inst.DeepCopy()
 	delete(c.Object, "status")
@@ -51,5 +67,198 @@ func canonical(inst *unstructured.Unstructured) *unstructured.Unstructured {
 	}
 	c.SetLabels(newLabels)
 
+	sanitize(c, ss)
 	return c
 }
+
+// hncMetadata returns only the HNC labels or annotations from the given map, which are the ones
+// that canonical() removes.
+func hncMetadata(m map[string]string) map[string]string {
+	out := map[string]string{}
+	for k, v := range m {
+		if strings.HasSuffix(metaPrefix(k), api.MetaGroup) {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// digest returns a hash of the canonical version of the object. Two objects have the same digest if
+// and only if their canonical versions are equal (barring hash collisions), so this can be used to
+// compare a source and a copy without keeping the copy in memory. The zero value is returned if the
+// object can't be serialized, which should never happen.
+func digest(inst *unstructured.Unstructured, ss []sanitizer) [sha256.Size]byte {
+	// The JSON encoder sorts map keys, so the output is stable.
+	b, err := json.Marshal(canonical(inst, ss).Object)
+	if err != nil {
+		return [sha256.Size]byte{}
+	}
+	return sha256.Sum256(b)
+}
+
+// immutableWhenSetErrorMsg is the message of the apiserver's FieldValueForbidden errors for fields
+// that can't be changed once an object is marked as immutable.
+const immutableWhenSetErrorMsg = "field is immutable when `immutable` is set"
+
+// isImmutableFieldError returns true if the error was caused by an update that would have changed
+// an immutable field. There's no specific error type for this, so we look at the causes of Invalid
+// errors: the apiserver reports fields that can't be changed once the object is marked as immutable
+// (e.g. the data of ConfigMaps and Secrets) as FieldValueForbidden with immutableWhenSetErrorMsg,
+// and other immutable fields as FieldValueInvalid with the message from
+// validation.ValidateImmutableField. RoleBindings use their own message when their roleRef is
+// changed, so we only look at the field there (see
+// https://github.com/kubernetes-sigs/hierarchical-namespaces/issues/798).
+func isImmutableFieldError(err error) bool {
+	if !errors.IsInvalid(err) {
+		return false
+	}
+	status, ok := err.(errors.APIStatus)
+	if !ok || status.Status().Details == nil {
+		return false
+	}
+	details := status.Status().Details
+	for _, c := range details.Causes {
+		switch c.Type {
+		case metav1.CauseType(field.ErrorTypeForbidden):
+			// Most Forbidden causes have nothing to do with immutability (e.g. "may not be set when type
+			// is ..."), and recreating the copy wouldn't help with those.
+			if strings.HasSuffix(c.Message, immutableWhenSetErrorMsg) {
+				return true
+			}
+		case metav1.CauseTypeFieldValueInvalid:
+			if strings.HasSuffix(c.Message, validation.FieldImmutableErrorMsg) {
+				return true
+			}
+			if details.Group == api.RBACGroup && details.Kind == api.RoleBindingKind && c.Field == "roleRef" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hncFields returns the set of fields that HNC owns in this object, or nil if HNC has never applied
+// the object (e.g. because it was propagated by an older version of HNC that used regular updates).
+func hncFields(inst *unstructured.Unstructured) *fieldpath.Set {
+	for _, mf := range inst.GetManagedFields() {
+		if mf.Manager != fieldManager || mf.Operation != metav1.ManagedFieldsOperationApply || mf.FieldsV1 == nil {
+			continue
+		}
+		s := &fieldpath.Set{}
+		if err := s.FromJSON(bytes.NewReader(mf.FieldsV1.Raw)); err != nil {
+			// Malformed managed fields should never happen, but if they do, treat the whole object as
+			// being owned by HNC, as if it had never been applied.
+			return nil
+		}
+		return s
+	}
+	return nil
+}
+
+// withFields returns a copy of the object that only includes the fields in the set, along with its
+// apiVersion, kind and name. If the set is nil, the whole object is returned.
+func withFields(inst *unstructured.Unstructured, fields *fieldpath.Set) *unstructured.Unstructured {
+	if fields == nil {
+		return inst
+	}
+	c := &unstructured.Unstructured{Object: project(inst.Object, fields).(map[string]interface{})}
+	c.SetAPIVersion(inst.GetAPIVersion())
+	c.SetKind(inst.GetKind())
+	c.SetName(inst.GetName())
+	return c
+}
+
+// ownedByHNC returns a copy of the object that only includes the fields that HNC owns in it. If HNC
+// has never applied this object, the whole object is returned.
+func ownedByHNC(inst *unstructured.Unstructured) *unstructured.Unstructured {
+	return withFields(inst, hncFields(inst))
+}
+
+// project returns a deep copy of the parts of the value that are in the set of fields. Fields and
+// list items that are in the set but missing from the value are left out, so that a projection can
+// be compared directly with a source that doesn't have them either.
+func project(v interface{}, fields *fieldpath.Set) interface{} {
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		out := map[string]interface{}{}
+		fields.Members.Iterate(func(pe fieldpath.PathElement) {
+			if pe.FieldName == nil {
+				return
+			}
+			if fv, ok := tv[*pe.FieldName]; ok {
+				out[*pe.FieldName] = deepCopyValue(fv)
+			}
+		})
+		// Children take precedence over members, since if a field is both, HNC only owns some of its
+		// contents (for example, the labels map is a member if HNC has set any labels).
+		fields.Children.Iterate(func(pe fieldpath.PathElement) {
+			if pe.FieldName == nil {
+				return
+			}
+			if fv, ok := tv[*pe.FieldName]; ok {
+				child, _ := fields.Children.Get(pe)
+				out[*pe.FieldName] = project(fv, child)
+			}
+		})
+		return out
+
+	case []interface{}:
+		// Keep the items in the order of the value rather than the order of the set (which is sorted),
+		// so that a projection can be compared directly with a source that has the same items.
+		out := []interface{}{}
+		for i, item := range tv {
+			var child *fieldpath.Set
+			fields.Children.Iterate(func(pe fieldpath.PathElement) {
+				if child == nil && listItemMatches(item, i, pe) {
+					child, _ = fields.Children.Get(pe)
+				}
+			})
+			if child != nil {
+				out = append(out, project(item, child))
+				continue
+			}
+			owned := false
+			fields.Members.Iterate(func(pe fieldpath.PathElement) {
+				owned = owned || listItemMatches(item, i, pe)
+			})
+			if owned {
+				out = append(out, deepCopyValue(item))
+			}
+		}
+		return out
+
+	default:
+		// The value is either missing or isn't a composite type, so there's nothing to select from.
+		return nil
+	}
+}
+
+// listItemMatches returns true if the path element refers to the item at index i of a list.
+func listItemMatches(item interface{}, i int, pe fieldpath.PathElement) bool {
+	switch {
+	case pe.Index != nil:
+		return *pe.Index == i
+	case pe.Value != nil:
+		return value.Equals(value.NewValueInterface(item), *pe.Value)
+	case pe.Key != nil:
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for _, f := range *pe.Key {
+			if !value.Equals(value.NewValueInterface(m[f.Name]), f.Value) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// deepCopyValue deep-copies a JSON-compatible value, such as a field of an unstructured object.
+func deepCopyValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return runtime.DeepCopyJSONValue(v)
+}
@@ -1,10 +1,15 @@
 package objects
 
 import (
+	"fmt"
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
 )
@@ -74,7 +79,7 @@ func TestCanonical(t *testing.T) {
 			// Setup
 			g := NewWithT(t)
 			// Test
-			got := canonical(tc.inst)
+			got := canonical(tc.inst, nil)
 			if tc.expected == nil {
 				tc.expected = tc.inst
 			}
@@ -83,3 +88,244 @@ func TestCanonical(t *testing.T) {
 		})
 	}
 }
+
+func TestDigest(t *testing.T) {
+	obj := func(labels map[string]interface{}, data string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name":   "foo",
+					"labels": labels,
+				},
+				"data": map[string]interface{}{
+					"key": data,
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		a     *unstructured.Unstructured
+		b     *unstructured.Unstructured
+		equal bool
+	}{{
+		name:  "Identical objects",
+		a:     obj(map[string]interface{}{"label": "value"}, "data"),
+		b:     obj(map[string]interface{}{"label": "value"}, "data"),
+		equal: true,
+	}, {
+		name:  "HNC metadata is ignored",
+		a:     obj(map[string]interface{}{"label": "value"}, "data"),
+		b:     obj(map[string]interface{}{"label": "value", api.LabelInheritedFrom: "foo"}, "data"),
+		equal: true,
+	}, {
+		name:  "Different data",
+		a:     obj(map[string]interface{}{"label": "value"}, "data"),
+		b:     obj(map[string]interface{}{"label": "value"}, "other data"),
+		equal: false,
+	}, {
+		name:  "Different non-HNC labels",
+		a:     obj(map[string]interface{}{"label": "value"}, "data"),
+		b:     obj(map[string]interface{}{"label": "other value"}, "data"),
+		equal: false,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(digest(tc.a, nil) == digest(tc.b, nil)).Should(Equal(tc.equal))
+		})
+	}
+}
+
+func TestOwnedByHNC(t *testing.T) {
+	rules := []interface{}{
+		map[string]interface{}{"name": "a", "value": "1", "extra": "x"},
+		map[string]interface{}{"name": "b", "value": "2"},
+	}
+
+	tests := []struct {
+		name     string
+		fields   string
+		expected map[string]interface{}
+	}{{
+		name: "Never applied by HNC",
+		expected: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Example",
+			"metadata": map[string]interface{}{
+				"name":   "obj",
+				"labels": map[string]interface{}{"mine": "1", "theirs": "2"},
+			},
+			"data":  map[string]interface{}{"mine": "a", "theirs": "b"},
+			"rules": rules,
+		},
+	}, {
+		name:   "Only owned fields",
+		fields: `{"f:data":{"f:mine":{}},"f:metadata":{"f:labels":{".":{},"f:mine":{}}}}`,
+		expected: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Example",
+			"metadata": map[string]interface{}{
+				"name":   "obj",
+				"labels": map[string]interface{}{"mine": "1"},
+			},
+			"data": map[string]interface{}{"mine": "a"},
+		},
+	}, {
+		name:   "Owned list items selected by key",
+		fields: `{"f:rules":{"k:{\"name\":\"a\"}":{".":{},"f:name":{},"f:value":{}}}}`,
+		expected: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Example",
+			"metadata":   map[string]interface{}{"name": "obj"},
+			"rules": []interface{}{
+				map[string]interface{}{"name": "a", "value": "1"},
+			},
+		},
+	}, {
+		name:   "Owned fields that are missing",
+		fields: `{"f:spec":{}}`,
+		expected: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Example",
+			"metadata":   map[string]interface{}{"name": "obj"},
+		},
+	}, {
+		name:   "Owned nested fields and list items that are missing",
+		fields: `{"f:data":{"f:gone":{}},"f:spec":{"f:replicas":{}},"f:rules":{"k:{\"name\":\"z\"}":{".":{},"f:name":{}}}}`,
+		expected: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Example",
+			"metadata":   map[string]interface{}{"name": "obj"},
+			"data":       map[string]interface{}{},
+			"rules":      []interface{}{},
+		},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			inst := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "Example",
+					"metadata": map[string]interface{}{
+						"name":   "obj",
+						"labels": map[string]interface{}{"mine": "1", "theirs": "2"},
+					},
+					"data":  map[string]interface{}{"mine": "a", "theirs": "b"},
+					"rules": rules,
+				},
+			}
+			if tc.fields != "" {
+				inst.SetManagedFields([]metav1.ManagedFieldsEntry{{
+					Manager:    fieldManager,
+					Operation:  metav1.ManagedFieldsOperationApply,
+					FieldsType: "FieldsV1",
+					FieldsV1:   &metav1.FieldsV1{Raw: []byte(tc.fields)},
+				}})
+			}
+			got := ownedByHNC(inst)
+			// HNC never owns the managed fields themselves.
+			got.SetManagedFields(nil)
+			g.Expect(got.Object).Should(Equal(tc.expected))
+		})
+	}
+}
+
+func TestOwnedByHNCMatchesSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    map[string]interface{}
+		fields string
+	}{{
+		// HNC used to set binaryData on the copy, but it has since been removed from the source (and the
+		// copy).
+		name:   "owned field that's missing",
+		src:    map[string]interface{}{"data": map[string]interface{}{"key": "value"}},
+		fields: `{"f:binaryData":{},"f:data":{".":{},"f:key":{}}}`,
+	}, {
+		name: "unsorted keyed list",
+		src: map[string]interface{}{"rules": []interface{}{
+			map[string]interface{}{"name": "z", "value": "1"},
+			map[string]interface{}{"name": "a", "value": "2"},
+			map[string]interface{}{"name": "m", "value": "3"},
+		}},
+		fields: `{"f:rules":{"k:{\"name\":\"a\"}":{".":{},"f:name":{},"f:value":{}},"k:{\"name\":\"m\"}":{".":{},"f:name":{},"f:value":{}},"k:{\"name\":\"z\"}":{".":{},"f:name":{},"f:value":{}}}}`,
+	}, {
+		name:   "unsorted set",
+		src:    map[string]interface{}{"finalizers": []interface{}{"z", "a"}},
+		fields: `{"f:finalizers":{".":{},"v:\"a\"":{},"v:\"z\"":{}}}`,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			src := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Example",
+				"metadata":   map[string]interface{}{"name": "obj"},
+			}}
+			for k, v := range tc.src {
+				src.Object[k] = v
+			}
+			inst := src.DeepCopy()
+			inst.SetManagedFields([]metav1.ManagedFieldsEntry{{
+				Manager:    fieldManager,
+				Operation:  metav1.ManagedFieldsOperationApply,
+				FieldsType: "FieldsV1",
+				FieldsV1:   &metav1.FieldsV1{Raw: []byte(tc.fields)},
+			}})
+			g.Expect(canonical(ownedByHNC(inst), nil)).Should(Equal(canonical(src, nil)))
+		})
+	}
+}
+
+func TestIsImmutableFieldError(t *testing.T) {
+	cmGK := schema.GroupKind{Kind: "ConfigMap"}
+	rbGK := schema.GroupKind{Group: api.RBACGroup, Kind: api.RoleBindingKind}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{{
+		name: "no error",
+	}, {
+		name: "unrelated error",
+		err:  errors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "foo", nil),
+	}, {
+		name: "unrelated invalid field",
+		err:  errors.NewInvalid(cmGK, "foo", field.ErrorList{field.Invalid(field.NewPath("data"), "x", "bad key")}),
+	}, {
+		name: "immutable ConfigMap",
+		err:  errors.NewInvalid(cmGK, "foo", field.ErrorList{field.Forbidden(field.NewPath("data"), "field is immutable when `immutable` is set")}),
+		want: true,
+	}, {
+		name: "unrelated forbidden cause",
+		err:  errors.NewInvalid(schema.GroupKind{Kind: "Service"}, "foo", field.ErrorList{field.Forbidden(field.NewPath("spec", "clusterIP"), "may not be set when type is 'ExternalName'")}),
+	}, {
+		name: "immutable field",
+		err:  errors.NewInvalid(cmGK, "foo", field.ErrorList{field.Invalid(field.NewPath("immutable"), false, "field is immutable")}),
+		want: true,
+	}, {
+		name: "unrelated forbidden field",
+		err:  errors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "foo", fmt.Errorf("no")),
+	}, {
+		name: "invalid roleRef of another type",
+		err:  errors.NewInvalid(cmGK, "foo", field.ErrorList{field.Invalid(field.NewPath("roleRef"), "x", "bad ref")}),
+	}, {
+		name: "RoleBinding roleRef",
+		err:  errors.NewInvalid(rbGK, "foo", field.ErrorList{field.Invalid(field.NewPath("roleRef"), "x", "cannot change roleRef")}),
+		want: true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(isImmutableFieldError(tc.err)).Should(Equal(tc.want))
+		})
+	}
+}
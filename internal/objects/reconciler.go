@@ -17,8 +17,10 @@ package objects
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,11 +28,13 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -55,6 +59,11 @@ const (
 	actionRemove  syncAction = "remove"
 	actionWrite   syncAction = "write"
 	actionNop     syncAction = "no-op"
+	// actionPaused means that the object is out of date but mustn't be touched because propagation
+	// of its source has been paused. No action is taken other than reporting it.
+	actionPaused syncAction = "pause"
+	// actionUpdateRollout means that the rollout status of a source object needs to be updated.
+	actionUpdateRollout syncAction = "update rollout status"
 
 	unknownSourceNamespace = "<unknown-source-namespace>"
 )
@@ -63,6 +72,13 @@ const (
 // a specific GVK in the cluster.
 type namespacedNameSet map[types.NamespacedName]bool
 
+// objectDigest is the digest of a propagated object as of a specific resourceVersion. It's only used
+// in metadata-only mode; see Reconciler.MetadataOnly.
+type objectDigest struct {
+	resourceVersion string
+	digest          [sha256.Size]byte
+}
+
 // Reconciler reconciles generic propagated objects. You must create one for each
 // group/version/kind that needs to be propagated and set its `GVK` field appropriately.
 type Reconciler struct {
@@ -80,6 +96,29 @@ type Reconciler struct {
 	// See more details in the comments of api.SynchronizationMode.
 	Mode api.SynchronizationMode
 
+	// UpdateStrategy is what to do if a propagated object can't be updated because the update would
+	// change an immutable field. See the comments of api.UpdateStrategy. Like Mode, it's only changed
+	// while the forest lock is held.
+	UpdateStrategy api.UpdateStrategy
+
+	// UnpropagatedFields are the paths of any fields that shouldn't be propagated, in addition to the
+	// ones removed by the builtin sanitizers. See SetUnpropagatedFields.
+	UnpropagatedFields []string
+
+	// sanitizersLock protects sanitizers.
+	sanitizersLock sync.RWMutex
+
+	// sanitizers remove the fields that can't be propagated from the objects of this type, including
+	// the UnpropagatedFields. They're applied by canonical().
+	sanitizers []sanitizer
+
+	// MetadataOnly causes this reconciler to only watch and cache the metadata of its objects, which
+	// is usually enough to tell if they need to be reconciled. Full objects are read from the
+	// apiserver only for sources, and for copies whose contents we don't already know. For copies
+	// that we do know, we only keep a digest of their contents (see digest()). This saves a lot of
+	// memory for large types such as Secrets at the cost of more reads from the apiserver.
+	MetadataOnly bool
+
 	// Affected is a channel of event.GenericEvent (see "Watching Channels" in
 	// https://book-v1.book.kubebuilder.io/beyond_basics/controller_watches.html) that is used to
 	// enqueue additional objects that need updating.
@@ -91,6 +130,47 @@ type Reconciler struct {
 
 	// propagatedObjects contains all propagated objects of the GVK handled by this reconciler.
 	propagatedObjects namespacedNameSet
+
+	// apiReader reads objects directly from the apiserver. It's only used in metadata-only mode.
+	apiReader client.Reader
+
+	// digestsLock protects digests.
+	digestsLock sync.Mutex
+
+	// digests contains the digests of the propagated objects handled by this reconciler, if they're
+	// known. It's only used in metadata-only mode.
+	digests map[types.NamespacedName]objectDigest
+
+	// rolloutFailuresLock protects rolloutFailures.
+	rolloutFailuresLock sync.Mutex
+
+	// rolloutFailures contains, for each source with a progressive rollout, the namespaces where its
+	// copy couldn't be updated, and the version of the source that we were trying to write there.
+	// It's only kept in memory, so it's reset when HNC restarts until every copy has been reconciled
+	// again; this is documented in the user guide.
+	rolloutFailures map[types.NamespacedName]map[string]string
+
+	// driftsLock protects drifts.
+	driftsLock sync.Mutex
+
+	// drifts contains the objects that differ from what would be propagated, and the action that would
+	// be taken on each of them. It's only used in the Audit mode.
+	drifts map[types.NamespacedName]syncAction
+
+	// staleLock protects stale.
+	staleLock sync.Mutex
+
+	// stale contains the propagated objects that are out of date because propagation from their
+	// source is paused (see isPaused).
+	stale namespacedNameSet
+
+	// stop cancels the context used to run this reconciler's controller and informer cache. It's
+	// set by SetupWithManager and called by Stop.
+	stop context.CancelFunc
+
+	// stopped is closed once this reconciler has been stopped. After that, nothing is reading from
+	// the Affected channel anymore.
+	stopped <-chan struct{}
 }
 
 type HNCConfigReconcilerType interface {
@@ -144,7 +224,7 @@ func (r *Reconciler) OnChangeNamespace(log logr.Logger, ns *forest.Namespace) {
 			inst.SetName(nnm.Name)
 			inst.SetNamespace(nnm.Namespace)
 			log.V(1).Info("Enqueuing local copy of the ancestor original for reconciliation", "affected", inst.GetName())
-			r.Affected <- event.GenericEvent{Object: inst}
+			r.enqueue(inst)
 		}
 	}()
 }
@@ -165,7 +245,7 @@ func (r *Reconciler) GetMode() api.SynchronizationMode {
 // treated as api.Ignore.
 func GetValidateMode(mode api.SynchronizationMode, log logr.Logger) api.SynchronizationMode {
 	switch mode {
-	case api.Propagate, api.Ignore, api.Remove, api.AllowPropagate:
+	case api.Propagate, api.Ignore, api.Remove, api.AllowPropagate, api.Audit:
 		return mode
 	case "":
 		log.Info("Sync mode is unset; using default 'Propagate'")
@@ -187,6 +267,10 @@ func (r *Reconciler) SetMode(ctx context.Context, log logr.Logger, mode api.Sync
 	}
 	log.Info("Changing sync mode of the object reconciler", "oldMode", oldMode, "newMode", newMode)
 	r.Mode = newMode
+	if oldMode == api.Audit {
+		// Drifts are only tracked in the Audit mode.
+		r.forgetDrifts()
+	}
 	// If the new mode is not "ignore", we need to update objects in the cluster
 	// (e.g., propagate or remove existing objects).
 	if newMode != api.Ignore {
@@ -198,11 +282,104 @@ func (r *Reconciler) SetMode(ctx context.Context, log logr.Logger, mode api.Sync
 	return nil
 }
 
+// SetUpdateStrategy sets the UpdateStrategy field of an object reconciler. The new strategy is used
+// the next time an update fails because of an immutable field.
+func (r *Reconciler) SetUpdateStrategy(log logr.Logger, strategy api.UpdateStrategy) {
+	if strategy == r.UpdateStrategy {
+		return
+	}
+	log.Info("Changing update strategy of the object reconciler", "gvk", r.GVK, "oldStrategy", r.UpdateStrategy, "newStrategy", strategy)
+	r.UpdateStrategy = strategy
+}
+
+// SetUnpropagatedFields sets the paths of any fields that shouldn't be propagated, in addition to the
+// ones removed by the builtin sanitizers, and resyncs all objects if they've changed.
+func (r *Reconciler) SetUnpropagatedFields(ctx context.Context, log logr.Logger, paths []string) error {
+	if equalPaths(r.UnpropagatedFields, paths) {
+		return nil
+	}
+	r.UnpropagatedFields = paths
+	r.setSanitizers()
+	log.Info("Changing unpropagated fields of the object reconciler", "gvk", r.GVK, "paths", paths)
+	if r.Mode == api.Ignore {
+		return nil
+	}
+	return r.enqueueAllObjects(ctx, r.Log)
+}
+
+// setSanitizers sets the sanitizers of this type from the builtin ones and the UnpropagatedFields.
+func (r *Reconciler) setSanitizers() {
+	r.sanitizersLock.Lock()
+	defer r.sanitizersLock.Unlock()
+	r.sanitizers = getSanitizers(r.GVK.GroupKind(), r.UnpropagatedFields)
+}
+
+// getSanitizers returns the sanitizers of this type, to be passed to canonical().
+func (r *Reconciler) getSanitizers() []sanitizer {
+	r.sanitizersLock.RLock()
+	defer r.sanitizersLock.RUnlock()
+	return r.sanitizers
+}
+
+// equalPaths returns true if both lists contain the same paths, in the same order.
+func equalPaths(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// getUpdateStrategy returns the current update strategy, defaulting to api.UpdateStrategyUpdate.
+func (r *Reconciler) getUpdateStrategy() api.UpdateStrategy {
+	r.Forest.Lock()
+	defer r.Forest.Unlock()
+	if r.UpdateStrategy == "" {
+		return api.UpdateStrategyUpdate
+	}
+	return r.UpdateStrategy
+}
+
 // CanPropagate returns true if Propagate mode or AllowPropagate mode is set
 func (r *Reconciler) CanPropagate() bool {
 	return (r.GetMode() == api.Propagate || r.GetMode() == api.AllowPropagate)
 }
 
+// GetNumDriftedObjects returns the number of objects that differ from what would be propagated. It's
+// always zero unless the mode is Audit.
+func (r *Reconciler) GetNumDriftedObjects() int {
+	r.driftsLock.Lock()
+	defer r.driftsLock.Unlock()
+
+	return len(r.drifts)
+}
+
+// GetNumStaleObjects returns the number of objects that are out of date because propagation from
+// their sources is paused.
+func (r *Reconciler) GetNumStaleObjects() int {
+	r.staleLock.Lock()
+	defer r.staleLock.Unlock()
+
+	return len(r.stale)
+}
+
+// setStale records whether the object is out of date because propagation from its source is paused.
+func (r *Reconciler) setStale(inst *unstructured.Unstructured, stale bool) {
+	r.staleLock.Lock()
+	defer r.staleLock.Unlock()
+
+	nnm := types.NamespacedName{Namespace: inst.GetNamespace(), Name: inst.GetName()}
+	if stale {
+		r.stale[nnm] = true
+	} else {
+		delete(r.stale, nnm)
+	}
+}
+
 // GetNumPropagatedObjects returns the number of propagated objects of the GVK handled by this object reconciler.
 func (r *Reconciler) GetNumPropagatedObjects() int {
 	r.propagatedObjectsLock.Lock()
@@ -232,7 +409,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return resp, nil
 	}
 
-	if r.Mode == api.Ignore {
+	if r.Mode == api.Ignore || r.isStopped() {
 		return resp, nil
 	}
 
@@ -240,21 +417,74 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	defer stats.StopObjReconcile(r.GVK)
 
 	// Read the object.
-	inst := &unstructured.Unstructured{}
-	inst.SetGroupVersionKind(r.GVK)
-	inst.SetNamespace(req.Namespace)
-	inst.SetName(req.Name)
-	if err := r.Get(ctx, req.NamespacedName, inst); err != nil {
-		if !errors.IsNotFound(err) {
-			log.Error(err, "Couldn't read")
-			return resp, err
-		}
+	inst, err := r.getObject(ctx, log, req.NamespacedName)
+	if err != nil {
+		log.Error(err, "Couldn't read")
+		return resp, err
 	}
 	log.V(1).Info("Reconciling")
 
 	// Sync with the forest and perform any required actions.
 	actions, srcInst := r.syncWithForest(log, inst)
-	return resp, r.operate(ctx, log, actions, inst, srcInst)
+	err = r.operate(ctx, log, actions, inst, srcInst)
+	if _, ok := err.(noRetryError); ok {
+		// The error has already been reported via events, and retrying won't help.
+		return resp, nil
+	}
+	if err == nil {
+		// If this is a source that's being rolled out progressively, come back when it's time for the
+		// next stage.
+		resp.RequeueAfter = rolloutRequeueAfter(inst, r.getSanitizers())
+	}
+	return resp, err
+}
+
+// getObject reads the object with the given name. If the object doesn't exist, it returns an empty
+// object with only the GVK, name and namespace set.
+//
+// In metadata-only mode, if the object is a propagated copy whose digest we already know, the
+// returned object only has its metadata set. Otherwise, the full object is read directly from the
+// apiserver, since it isn't cached.
+func (r *Reconciler) getObject(ctx context.Context, log logr.Logger, nnm types.NamespacedName) (*unstructured.Unstructured, error) {
+	inst := &unstructured.Unstructured{}
+	inst.SetGroupVersionKind(r.GVK)
+	inst.SetNamespace(nnm.Namespace)
+	inst.SetName(nnm.Name)
+
+	if !r.MetadataOnly {
+		if err := r.Get(ctx, nnm, inst); err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+		return inst, nil
+	}
+
+	pom := &metav1.PartialObjectMetadata{}
+	pom.SetGroupVersionKind(r.GVK)
+	if err := r.Get(ctx, nnm, pom); err != nil {
+		if errors.IsNotFound(err) {
+			return inst, nil
+		}
+		return nil, err
+	}
+	if hasPropagatedLabel(pom) && r.hasDigest(nnm, pom.GetResourceVersion()) {
+		meta, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&pom.ObjectMeta)
+		if err != nil {
+			return nil, err
+		}
+		inst.Object["metadata"] = meta
+		return inst, nil
+	}
+
+	log.V(1).Info("Reading full object from the apiserver")
+	if err := r.apiReader.Get(ctx, nnm, inst); err != nil {
+		if errors.IsNotFound(err) {
+			// It was deleted since the cache was updated.
+			return inst, nil
+		}
+		return nil, err
+	}
+	r.recordDigest(inst)
+	return inst, nil
 }
 
 // syncWithForest syncs the object instance with the in-memory forest. It returns the action to take on
@@ -267,19 +497,30 @@ func (r *Reconciler) syncWithForest(log logr.Logger, inst *unstructured.Unstruct
 	r.Forest.Lock()
 	defer r.Forest.Unlock()
 
+	// If this reconciler was stopped while we were waiting for the lock, it's been removed from the
+	// forest, so don't add any of its objects back.
+	if r.isStopped() {
+		return actionNop, nil
+	}
+
 	// If this namespace isn't ready to be synced (or is never synced), early exit. We'll be called
 	// again if this changes.
 	if r.skipNamespace(log, inst) {
+		// If the object is being deleted, it can't be drifted or stale anymore.
+		r.forgetDrift(types.NamespacedName{Namespace: inst.GetNamespace(), Name: inst.GetName()})
+		r.setStale(inst, false)
 		return actionNop, nil
 	}
 
 	// If the object's missing and we know how to handle it, return early.
 	if missingAction := r.syncMissingObject(log, inst); missingAction != actionUnknown {
+		r.setStale(inst, false)
 		return missingAction, nil
 	}
 
 	// Update the forest and get the intended action.
 	action, srcInst := r.syncObject(log, inst)
+	r.setStale(inst, action == actionPaused)
 
 	// If the namespace has a critical condition, we shouldn't actually take any action, regardless of
 	// what we'd _like_ to do. We still needed to sync the forest since we want to know when objects
@@ -290,6 +531,11 @@ func (r *Reconciler) syncWithForest(log logr.Logger, inst *unstructured.Unstruct
 		return actionNop, nil
 	}
 
+	// In the Audit mode, we only report what we would have done.
+	if r.Mode == api.Audit {
+		return r.syncAudit(log, inst, srcInst, action), nil
+	}
+
 	return action, srcInst
 }
 
@@ -322,9 +568,12 @@ func (r *Reconciler) syncMissingObject(log logr.Logger, inst *unstructured.Unstr
 	ns := r.Forest.Get(inst.GetNamespace())
 	// If it's a source, it must have been deleted. Update the forest and enqueue all its
 	// descendants, but there's nothing else to do.
-	if ns.HasSourceObject(r.GVK, inst.GetName()) {
+	if src := ns.GetSourceObject(r.GVK, inst.GetName()); src != nil {
 		ns.DeleteSourceObject(r.GVK, inst.GetName())
 		r.enqueueDescendants(log, inst, "source object is missing and must have been deleted")
+		if isPaused(src) {
+			r.enqueuePropagatedCopies(log, inst, "paused source object is missing and must have been deleted")
+		}
 		return actionNop
 	}
 
@@ -369,12 +618,12 @@ func (r *Reconciler) syncObject(log logr.Logger, inst *unstructured.Unstructured
 
 	// If the object should be propagated, we will sync it as an propagated object.
 	if yes, srcInst := r.shouldSyncAsPropagated(log, inst); yes {
-		return r.syncPropagated(inst, srcInst)
+		return r.syncPropagated(log, inst, srcInst)
 	}
 
 	r.syncSource(log, inst)
-	// No action needs to take on source objects.
-	return actionNop, nil
+	// The only action we ever take on source objects is to update the status of their rollouts.
+	return r.syncRollout(log, inst)
 }
 
 // shouldSyncAsPropagated returns true and the source object if this object
@@ -395,7 +644,7 @@ func (r *Reconciler) shouldSyncAsPropagated(log logr.Logger, inst *unstructured.
 	// If there's a conflicting source in the ancestors (excluding itself) and the
 	// the type has 'Propagate' mode or 'AllowPropagate' mode, the object will be overwritten.
 	mode := r.Forest.GetTypeSyncer(r.GVK).GetMode()
-	if mode == api.Propagate || mode == api.AllowPropagate {
+	if mode == api.Propagate || mode == api.AllowPropagate || mode == api.Audit {
 		if srcInst != nil {
 			log.Info("Conflicting object found in ancestors namespace; will overwrite this object", "conflictingAncestor", srcInst.GetNamespace())
 			return true, srcInst
@@ -428,7 +677,13 @@ func (r *Reconciler) getTopSourceToPropagate(log logr.Logger, inst *unstructured
 
 // syncPropagated will determine whether to delete the obsolete copy or overwrite it with the source.
 // Or do nothing if it remains the same as the source object.
-func (r *Reconciler) syncPropagated(inst, srcInst *unstructured.Unstructured) (syncAction, *unstructured.Unstructured) {
+func (r *Reconciler) syncPropagated(log logr.Logger, inst, srcInst *unstructured.Unstructured) (syncAction, *unstructured.Unstructured) {
+	// If propagation has been paused, leave this object exactly as it is - even if it's out of date,
+	// or its source should no longer be propagated here at all.
+	if pausedSrc := r.getPausedSource(log, inst, srcInst); pausedSrc != nil {
+		return r.syncPaused(log, inst, srcInst, pausedSrc)
+	}
+
 	ns := r.Forest.Get(inst.GetNamespace())
 	// Delete this local source object from the forest if it exists. (This could
 	// only happen when we are trying to overwrite a conflicting source).
@@ -448,8 +703,16 @@ func (r *Reconciler) syncPropagated(inst, srcInst *unstructured.Unstructured) (s
 	// source instance. Note that DeepEqual could return `true` even if the object doesn't exist if
 	// the source object is trivial (e.g. a completely empty ConfigMap).
 	if !exists ||
-		!reflect.DeepEqual(canonical(inst), canonical(srcInst)) ||
+		!r.matchesSource(inst, srcInst) ||
 		inst.GetLabels()[api.LabelInheritedFrom] != srcInst.GetNamespace() {
+		// If the source is being rolled out progressively, leave the copy alone until the rollout
+		// reaches this namespace.
+		if r.holdForRollout(log, srcInst, inst.GetNamespace()) {
+			if exists && hasPropagatedLabel(inst) {
+				r.recordPropagatedObject(inst.GetNamespace(), inst.GetName())
+			}
+			return actionNop, nil
+		}
 		metadata.SetLabel(inst, api.LabelInheritedFrom, srcInst.GetNamespace())
 		return actionWrite, srcInst
 	}
@@ -463,6 +726,71 @@ func (r *Reconciler) syncPropagated(inst, srcInst *unstructured.Unstructured) (s
 	return actionNop, nil
 }
 
+// getPausedSource returns the source whose propagation to this object has been paused, if any. If
+// the object is an existing copy, this is the source that it was copied from (which may no longer be
+// the source that would be propagated here); otherwise, it's the source that would be propagated.
+func (r *Reconciler) getPausedSource(log logr.Logger, inst, srcInst *unstructured.Unstructured) *unstructured.Unstructured {
+	src := srcInst
+	exists := inst.GetCreationTimestamp() != metav1.Time{}
+	if exists && hasPropagatedLabel(inst) {
+		src = r.Forest.Get(inst.GetLabels()[api.LabelInheritedFrom]).GetSourceObject(r.GVK, inst.GetName())
+	}
+	if src == nil {
+		return nil
+	}
+
+	paused, err := selectors.GetPaused(src)
+	if err != nil {
+		// This should have been caught by the webhook, so just propagate as usual.
+		log.Error(err, "Cannot determine whether propagation is paused")
+		r.EventRecorder.Event(src, "Warning", api.EventCannotParseSelector, err.Error())
+		return nil
+	}
+	if !paused {
+		return nil
+	}
+	return src
+}
+
+// syncPaused determines whether an object whose source has been paused is up to date. If so, there's
+// nothing to do; otherwise, it returns actionPaused so that the stale object can be reported.
+func (r *Reconciler) syncPaused(log logr.Logger, inst, srcInst, pausedSrc *unstructured.Unstructured) (syncAction, *unstructured.Unstructured) {
+	exists := inst.GetCreationTimestamp() != metav1.Time{}
+	if exists && hasPropagatedLabel(inst) {
+		// It's still a propagated object even if it's stale.
+		r.recordPropagatedObject(inst.GetNamespace(), inst.GetName())
+	}
+
+	// The object is up to date if it's the same as the source that would be propagated here if
+	// propagation weren't paused.
+	if exists && srcInst == pausedSrc &&
+		inst.GetLabels()[api.LabelInheritedFrom] == srcInst.GetNamespace() &&
+		r.matchesSource(inst, srcInst) {
+		return actionNop, nil
+	}
+
+	log.V(1).Info("Propagation is paused; leaving stale object alone", "pausedSource", pausedSrc.GetNamespace())
+	return actionPaused, pausedSrc
+}
+
+// matchesSource returns true if the copy has the same canonical form as the source.
+func (r *Reconciler) matchesSource(inst, srcInst *unstructured.Unstructured) bool {
+	ss := r.getSanitizers()
+	if !r.MetadataOnly {
+		// Only compare the fields that HNC owns, since other controllers are allowed to add fields to
+		// the copy.
+		return reflect.DeepEqual(canonical(ownedByHNC(inst), ss), canonical(srcInst, ss))
+	}
+
+	// In metadata-only mode, getObject has always recorded the digest of any copy that exists (unless
+	// it was deleted in the meantime, in which case there's no harm trying to rewrite it).
+	nnm := types.NamespacedName{Namespace: inst.GetNamespace(), Name: inst.GetName()}
+	r.digestsLock.Lock()
+	d, ok := r.digests[nnm]
+	r.digestsLock.Unlock()
+	return ok && d.digest == digest(srcInst, ss)
+}
+
 // syncSource updates the copy in the forest with the current source object. We
 // will enqueue all the descendants if the source can be propagated. The
 // propagation exceptions will be checked when reconciling the (enqueued)
@@ -474,10 +802,23 @@ func (r *Reconciler) syncSource(log logr.Logger, src *unstructured.Unstructured)
 	// source objects in the forest to see if a mode change is allowed.
 	ns := r.Forest.Get(src.GetNamespace())
 
+	wasPaused := isPaused(ns.GetSourceObject(r.GVK, src.GetName()))
 	ns.SetSourceObject(cleanSource(src))
 
 	// Enqueue propagated copies for this possibly deleted source
 	r.enqueueDescendants(log, src, "modified source object")
+	if wasPaused && !isPaused(src) {
+		r.enqueuePropagatedCopies(log, src, "unpaused source object")
+	}
+}
+
+// isPaused returns true if the source object exists and its propagation has been paused.
+func isPaused(src *unstructured.Unstructured) bool {
+	if src == nil {
+		return false
+	}
+	paused, _ := selectors.GetPaused(src)
+	return paused
 }
 
 // cleanSource creates a sanitized version of the object to store in the forest. In particular, it
@@ -523,10 +864,42 @@ func (r *Reconciler) enqueueDescendants(log logr.Logger, src *unstructured.Unstr
 	}
 	log.V(1).Info("Enqueuing descendant objects", "reason", reason)
 	for _, ns := range sns.DescendantNames() {
-		dc := canonical(src)
+		dc := canonical(src, r.getSanitizers())
 		dc.SetNamespace(ns)
 		log.V(1).Info("... enqueuing descendant copy", "affected", ns+"/"+src.GetName(), "reason", reason)
-		r.Affected <- event.GenericEvent{Object: dc}
+		r.enqueue(dc)
+	}
+}
+
+// enqueuePropagatedCopies enqueues all the propagated objects with the same name as the source,
+// whether or not they're in its descendants. While propagation is paused, copies are left alone
+// even if they're no longer in the source's descendants (e.g. if the hierarchy has changed), so
+// this is used to catch up with any changes when propagation is resumed.
+func (r *Reconciler) enqueuePropagatedCopies(log logr.Logger, src *unstructured.Unstructured, reason string) {
+	r.propagatedObjectsLock.Lock()
+	nnms := []types.NamespacedName{}
+	for nnm := range r.propagatedObjects {
+		if nnm.Name == src.GetName() {
+			nnms = append(nnms, nnm)
+		}
+	}
+	r.propagatedObjectsLock.Unlock()
+
+	for _, nnm := range nnms {
+		inst := canonical(src, r.getSanitizers())
+		inst.SetNamespace(nnm.Namespace)
+		log.V(1).Info("... enqueuing propagated copy", "affected", nnm.String(), "reason", reason)
+		r.enqueue(inst)
+	}
+}
+
+// enqueue adds the object to the Affected channel so that it gets reconciled. If the reconciler
+// has been stopped, the object is dropped instead, since nobody will ever read it from the channel
+// and we may be holding the forest lock.
+func (r *Reconciler) enqueue(inst *unstructured.Unstructured) {
+	select {
+	case r.Affected <- event.GenericEvent{Object: inst}:
+	case <-r.stopped:
 	}
 }
 
@@ -543,20 +916,36 @@ func (r *Reconciler) enqueueDescendants(log logr.Logger, src *unstructured.Unstr
 // Since this function is called while the forest lock is held, everything it does is launched in a
 // new goroutine to avoid any possible calls to the apiserver while the lock is held.
 func (r *Reconciler) enqueueExistingObjects(ctx context.Context, log logr.Logger, nsnm string) error {
-	ul := &unstructured.UnstructuredList{}
-	ul.SetGroupVersionKind(r.GVK)
-	ul.SetKind(ul.GetKind() + "List")
-	if err := r.List(ctx, ul, client.InNamespace(nsnm)); err != nil {
-		return err
+	var nms []string
+	if r.MetadataOnly {
+		l := &metav1.PartialObjectMetadataList{}
+		l.SetGroupVersionKind(r.GVK.GroupVersion().WithKind(r.GVK.Kind + "List"))
+		if err := r.List(ctx, l, client.InNamespace(nsnm)); err != nil {
+			return err
+		}
+		for _, pom := range l.Items {
+			nms = append(nms, pom.GetName())
+		}
+	} else {
+		ul := &unstructured.UnstructuredList{}
+		ul.SetGroupVersionKind(r.GVK)
+		ul.SetKind(ul.GetKind() + "List")
+		if err := r.List(ctx, ul, client.InNamespace(nsnm)); err != nil {
+			return err
+		}
+		for _, inst := range ul.Items {
+			nms = append(nms, inst.GetName())
+		}
 	}
 
-	for _, inst := range ul.Items {
-		// We don't need the entire canonical object here but only its metadata.
-		// Using canonical copy is the easiest way to get an object with its metadata set.
-		co := canonical(&inst)
-		co.SetNamespace(inst.GetNamespace())
-		log.V(1).Info("Enqueuing existing object for reconciliation", "affected", co.GetName())
-		r.Affected <- event.GenericEvent{Object: co}
+	for _, nm := range nms {
+		// We only need enough of the object to enqueue it.
+		inst := &unstructured.Unstructured{}
+		inst.SetGroupVersionKind(r.GVK)
+		inst.SetNamespace(nsnm)
+		inst.SetName(nm)
+		log.V(1).Info("Enqueuing existing object for reconciliation", "affected", nm)
+		r.enqueue(inst)
 	}
 
 	return nil
@@ -567,12 +956,15 @@ func (r *Reconciler) operate(ctx context.Context, log logr.Logger, act syncActio
 	var err error
 
 	switch act {
-	case actionNop:
+	case actionNop, actionPaused:
 		// nop
 	case actionRemove:
 		err = r.deleteObject(ctx, log, inst)
 	case actionWrite:
 		err = r.writeObject(ctx, log, inst, srcInst)
+		r.recordRolloutResult(srcInst, inst, err)
+	case actionUpdateRollout:
+		err = r.updateRolloutStatus(ctx, log, srcInst)
 	default: // this should never, ever happen. But if it does, try to make a very obvious error message.
 		if act == "" {
 			act = actionUnknown
@@ -602,63 +994,34 @@ func (r *Reconciler) deleteObject(ctx context.Context, log logr.Logger, inst *un
 	// Remove the propagated object from the map because we are confident that the object was successfully deleted
 	// on the apiserver.
 	r.recordRemovedObject(inst.GetNamespace(), inst.GetName())
+	r.forgetDigest(inst.GetNamespace(), inst.GetName())
 	return nil
 }
 
 func (r *Reconciler) writeObject(ctx context.Context, log logr.Logger, inst, srcInst *unstructured.Unstructured) error {
-	// The object exists if CreationTimestamp is set. This flag enables us to have only 1 API call.
+	// The object exists if CreationTimestamp is set. This is only used for logging, since applying the
+	// object creates it if it doesn't exist.
 	exist := inst.GetCreationTimestamp() != metav1.Time{}
 	ns := inst.GetNamespace()
-	// Get current ResourceVersion, required for updates for newer API objects (including custom resources).
-	rv := inst.GetResourceVersion()
 
 	// Overwrite the propagated copy with the source, then restore all essential properties of the copy.
-	inst = canonical(srcInst)
+	// We don't need to set the resourceVersion since the copy is applied, not updated. Any fields that
+	// other controllers have added to the copy are left alone.
+	inst = canonical(srcInst, r.getSanitizers())
 	inst.SetNamespace(ns)
-	// We should set the resourceVersion when updating the object, which is required by k8s.
-	// for more details see https://github.com/kubernetes-sigs/hierarchical-namespaces/issues/53.
-	inst.SetResourceVersion(rv)
 	metadata.SetLabel(inst, api.LabelInheritedFrom, srcInst.GetNamespace())
 	log.V(1).Info("Writing", "dst", inst.GetNamespace(), "origin", srcInst.GetNamespace())
 
-	var err error = nil
 	stats.WriteObject(r.GVK)
 	if exist {
 		log.Info("Updating propagated object")
-		err = r.Update(ctx, inst)
-		// RoleBindings can't have their Roles changed after they're created
-		// (see  https://github.com/kubernetes-sigs/hierarchical-namespaces/issues/798).
-		// If an RB was quickly delete and re-created in an ancestor namespace
-		// - fast enough that by the time that HNC notices, the new RB exists; or
-		// if there's a change to the RBs when HNC isn't running - HNC could see
-		// it as an update (not a delete + create) and attempt to update the RBs in
-		// all descendant namespaces, and this will fail. In order to handle this
-		// case, we try to delete and re-create the rolebinding here
-
-		// We don't apply this logic to other objects because if another object has an
-		// ownerReference pointing to the object we're deleting, it could be deleted as
-		// well, which is undesirable.
-
-		// The error type is 'Invalid' after I tested it out with different error types
-		// from https://godoc.org/k8s.io/apimachinery/pkg/api/errors
-		group := inst.GroupVersionKind().Group
-		if err != nil && errors.IsInvalid(err) && inst.GetKind() == "RoleBinding" && group == "rbac.authorization.k8s.io" {
-			// Log this error because we're about to throw it away.
-			log.Error(err, "Couldn't update propagated object; will try to delete and recreate instead")
-			if err = r.Delete(ctx, inst); err == nil {
-				err = r.Create(ctx, inst)
-				if err != nil {
-					log.Info("Couldn't recreate propagated object after deleting it") // error is handles below
-				} else {
-					log.Info("Successfully recreated propagated object")
-				}
-			} else {
-				log.Info("Couldn't delete propagated object that we couldn't update") // error is handles below
-			}
-		}
 	} else {
 		log.Info("Propagating object")
-		err = r.Create(ctx, inst)
+	}
+	err := r.apply(ctx, inst)
+	if exist && isImmutableFieldError(err) {
+		stats.ImmutableObjectUpdate(r.GVK)
+		err = r.handleImmutableUpdate(ctx, log, inst, err)
 	}
 	if err != nil {
 		// Don't log the error since controller-runtime will do it for us
@@ -668,9 +1031,60 @@ func (r *Reconciler) writeObject(ctx context.Context, log logr.Logger, inst, src
 	// Add the object to the map if it does not exist because we are confident that the object was updated/created
 	// successfully on the apiserver.
 	r.recordPropagatedObject(inst.GetNamespace(), inst.GetName())
+	// The client has updated inst with the response from the apiserver, so we know exactly what the
+	// new version of the object looks like and won't have to read it again.
+	r.recordDigest(inst)
 	return nil
 }
 
+// handleImmutableUpdate is called when the propagated copy couldn't be updated because the update
+// would change an immutable field, and follows the reconciler's update strategy. It returns the
+// error, if any, that should be reported to the caller.
+func (r *Reconciler) handleImmutableUpdate(ctx context.Context, log logr.Logger, inst *unstructured.Unstructured, err error) error {
+	switch r.getUpdateStrategy() {
+	case api.UpdateStrategyRecreate:
+		// We don't do this for all types by default because if another object has an ownerReference
+		// pointing to the object we're deleting, it could be deleted as well, which is undesirable.
+		// Log this error because we're about to throw it away.
+		log.Error(err, "Couldn't update propagated object; will try to delete and recreate instead")
+		if err := r.Delete(ctx, inst); err != nil {
+			log.Info("Couldn't delete propagated object that we couldn't update") // error is handled by the caller
+			return err
+		}
+		if err := r.apply(ctx, inst); err != nil {
+			log.Info("Couldn't recreate propagated object after deleting it") // error is handled by the caller
+			return err
+		}
+		log.Info("Successfully recreated propagated object")
+		stats.RecreateObject(r.GVK)
+		msg := fmt.Sprintf("Recreated object from source namespace %q since its immutable fields had changed.", inst.GetLabels()[api.LabelInheritedFrom])
+		r.EventRecorder.Event(inst, "Normal", api.EventRecreatedObject, msg)
+		return nil
+
+	case api.UpdateStrategyFail:
+		return noRetryError{fmt.Errorf("%w (not retrying since the update strategy is %q)", err, api.UpdateStrategyFail)}
+
+	default:
+		return err
+	}
+}
+
+// noRetryError is an error that won't go away by retrying the reconciliation, such as an update
+// that failed because of the Fail update strategy. It's still reported via events.
+type noRetryError struct {
+	error
+}
+
+// apply creates or updates the propagated copy with server-side apply, so that HNC only owns the
+// fields that it copies from the source. Ownership is forced, since the source always wins over any
+// other controller that has set the same fields on the copy.
+//
+// Note that copies written by older versions of HNC, which used regular updates, may keep fields that
+// are later removed from the source, since HNC doesn't own those fields on the apiserver.
+func (r *Reconciler) apply(ctx context.Context, inst *unstructured.Unstructured) error {
+	return r.Patch(ctx, inst, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership)
+}
+
 // generateEvents is called when the reconciler has performed all necessary
 // actions and knows if they've succeeded or failed. If a source should not be
 // propagated or there was a failure, generate "Warning" events.
@@ -681,6 +1095,21 @@ func (r *Reconciler) generateEvents(log logr.Logger, srcInst, inst *unstructured
 		log.Info("Generating event", "type", api.EventCannotPropagate, "msg", "Objects with finalizers cannot be propagated")
 		r.EventRecorder.Event(inst, "Warning", api.EventCannotPropagate, "Objects with finalizers cannot be propagated")
 
+	case act == actionUpdateRollout:
+		// This only affects the source; any errors are logged and retried by controller-runtime.
+		return
+
+	case act == actionPaused:
+		// Report that the object is stale on both the object (if it exists) and its source, since
+		// there's no other way to tell that the source's changes haven't been propagated yet.
+		if inst.GetCreationTimestamp() != (metav1.Time{}) {
+			msg := fmt.Sprintf("Propagation from source namespace %q is paused; this object may be out of date.", srcInst.GetNamespace())
+			r.EventRecorder.Event(inst, "Normal", api.EventPropagationPaused, msg)
+		}
+		msg := fmt.Sprintf("Propagation is paused; the object in destination namespace %q may be out of date.", inst.GetNamespace())
+		log.Info("Generating event", "srcNS", srcInst.GetNamespace(), "type", api.EventPropagationPaused, "msg", msg)
+		r.EventRecorder.Event(srcInst, "Normal", api.EventPropagationPaused, msg)
+
 	case errors.IsAlreadyExists(err):
 		// This can happen if the same newly-propagated object is enqueued twice in quick succession;
 		// the second goroutine will try to create it but it will already exist. We should definitely
@@ -707,7 +1136,7 @@ func (r *Reconciler) generateEvents(log logr.Logger, srcInst, inst *unstructured
 }
 
 // hasPropagatedLabel returns true if "api.LabelInheritedFrom" label is set.
-func hasPropagatedLabel(inst *unstructured.Unstructured) bool {
+func hasPropagatedLabel(inst metav1.Object) bool {
 	labels := inst.GetLabels()
 	if labels == nil {
 		// this cannot be a copy
@@ -792,15 +1221,64 @@ func (r *Reconciler) recordRemovedObject(namespace, name string) {
 	}
 }
 
+// recordDigest records the digest of this version of the object if we're in metadata-only mode, so
+// that we won't need to read it from the apiserver again unless it changes.
+func (r *Reconciler) recordDigest(inst *unstructured.Unstructured) {
+	if !r.MetadataOnly {
+		return
+	}
+	r.digestsLock.Lock()
+	defer r.digestsLock.Unlock()
+
+	// Like matchesSource, only include the fields that HNC owns in the digest.
+	nnm := types.NamespacedName{Namespace: inst.GetNamespace(), Name: inst.GetName()}
+	r.digests[nnm] = objectDigest{resourceVersion: inst.GetResourceVersion(), digest: digest(ownedByHNC(inst), r.getSanitizers())}
+}
+
+// hasDigest returns true if we know the digest of the given version of the object.
+func (r *Reconciler) hasDigest(nnm types.NamespacedName, rv string) bool {
+	r.digestsLock.Lock()
+	defer r.digestsLock.Unlock()
+
+	d, ok := r.digests[nnm]
+	return ok && d.resourceVersion == rv
+}
+
+// forgetDigest removes the digest of an object that no longer exists.
+func (r *Reconciler) forgetDigest(namespace, name string) {
+	r.digestsLock.Lock()
+	defer r.digestsLock.Unlock()
+
+	delete(r.digests, types.NamespacedName{Namespace: namespace, Name: name})
+}
+
 func hasFinalizers(inst *unstructured.Unstructured) bool {
 	return len(inst.GetFinalizers()) != 0
 }
 
-func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, maxReconciles int) error {
+// SetupWithManager creates and starts the controller for this reconciler. Unlike the other HNC
+// reconcilers, each object reconciler has its own controller and informer cache rather than sharing
+// the manager's, since controller-runtime has no way to remove a controller or an informer from a
+// running manager. This allows Stop to shut both of them down (and release all the objects they've
+// cached) if the type is removed from the HNCConfiguration. They also stop when ctx is done.
+//
+// In metadata-only mode, the informer only watches the metadata of the objects, and full objects
+// are read directly from the apiserver instead.
+func (r *Reconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, maxReconciles int) error {
 	r.propagatedObjects = namespacedNameSet{}
-	target := &unstructured.Unstructured{}
-	target.SetGroupVersionKind(r.GVK)
+	r.digests = map[types.NamespacedName]objectDigest{}
+	r.rolloutFailures = map[types.NamespacedName]map[string]string{}
+	r.drifts = map[types.NamespacedName]syncAction{}
+	r.stale = namespacedNameSet{}
+	r.setSanitizers()
+	r.apiReader = mgr.GetAPIReader()
+	var target client.Object = &unstructured.Unstructured{}
+	if r.MetadataOnly {
+		target = &metav1.PartialObjectMetadata{}
+	}
+	target.GetObjectKind().SetGroupVersionKind(r.GVK)
 	opts := controller.Options{
+		Reconciler:              r,
 		MaxConcurrentReconciles: maxReconciles,
 
 		// Unlike the other HNC reconcilers, the object reconciler can easily be affected by objects
@@ -815,9 +1293,74 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, maxReconciles int) error
 		// by users should still be about 5s though.
 		RateLimiter: workqueue.NewItemExponentialFailureRateLimiter(250*time.Millisecond, 10*time.Second),
 	}
-	return ctrl.NewControllerManagedBy(mgr).
-		For(target).
-		Watches(&source.Channel{Source: r.Affected}, &handler.EnqueueRequestForObject{}).
-		WithOptions(opts).
-		Complete(r)
+
+	objCache, err := cache.New(mgr.GetConfig(), cache.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()})
+	if err != nil {
+		return fmt.Errorf("cannot create cache: %w", err)
+	}
+	// Read objects from our own cache, but keep writing them via the manager's client.
+	r.Client, err = client.NewDelegatingClient(client.NewDelegatingClientInput{
+		CacheReader:       objCache,
+		Client:            r.Client,
+		CacheUnstructured: true,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create client: %w", err)
+	}
+
+	// Use the same name that the controller builder would have picked.
+	c, err := controller.NewUnmanaged(strings.ToLower(r.GVK.Kind), mgr, opts)
+	if err != nil {
+		return err
+	}
+	if err := c.Watch(source.NewKindWithCache(target, objCache), &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	affected := &source.Channel{Source: r.Affected}
+	// The controller would otherwise inject the manager's stop channel, which would keep the channel
+	// source running after this reconciler's been stopped.
+	if err := affected.InjectStopChannel(ctx.Done()); err != nil {
+		cancel()
+		return err
+	}
+	if err := c.Watch(affected, &handler.EnqueueRequestForObject{}); err != nil {
+		cancel()
+		return err
+	}
+	r.stop = cancel
+	r.stopped = ctx.Done()
+
+	go func() {
+		if err := objCache.Start(ctx); err != nil {
+			r.Log.Error(err, "Informer cache exited with an error")
+		}
+	}()
+	go func() {
+		if err := c.Start(ctx); err != nil {
+			r.Log.Error(err, "Controller exited with an error")
+		}
+	}()
+	return nil
+}
+
+// Stop shuts down the controller and informer cache started by SetupWithManager. Once all pending
+// reconciliations finish, nothing refers to the cached objects anymore, so their memory can be
+// reclaimed. The caller should also remove this reconciler from the forest.
+func (r *Reconciler) Stop() {
+	if r.stop == nil {
+		return
+	}
+	r.Log.Info("Stopping object reconciler", "gvk", r.GVK)
+	r.stop()
+}
+
+// isStopped returns true if Stop has been called.
+func (r *Reconciler) isStopped() bool {
+	select {
+	case <-r.stopped:
+		return true
+	default:
+		return false
+	}
 }
@@ -3,6 +3,7 @@ package objects_test
 import (
 	"context"
 	"fmt"
+	"os"
 	"testing"
 	"time"
 
@@ -10,7 +11,9 @@ import (
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
@@ -423,6 +426,49 @@ var _ = Describe("Basic propagation", func() {
 		Expect(ObjectInheritedFrom(ctx, "configmaps", barName, "foo-config")).Should(Equal(fooName))
 	})
 
+	It("should recreate copies whose immutable fields have changed if the update strategy is Recreate", func() {
+		SetParent(ctx, barName, fooName)
+		SetResourceInHNCConfigWithOffset(ctx, 0, api.ResourceSpec{Resource: "configmaps", Mode: api.Propagate, UpdateStrategy: api.UpdateStrategyRecreate})
+		makeImmutableConfigMap(ctx, fooName, "foo-config", "v1")
+		Eventually(configMapValue(ctx, barName, "foo-config")).Should(Equal("v1"))
+
+		replaceImmutableConfigMapWhileIgnored(ctx, fooName, "foo-config", "v2", api.UpdateStrategyRecreate)
+
+		Eventually(configMapValue(ctx, barName, "foo-config")).Should(Equal("v2"))
+		Eventually(eventFor(ctx, barName, "foo-config", api.EventRecreatedObject)).ShouldNot(BeEmpty())
+	})
+
+	It("should leave copies whose immutable fields have changed alone if the update strategy is Fail", func() {
+		SetParent(ctx, barName, fooName)
+		SetResourceInHNCConfigWithOffset(ctx, 0, api.ResourceSpec{Resource: "configmaps", Mode: api.Propagate, UpdateStrategy: api.UpdateStrategyFail})
+		makeImmutableConfigMap(ctx, fooName, "foo-config", "v1")
+		Eventually(configMapValue(ctx, barName, "foo-config")).Should(Equal("v1"))
+
+		replaceImmutableConfigMapWhileIgnored(ctx, fooName, "foo-config", "v2", api.UpdateStrategyFail)
+
+		Eventually(eventFor(ctx, barName, "foo-config", api.EventCannotUpdate)).ShouldNot(BeEmpty())
+		Consistently(configMapValue(ctx, barName, "foo-config")).Should(Equal("v1"))
+	})
+
+	It("should propagate Services without their cluster IPs", func() {
+		SetParent(ctx, barName, fooName)
+		AddToHNCConfig(ctx, "", "services", api.Propagate)
+		svc := &corev1.Service{}
+		svc.Namespace = fooName
+		svc.Name = "foo-svc"
+		svc.Spec.Ports = []corev1.ServicePort{{Port: 80}}
+		Expect(K8sClient.Create(ctx, svc)).Should(Succeed())
+
+		// The copy should be allocated its own cluster IP.
+		cp := &corev1.Service{}
+		Eventually(func() error {
+			return K8sClient.Get(ctx, types.NamespacedName{Namespace: barName, Name: "foo-svc"}, cp)
+		}).Should(Succeed())
+		Expect(cp.Spec.ClusterIP).ShouldNot(BeEmpty())
+		Expect(cp.Spec.ClusterIP).ShouldNot(Equal(svc.Spec.ClusterIP))
+		Expect(cp.Labels[api.LabelInheritedFrom]).Should(Equal(fooName))
+	})
+
 	It("should not propagate builtin exclusions by name", func() {
 		SetParent(ctx, barName, fooName)
 		MakeObject(ctx, "configmaps", fooName, "istio-ca-root-cert")
@@ -548,6 +594,69 @@ var _ = Describe("Basic propagation", func() {
 		Eventually(isModified(ctx, bazName, "foo-role")).Should(BeTrue())
 	})
 
+	It("should leave propagated copies alone while propagation is paused", func() {
+		SetParent(ctx, barName, fooName)
+		Eventually(HasObject(ctx, api.RoleResource, barName, "foo-role")).Should(BeTrue())
+
+		// Pause propagation and modify the source. The copy should become stale.
+		UpdateObjectWithAnnotations(ctx, api.RoleResource, fooName, "foo-role", map[string]string{api.AnnotationPaused: "true"})
+		modifyRole(ctx, fooName, "foo-role")
+		Eventually(eventFor(ctx, fooName, "foo-role", api.EventPropagationPaused)).ShouldNot(Equal(""))
+		Eventually(eventFor(ctx, barName, "foo-role", api.EventPropagationPaused)).ShouldNot(Equal(""))
+		Consistently(isModified(ctx, barName, "foo-role")).Should(BeFalse())
+		Eventually(numStaleRoles).Should(Equal(1))
+
+		// Copies shouldn't be deleted even if their source no longer applies to them, and new ones
+		// shouldn't be created either.
+		SetParent(ctx, barName, "")
+		SetParent(ctx, bazName, fooName)
+		Consistently(HasObject(ctx, api.RoleResource, barName, "foo-role")).Should(BeTrue())
+		Consistently(HasObject(ctx, api.RoleResource, bazName, "foo-role")).Should(BeFalse())
+
+		// Unpause propagation and verify that everything catches up.
+		UpdateObjectWithAnnotations(ctx, api.RoleResource, fooName, "foo-role", map[string]string{api.AnnotationPaused: "false"})
+		Eventually(HasObject(ctx, api.RoleResource, barName, "foo-role")).Should(BeFalse())
+		Eventually(HasObject(ctx, api.RoleResource, bazName, "foo-role")).Should(BeTrue())
+		Eventually(isModified(ctx, bazName, "foo-role")).Should(BeTrue())
+		Eventually(numStaleRoles).Should(Equal(0))
+	})
+
+	It("should roll out changes to the source one level at a time", func() {
+		SetParent(ctx, barName, fooName)
+		SetParent(ctx, bazName, barName)
+		Eventually(HasObject(ctx, api.RoleResource, bazName, "foo-role")).Should(BeTrue())
+
+		// Wait for the rollout of the current version to finish before changing the source, so that
+		// HNC isn't updating its status while we modify it.
+		UpdateObjectWithAnnotations(ctx, api.RoleResource, fooName, "foo-role", map[string]string{
+			api.AnnotationRollout:         "levels",
+			api.AnnotationRolloutInterval: "3s",
+		})
+		Eventually(objectAnnotation(ctx, api.RoleResource, fooName, "foo-role", api.AnnotationRolloutStatus), 10*time.Second).Should(ContainSubstring(`"complete":true`))
+
+		// The first level is updated straight away, but the second one has to wait for the interval.
+		modifyRole(ctx, fooName, "foo-role")
+		Eventually(isModified(ctx, barName, "foo-role")).Should(BeTrue())
+		Expect(objectAnnotation(ctx, api.RoleResource, fooName, "foo-role", api.AnnotationRolloutStatus)()).Should(ContainSubstring(`"stage":1,"stages":2`))
+		Consistently(isModified(ctx, bazName, "foo-role"), time.Second).Should(BeFalse())
+		Eventually(isModified(ctx, bazName, "foo-role"), 10*time.Second).Should(BeTrue())
+		Eventually(objectAnnotation(ctx, api.RoleResource, fooName, "foo-role", api.AnnotationRolloutStatus)).Should(ContainSubstring(`"complete":true`))
+	})
+
+	It("should leave fields set by other controllers on propagated copies alone", func() {
+		SetParent(ctx, barName, fooName)
+		Eventually(HasObject(ctx, api.RoleResource, barName, "foo-role")).Should(BeTrue())
+
+		// Another controller annotates the copy. Since HNC doesn't own this annotation, it shouldn't
+		// remove it, either now or when the source changes.
+		UpdateObjectWithAnnotations(ctx, api.RoleResource, barName, "foo-role", map[string]string{"other-controller/annot": "yes"})
+		Consistently(objectAnnotation(ctx, api.RoleResource, barName, "foo-role", "other-controller/annot")).Should(Equal("yes"))
+
+		modifyRole(ctx, fooName, "foo-role")
+		Eventually(isModified(ctx, barName, "foo-role")).Should(BeTrue())
+		Expect(objectAnnotation(ctx, api.RoleResource, barName, "foo-role", "other-controller/annot")()).Should(Equal("yes"))
+	})
+
 	It("should overwrite the conflicting source in the descedants", func() {
 		SetParent(ctx, barName, fooName)
 		SetParent(ctx, bazName, barName)
@@ -730,6 +839,26 @@ var _ = Describe("Basic propagation", func() {
 		Eventually(HasObject(ctx, "secrets", barName, "bar-sec")).Should(BeTrue())
 	})
 
+	It("should only report drifted objects if the sync mode is 'Audit'", func() {
+		AddToHNCConfig(ctx, "", "secrets", api.Audit)
+		// Set tree as bar -> foo(root), and copy a secret from foo to bar by hand.
+		SetParent(ctx, barName, fooName)
+		MakeObject(ctx, "secrets", fooName, "foo-sec")
+		MakeObject(ctx, "secrets", barName, "foo-sec")
+
+		// HNC should report that it would overwrite the copy, but leave it alone.
+		Eventually(eventFor(ctx, barName, "foo-sec", api.EventDriftDetected)).ShouldNot(Equal(""))
+		Consistently(func() string {
+			return ObjectInheritedFrom(ctx, "secrets", barName, "foo-sec")
+		}).Should(Equal(""))
+
+		// Once the mode is switched to 'Propagate', the copy should be overwritten.
+		SetResourceInHNCConfigWithOffset(ctx, 0, api.ResourceSpec{Resource: "secrets", Mode: api.Propagate})
+		Eventually(func() string {
+			return ObjectInheritedFrom(ctx, "secrets", barName, "foo-sec")
+		}).Should(Equal(fooName))
+	})
+
 	It("should avoid propagating banned annotations", func() {
 		SetParent(ctx, barName, fooName)
 		MakeObjectWithAnnotations(ctx, "roles", fooName, "foo-annot-role", map[string]string{
@@ -851,6 +980,61 @@ var _ = Describe("Basic propagation", func() {
 	})
 })
 
+// This isn't a regular test, since measuring memory usage is slow and noisy. Instead, it reports how
+// much memory HNC uses for a synthetic cluster with lots of large propagated objects. To compare the
+// default and metadata-only caching modes, run it once with HNC_TEST_CACHE_METADATA_ONLY set and
+// once without; see scripts/performance/README.md for details.
+var _ = Describe("Memory usage", func() {
+	ctx := context.Background()
+
+	const (
+		numChildren = 20
+		numSecrets  = 50
+		secretSize  = 64 * 1024
+	)
+
+	BeforeEach(func() {
+		if os.Getenv("HNC_BENCHMARK_MEMORY") == "" {
+			Skip("set HNC_BENCHMARK_MEMORY to run memory benchmarks")
+		}
+		CleanupObjects(ctx)
+	})
+
+	AfterEach(func() {
+		ResetHNCConfigToDefault(ctx)
+		CleanupObjects(ctx)
+	})
+
+	It("should report the memory used to propagate large Secrets", func() {
+		before := HeapAlloc()
+
+		// Create a wide tree and propagate lots of large Secrets to every child.
+		root := CreateNS(ctx, "root")
+		children := []string{}
+		for i := 0; i < numChildren; i++ {
+			child := CreateNS(ctx, fmt.Sprintf("child%d", i))
+			SetParent(ctx, child, root)
+			children = append(children, child)
+		}
+		AddToHNCConfig(ctx, "", "secrets", api.Propagate)
+		payload := make([]byte, secretSize)
+		for i := 0; i < numSecrets; i++ {
+			MakeSecretWithData(ctx, root, fmt.Sprintf("large-sec-%d", i), map[string][]byte{"payload": payload})
+		}
+		for _, child := range children {
+			Eventually(HasObject(ctx, "secrets", child, fmt.Sprintf("large-sec-%d", numSecrets-1))).Should(BeTrue())
+		}
+
+		after := HeapAlloc()
+		mode := "full"
+		if os.Getenv("HNC_TEST_CACHE_METADATA_ONLY") != "" {
+			mode = "metadata-only"
+		}
+		AddReportEntry("Memory usage", fmt.Sprintf("%s caching: %d copies of %d KiB each; heap grew by %d MiB",
+			mode, numChildren*numSecrets, secretSize/1024, (int64(after)-int64(before))/(1024*1024)))
+	})
+})
+
 func modifyRole(ctx context.Context, nsName, roleName string) {
 	nnm := types.NamespacedName{Namespace: nsName, Name: roleName}
 	role := &v1.Role{}
@@ -877,6 +1061,14 @@ func setFinalizer(ctx context.Context, nsName, roleName string, set bool) {
 	ExpectWithOffset(1, K8sClient.Update(ctx, role)).Should(Succeed())
 }
 
+// numStaleRoles returns the number of Roles that are out of date because propagation is paused,
+// according to the Role reconciler.
+func numStaleRoles() int {
+	TestForest.Lock()
+	defer TestForest.Unlock()
+	return TestForest.GetTypeSyncerFromGroupKind(schema.GroupKind{Group: api.RBACGroup, Kind: api.RoleKind}).GetNumStaleObjects()
+}
+
 func isModified(ctx context.Context, nsName, roleName string) func() bool {
 	// `Eventually` only works with a fn that doesn't take any args.
 	return func() bool {
@@ -895,6 +1087,69 @@ func isModified(ctx context.Context, nsName, roleName string) func() bool {
 	}
 }
 
+func makeImmutableConfigMap(ctx context.Context, nsName, name, val string) {
+	cm := &corev1.ConfigMap{}
+	cm.Namespace = nsName
+	cm.Name = name
+	cm.Immutable = pointer.Bool(true)
+	cm.Data = map[string]string{"key": val}
+	ExpectWithOffset(1, K8sClient.Create(ctx, cm)).Should(Succeed())
+}
+
+// replaceImmutableConfigMapWhileIgnored deletes the ConfigMap and recreates it with a new value while
+// HNC is ignoring ConfigMaps, so that HNC has to update the existing copies (as opposed to deleting
+// them and creating new ones) when it starts propagating ConfigMaps again.
+func replaceImmutableConfigMapWhileIgnored(ctx context.Context, nsName, name, val string, strategy api.UpdateStrategy) {
+	SetResourceInHNCConfigWithOffset(ctx, 1, api.ResourceSpec{Resource: "configmaps", Mode: api.Ignore, UpdateStrategy: strategy})
+	EventuallyWithOffset(1, func() api.SynchronizationMode {
+		c, err := GetHNCConfig(ctx)
+		if err != nil {
+			return ""
+		}
+		for _, rsc := range c.Status.Resources {
+			if rsc.Resource == "configmaps" {
+				return rsc.Mode
+			}
+		}
+		return ""
+	}).Should(Equal(api.Ignore))
+
+	cm := &corev1.ConfigMap{}
+	cm.Namespace = nsName
+	cm.Name = name
+	ExpectWithOffset(1, K8sClient.Delete(ctx, cm)).Should(Succeed())
+	EventuallyWithOffset(1, func() error {
+		cm := &corev1.ConfigMap{}
+		cm.Namespace = nsName
+		cm.Name = name
+		cm.Immutable = pointer.Bool(true)
+		cm.Data = map[string]string{"key": val}
+		return K8sClient.Create(ctx, cm)
+	}).Should(Succeed())
+
+	SetResourceInHNCConfigWithOffset(ctx, 1, api.ResourceSpec{Resource: "configmaps", Mode: api.Propagate, UpdateStrategy: strategy})
+}
+
+func configMapValue(ctx context.Context, nsName, name string) func() string {
+	return func() string {
+		cm := &corev1.ConfigMap{}
+		if err := K8sClient.Get(ctx, types.NamespacedName{Namespace: nsName, Name: name}, cm); err != nil {
+			return err.Error()
+		}
+		return cm.Data["key"]
+	}
+}
+
+func objectAnnotation(ctx context.Context, resource, nsName, name, key string) func() string {
+	return func() string {
+		inst, err := GetObject(ctx, resource, nsName, name)
+		if err != nil {
+			return err.Error()
+		}
+		return inst.GetAnnotations()[key]
+	}
+}
+
 func removeRole(ctx context.Context, nsName, roleName string) {
 	role := &v1.Role{}
 	role.Name = roleName
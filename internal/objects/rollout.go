@@ -0,0 +1,346 @@
+package objects
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+	"sigs.k8s.io/hierarchical-namespaces/internal/stats"
+)
+
+const (
+	// rolloutLevels is the AnnotationRollout value to propagate each version one level of the tree
+	// at a time.
+	rolloutLevels = "levels"
+	// rolloutCanaryPrefix prefixes the AnnotationRollout value to propagate each version to a canary
+	// subtree first.
+	rolloutCanaryPrefix = "canary="
+
+	defaultRolloutInterval = 5 * time.Minute
+)
+
+// rolloutStatus is the progress of a rollout, stored in the AnnotationRolloutStatus annotation on the
+// source object. Storing it on the source (rather than in memory) makes it visible to users and
+// preserves it across HNC restarts.
+type rolloutStatus struct {
+	// Version identifies the version of the source being rolled out. It's derived from the canonical
+	// form of the source, so reverting the source to an earlier version starts a new rollout.
+	Version string `json:"version"`
+
+	// Stage is the current stage (starting at 1). Copies in this or any earlier stage are updated.
+	Stage int `json:"stage"`
+
+	// Stages is the total number of stages.
+	Stages int `json:"stages"`
+
+	// StageStartTime is when the current stage started, in RFC3339 format.
+	StageStartTime string `json:"stageStartTime,omitempty"`
+
+	// Complete is true once all stages have started; from then on, all copies are updated, including
+	// those in namespaces that are added to the tree later.
+	Complete bool `json:"complete,omitempty"`
+
+	// Message explains why the rollout isn't progressing, if applicable.
+	Message string `json:"message,omitempty"`
+}
+
+// rolloutSpec is the parsed form of the rollout annotations on a source object.
+type rolloutSpec struct {
+	// canary is the root of the canary subtree, or empty if each stage is one level of the tree.
+	canary   string
+	interval time.Duration
+}
+
+// getRolloutSpec returns the rollout spec of the source, or nil if it isn't using progressive
+// rollouts.
+func getRolloutSpec(src *unstructured.Unstructured) (*rolloutSpec, error) {
+	annots := src.GetAnnotations()
+	strategy := annots[api.AnnotationRollout]
+	if strategy == "" {
+		return nil, nil
+	}
+
+	spec := &rolloutSpec{interval: defaultRolloutInterval}
+	switch {
+	case strategy == rolloutLevels:
+	case strings.HasPrefix(strategy, rolloutCanaryPrefix) && len(strategy) > len(rolloutCanaryPrefix):
+		spec.canary = strings.TrimPrefix(strategy, rolloutCanaryPrefix)
+		if errs := validation.IsDNS1123Label(spec.canary); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid %s value %q: %s", api.AnnotationRollout, strategy, strings.Join(errs, "; "))
+		}
+	default:
+		return nil, fmt.Errorf("invalid %s value %q: must be %q or %q followed by a namespace", api.AnnotationRollout, strategy, rolloutLevels, rolloutCanaryPrefix)
+	}
+
+	if intervalStr := annots[api.AnnotationRolloutInterval]; intervalStr != "" {
+		interval, err := time.ParseDuration(intervalStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value: %w", api.AnnotationRolloutInterval, err)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("invalid %s value %q: must be positive", api.AnnotationRolloutInterval, intervalStr)
+		}
+		spec.interval = interval
+	}
+	return spec, nil
+}
+
+// getRolloutStatus returns the rollout status stored on the source. If it's missing or can't be
+// parsed, the zero value is returned, which doesn't match any version.
+func getRolloutStatus(src *unstructured.Unstructured) rolloutStatus {
+	st := rolloutStatus{}
+	if s := src.GetAnnotations()[api.AnnotationRolloutStatus]; s != "" {
+		// Ignore errors; a corrupt status simply restarts the rollout.
+		_ = json.Unmarshal([]byte(s), &st)
+	}
+	return st
+}
+
+// rolloutVersion returns the version of the source, for the purpose of rollouts. Changes to the
+// fields removed by the sanitizers of its type don't change its version, since they're never
+// propagated.
+func rolloutVersion(src *unstructured.Unstructured, ss []sanitizer) string {
+	d := digest(src, ss)
+	return hex.EncodeToString(d[:8])
+}
+
+// rolloutStage returns the stage of the rollout in which the destination namespace is updated. It
+// must be called while the forest lock is held.
+func (r *Reconciler) rolloutStage(spec *rolloutSpec, src *unstructured.Unstructured, dst string) int {
+	dns := r.Forest.Get(dst)
+	if spec.canary != "" {
+		if dst == spec.canary || dns.IsAncestor(r.Forest.Get(spec.canary)) {
+			return 1
+		}
+		return 2
+	}
+	// The tree labels on the destination give us its depth below the source's namespace.
+	return dns.GetTreeLabels()[src.GetNamespace()+api.LabelTreeDepthSuffix]
+}
+
+// numRolloutStages returns the total number of stages needed to roll out the source. It must be
+// called while the forest lock is held.
+func (r *Reconciler) numRolloutStages(spec *rolloutSpec, src *unstructured.Unstructured) int {
+	if spec.canary != "" {
+		return 2
+	}
+	stages := 1
+	for _, dst := range r.Forest.Get(src.GetNamespace()).DescendantNames() {
+		if s := r.rolloutStage(spec, src, dst); s > stages {
+			stages = s
+		}
+	}
+	return stages
+}
+
+// syncRollout determines whether the rollout status of the source needs to change, either because
+// the source has changed (starting a new rollout) or because it's time to move on to the next stage.
+// If so, it returns actionUpdateRollout and a copy of the source with the new status. It must be
+// called while the forest lock is held.
+func (r *Reconciler) syncRollout(log logr.Logger, src *unstructured.Unstructured) (syncAction, *unstructured.Unstructured) {
+	old := getRolloutStatus(src)
+	_, hasStatus := src.GetAnnotations()[api.AnnotationRolloutStatus]
+	spec, err := getRolloutSpec(src)
+	if err != nil {
+		// This should have been caught by the webhook. Treat it as a regular source.
+		log.Error(err, "Cannot parse rollout annotations")
+		r.EventRecorder.Event(src, "Warning", api.EventCannotParseSelector, err.Error())
+	}
+	if spec == nil {
+		// If the source was using progressive rollouts but isn't anymore, clean up its status.
+		if hasStatus {
+			return actionUpdateRollout, withRolloutStatus(src, nil)
+		}
+		return actionNop, nil
+	}
+
+	now := time.Now()
+	st := old
+	version := rolloutVersion(src, r.getSanitizers())
+	switch {
+	case st.Version != version:
+		// This is a new version of the source, so start rolling it out.
+		st = rolloutStatus{Version: version, Stage: 1, StageStartTime: now.UTC().Format(time.RFC3339)}
+
+	case st.Complete:
+		// Nothing more to do until the source changes.
+		return actionNop, nil
+
+	default:
+		st.Message = ""
+		if failed := r.numFailedRolloutCopies(src, version); failed > 0 {
+			// The health signal for a rollout is whether all the copies in the stages so far were
+			// updated successfully. If not, don't go any further; the failures are reported by events.
+			st.Message = fmt.Sprintf("Stopped: %d propagated copies could not be updated", failed)
+			break
+		}
+		start, _ := time.Parse(time.RFC3339, st.StageStartTime)
+		if now.Sub(start) >= spec.interval {
+			st.Stage++
+			st.StageStartTime = now.UTC().Format(time.RFC3339)
+		}
+	}
+
+	// The number of stages can change if the hierarchy changes during the rollout.
+	st.Stages = r.numRolloutStages(spec, src)
+	if st.Stage >= st.Stages {
+		st.Stage = st.Stages
+		st.Complete = true
+	}
+	if st == old {
+		return actionNop, nil
+	}
+	if st.Stage != old.Stage || st.Version != old.Version {
+		log.Info("Starting rollout stage", "version", st.Version, "stage", st.Stage, "stages", st.Stages)
+		msg := fmt.Sprintf("Started stage %d of %d of the rollout of version %s.", st.Stage, st.Stages, st.Version)
+		r.EventRecorder.Event(src, "Normal", api.EventRolloutStageStarted, msg)
+	}
+	return actionUpdateRollout, withRolloutStatus(src, &st)
+}
+
+// holdForRollout returns true if the source's rollout hasn't reached the destination namespace yet,
+// so its copy there shouldn't be updated. It must be called while the forest lock is held.
+func (r *Reconciler) holdForRollout(log logr.Logger, src *unstructured.Unstructured, dst string) bool {
+	if r.Mode == api.Audit {
+		// Rollouts never progress in the Audit mode, so report every copy that would eventually be
+		// updated.
+		return false
+	}
+	spec, err := getRolloutSpec(src)
+	if err != nil || spec == nil {
+		return false
+	}
+	st := getRolloutStatus(src)
+	if st.Version != rolloutVersion(src, r.getSanitizers()) {
+		// The rollout of this version hasn't started yet. The copy will be enqueued again once the
+		// status of the source is updated.
+		log.V(1).Info("Waiting for the rollout to start")
+		return true
+	}
+	if st.Complete {
+		return false
+	}
+	if stage := r.rolloutStage(spec, src, dst); stage > st.Stage {
+		log.V(1).Info("Waiting for the rollout to reach this namespace", "stage", stage, "currentStage", st.Stage)
+		return true
+	}
+	return false
+}
+
+// rolloutRequeueAfter returns how long to wait before reconciling the source again in order to start
+// the next stage of its rollout, or zero if it doesn't need to be requeued.
+func rolloutRequeueAfter(src *unstructured.Unstructured, ss []sanitizer) time.Duration {
+	spec, err := getRolloutSpec(src)
+	if err != nil || spec == nil {
+		return 0
+	}
+	st := getRolloutStatus(src)
+	if st.Complete || st.Version != rolloutVersion(src, ss) {
+		return 0
+	}
+	start, err := time.Parse(time.RFC3339, st.StageStartTime)
+	if err != nil {
+		return 0
+	}
+	if wait := time.Until(start.Add(spec.interval)); wait > 0 {
+		return wait
+	}
+	// The stage can't advance yet because of failures (or this was called before the status was
+	// updated), so check again later.
+	return spec.interval
+}
+
+// recordRolloutResult records whether a copy of a source with a progressive rollout was updated
+// successfully, which is used as the health signal for the rollout.
+func (r *Reconciler) recordRolloutResult(src, inst *unstructured.Unstructured, err error) {
+	if spec, _ := getRolloutSpec(src); spec == nil {
+		return
+	}
+	snnm := types.NamespacedName{Namespace: src.GetNamespace(), Name: src.GetName()}
+	r.rolloutFailuresLock.Lock()
+	defer r.rolloutFailuresLock.Unlock()
+
+	failures := r.rolloutFailures[snnm]
+	if err == nil {
+		delete(failures, inst.GetNamespace())
+		if len(failures) == 0 {
+			delete(r.rolloutFailures, snnm)
+		}
+		return
+	}
+	if failures == nil {
+		failures = map[string]string{}
+		r.rolloutFailures[snnm] = failures
+	}
+	failures[inst.GetNamespace()] = rolloutVersion(src, r.getSanitizers())
+}
+
+// numFailedRolloutCopies returns the number of copies of this version of the source that couldn't
+// be updated.
+func (r *Reconciler) numFailedRolloutCopies(src *unstructured.Unstructured, version string) int {
+	snnm := types.NamespacedName{Namespace: src.GetNamespace(), Name: src.GetName()}
+	r.rolloutFailuresLock.Lock()
+	defer r.rolloutFailuresLock.Unlock()
+
+	n := 0
+	for _, v := range r.rolloutFailures[snnm] {
+		if v == version {
+			n++
+		}
+	}
+	return n
+}
+
+// withRolloutStatus returns a copy of the source with the given rollout status, or without any
+// status if it's nil.
+func withRolloutStatus(src *unstructured.Unstructured, st *rolloutStatus) *unstructured.Unstructured {
+	src = src.DeepCopy()
+	annots := src.GetAnnotations()
+	if annots == nil {
+		annots = map[string]string{}
+	}
+	if st == nil {
+		delete(annots, api.AnnotationRolloutStatus)
+	} else {
+		// This can't fail since the status only contains strings, ints and bools.
+		b, _ := json.Marshal(st)
+		annots[api.AnnotationRolloutStatus] = string(b)
+	}
+	src.SetAnnotations(annots)
+	return src
+}
+
+// updateRolloutStatus writes the rollout status of the source to the apiserver. Only the status
+// annotation is patched, so that we don't overwrite any concurrent changes to the source.
+func (r *Reconciler) updateRolloutStatus(ctx context.Context, log logr.Logger, src *unstructured.Unstructured) error {
+	var val interface{}
+	if s, ok := src.GetAnnotations()[api.AnnotationRolloutStatus]; ok {
+		val = s
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{api.AnnotationRolloutStatus: val},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	inst := &unstructured.Unstructured{}
+	inst.SetGroupVersionKind(r.GVK)
+	inst.SetNamespace(src.GetNamespace())
+	inst.SetName(src.GetName())
+	log.V(1).Info("Updating rollout status")
+	stats.WriteObject(r.GVK)
+	return r.Patch(ctx, inst, client.RawPatch(types.MergePatchType, patch))
+}
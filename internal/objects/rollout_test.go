@@ -0,0 +1,98 @@
+package objects
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+)
+
+func TestGetRolloutSpec(t *testing.T) {
+	tests := []struct {
+		name   string
+		annots map[string]string
+		want   *rolloutSpec
+		fail   bool
+	}{{
+		name: "no rollout",
+	}, {
+		name:   "levels",
+		annots: map[string]string{api.AnnotationRollout: "levels"},
+		want:   &rolloutSpec{interval: defaultRolloutInterval},
+	}, {
+		name:   "canary with interval",
+		annots: map[string]string{api.AnnotationRollout: "canary=team-a", api.AnnotationRolloutInterval: "1h"},
+		want:   &rolloutSpec{canary: "team-a", interval: time.Hour},
+	}, {
+		name:   "canary without namespace",
+		annots: map[string]string{api.AnnotationRollout: "canary="},
+		fail:   true,
+	}, {
+		name:   "canary with invalid namespace",
+		annots: map[string]string{api.AnnotationRollout: "canary=Team_A"},
+		fail:   true,
+	}, {
+		name:   "unknown strategy",
+		annots: map[string]string{api.AnnotationRollout: "all-at-once"},
+		fail:   true,
+	}, {
+		name:   "invalid interval",
+		annots: map[string]string{api.AnnotationRollout: "levels", api.AnnotationRolloutInterval: "soon"},
+		fail:   true,
+	}, {
+		name:   "zero interval",
+		annots: map[string]string{api.AnnotationRollout: "levels", api.AnnotationRolloutInterval: "0s"},
+		fail:   true,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			inst := &unstructured.Unstructured{}
+			inst.SetAnnotations(tc.annots)
+
+			got, err := getRolloutSpec(inst)
+			if tc.fail {
+				g.Expect(err).Should(HaveOccurred())
+				return
+			}
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(got).Should(Equal(tc.want))
+		})
+	}
+}
+
+func TestRolloutStatus(t *testing.T) {
+	g := NewWithT(t)
+	inst := &unstructured.Unstructured{}
+	inst.SetAnnotations(map[string]string{api.AnnotationRollout: "levels", api.AnnotationRolloutInterval: "1h"})
+
+	// There's no status yet, so the source doesn't need to be requeued until its rollout starts.
+	g.Expect(getRolloutStatus(inst)).Should(Equal(rolloutStatus{}))
+	g.Expect(rolloutRequeueAfter(inst, nil)).Should(BeZero())
+
+	// Changes to HNC annotations (including the status itself) don't change the version.
+	version := rolloutVersion(inst, nil)
+	st := rolloutStatus{Version: version, Stage: 1, Stages: 3, StageStartTime: time.Now().UTC().Format(time.RFC3339)}
+	inst = withRolloutStatus(inst, &st)
+	g.Expect(rolloutVersion(inst, nil)).Should(Equal(version))
+	g.Expect(getRolloutStatus(inst)).Should(Equal(st))
+	g.Expect(rolloutRequeueAfter(inst, nil)).Should(BeNumerically("~", time.Hour, time.Minute))
+
+	// Other changes do.
+	inst.Object["data"] = map[string]interface{}{"foo": "bar"}
+	g.Expect(rolloutVersion(inst, nil)).ShouldNot(Equal(version))
+	g.Expect(rolloutRequeueAfter(inst, nil)).Should(BeZero())
+
+	// Completed rollouts never need to be requeued.
+	st = rolloutStatus{Version: rolloutVersion(inst, nil), Stage: 3, Stages: 3, StageStartTime: st.StageStartTime, Complete: true}
+	inst = withRolloutStatus(inst, &st)
+	g.Expect(rolloutRequeueAfter(inst, nil)).Should(BeZero())
+
+	// The status can be removed.
+	inst = withRolloutStatus(inst, nil)
+	g.Expect(inst.GetAnnotations()).ShouldNot(HaveKey(api.AnnotationRolloutStatus))
+}
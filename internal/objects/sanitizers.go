@@ -0,0 +1,113 @@
+package objects
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// sanitizer removes the fields of an object that can't be copied verbatim into another namespace,
+// such as fields that are allocated by the apiserver or by another controller for each object. It's
+// called on a copy of the object, which it may modify freely.
+type sanitizer func(inst *unstructured.Unstructured)
+
+// builtinSanitizers are the sanitizers for the builtin types that can't otherwise be propagated
+// safely.
+var builtinSanitizers = map[schema.GroupKind][]sanitizer{
+	// Services are assigned their own cluster IPs (unless they're headless) and node ports, which must
+	// be unique across the cluster.
+	{Kind: "Service"}: {
+		sanitizeClusterIP,
+		fieldsSanitizer("spec.clusterIPs", "spec.healthCheckNodePort", "spec.ports.nodePort"),
+	},
+
+	// PVCs are bound to their own PVs.
+	{Kind: "PersistentVolumeClaim"}: {
+		fieldsSanitizer("spec.volumeName"),
+		annotationsSanitizer(
+			"pv.kubernetes.io/bind-completed",
+			"pv.kubernetes.io/bound-by-controller",
+			"volume.beta.kubernetes.io/storage-provisioner",
+			"volume.kubernetes.io/storage-provisioner",
+			"volume.kubernetes.io/selected-node",
+		),
+	},
+
+	// ServiceAccounts may refer to token Secrets that are generated for them in their own namespace.
+	{Kind: "ServiceAccount"}: {
+		fieldsSanitizer("secrets"),
+	},
+}
+
+// getSanitizers returns the sanitizers for the given type: the builtin ones, if any, followed by
+// one that removes the fields that the admin has asked HNC not to propagate via the
+// HNCConfiguration.
+func getSanitizers(gk schema.GroupKind, unpropagatedFields []string) []sanitizer {
+	ss := append([]sanitizer{}, builtinSanitizers[gk]...)
+	if len(unpropagatedFields) > 0 {
+		ss = append(ss, fieldsSanitizer(unpropagatedFields...))
+	}
+	return ss
+}
+
+// sanitize removes all the fields that shouldn't be propagated from the object, which must already
+// be a copy.
+func sanitize(inst *unstructured.Unstructured, ss []sanitizer) {
+	for _, s := range ss {
+		s(inst)
+	}
+}
+
+// fieldsSanitizer returns a sanitizer that removes the fields with the given dot-separated paths.
+func fieldsSanitizer(paths ...string) sanitizer {
+	return func(inst *unstructured.Unstructured) {
+		for _, p := range paths {
+			removeField(inst.Object, strings.Split(p, "."))
+		}
+	}
+}
+
+// annotationsSanitizer returns a sanitizer that removes the given annotations.
+func annotationsSanitizer(keys ...string) sanitizer {
+	return func(inst *unstructured.Unstructured) {
+		annots := inst.GetAnnotations()
+		if annots == nil {
+			return
+		}
+		for _, k := range keys {
+			delete(annots, k)
+		}
+		inst.SetAnnotations(annots)
+	}
+}
+
+// sanitizeClusterIP removes the cluster IP from Services, unless it's "None" (i.e. the Service is
+// headless), since that's set by the user and not allocated by the apiserver.
+func sanitizeClusterIP(inst *unstructured.Unstructured) {
+	ip, _, _ := unstructured.NestedString(inst.Object, "spec", "clusterIP")
+	if ip != "None" {
+		unstructured.RemoveNestedField(inst.Object, "spec", "clusterIP")
+	}
+}
+
+// removeField removes the field with the given path from the object. If any field along the path is
+// a list, the rest of the path is removed from every element of the list. Missing fields are
+// ignored.
+func removeField(obj interface{}, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	switch o := obj.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			delete(o, path[0])
+			return
+		}
+		removeField(o[path[0]], path[1:])
+	case []interface{}:
+		for _, item := range o {
+			removeField(item, path)
+		}
+	}
+}
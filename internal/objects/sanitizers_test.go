@@ -0,0 +1,183 @@
+package objects
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   []string
+		inst     map[string]interface{}
+		expected map[string]interface{}
+	}{{
+		name: "Service",
+		inst: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"spec": map[string]interface{}{
+				"clusterIP":  "10.0.0.1",
+				"clusterIPs": []interface{}{"10.0.0.1"},
+				"ports": []interface{}{
+					map[string]interface{}{"port": int64(80), "nodePort": int64(30080)},
+					map[string]interface{}{"port": int64(443), "nodePort": int64(30443)},
+				},
+				"type": "NodePort",
+			},
+		},
+		expected: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"spec": map[string]interface{}{
+				"ports": []interface{}{
+					map[string]interface{}{"port": int64(80)},
+					map[string]interface{}{"port": int64(443)},
+				},
+				"type": "NodePort",
+			},
+		},
+	}, {
+		name: "Headless Service",
+		inst: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"spec": map[string]interface{}{
+				"clusterIP":  "None",
+				"clusterIPs": []interface{}{"None"},
+			},
+		},
+		expected: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"spec": map[string]interface{}{
+				"clusterIP": "None",
+			},
+		},
+	}, {
+		name: "PersistentVolumeClaim",
+		inst: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolumeClaim",
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					"pv.kubernetes.io/bind-completed": "yes",
+					"other":                           "value",
+				},
+			},
+			"spec": map[string]interface{}{
+				"storageClassName": "standard",
+				"volumeName":       "pvc-1234",
+			},
+		},
+		expected: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolumeClaim",
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					"other": "value",
+				},
+			},
+			"spec": map[string]interface{}{
+				"storageClassName": "standard",
+			},
+		},
+	}, {
+		name: "ServiceAccount",
+		inst: map[string]interface{}{
+			"apiVersion":       "v1",
+			"kind":             "ServiceAccount",
+			"secrets":          []interface{}{map[string]interface{}{"name": "sa-token-abcde"}},
+			"imagePullSecrets": []interface{}{map[string]interface{}{"name": "registry"}},
+		},
+		expected: map[string]interface{}{
+			"apiVersion":       "v1",
+			"kind":             "ServiceAccount",
+			"imagePullSecrets": []interface{}{map[string]interface{}{"name": "registry"}},
+		},
+	}, {
+		name:   "Unpropagated fields of a custom resource",
+		fields: []string{"spec.assigned", "spec.items.id", "spec.missing.field"},
+		inst: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"spec": map[string]interface{}{
+				"assigned": "node-1",
+				"size":     int64(3),
+				"items": []interface{}{
+					map[string]interface{}{"name": "a", "id": "1"},
+					map[string]interface{}{"name": "b", "id": "2"},
+				},
+			},
+		},
+		expected: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"spec": map[string]interface{}{
+				"size": int64(3),
+				"items": []interface{}{
+					map[string]interface{}{"name": "a"},
+					map[string]interface{}{"name": "b"},
+				},
+			},
+		},
+	}, {
+		name: "Types without sanitizers",
+		inst: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"data":       map[string]interface{}{"volumeName": "foo"},
+		},
+		expected: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"data":       map[string]interface{}{"volumeName": "foo"},
+		},
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			inst := &unstructured.Unstructured{Object: tc.inst}
+			gk := inst.GroupVersionKind().GroupKind()
+
+			sanitize(inst, getSanitizers(gk, tc.fields))
+			g.Expect(inst.Object).Should(Equal(tc.expected))
+		})
+	}
+}
+
+func TestReconcilerSanitizers(t *testing.T) {
+	g := NewWithT(t)
+	r := &Reconciler{
+		GVK:                schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Gadget"},
+		Mode:               api.Ignore,
+		UnpropagatedFields: []string{"status2"},
+	}
+	r.setSanitizers()
+
+	inst := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Gadget",
+		"spec":       map[string]interface{}{"a": "b", "c": "d"},
+		"status2":    "ready",
+	}}
+	c := canonical(inst, r.getSanitizers())
+	g.Expect(c.Object).ShouldNot(HaveKey("status2"))
+	g.Expect(c.Object).Should(HaveKey("spec"))
+	// The original object shouldn't be modified.
+	g.Expect(inst.Object).Should(HaveKey("status2"))
+
+	// Changing the unpropagated fields replaces the old ones.
+	g.Expect(r.SetUnpropagatedFields(context.Background(), zap.New(), []string{"spec.c"})).Should(Succeed())
+	c = canonical(inst, r.getSanitizers())
+	g.Expect(c.Object).Should(HaveKey("status2"))
+	g.Expect(c.Object).Should(HaveKeyWithValue("spec", map[string]interface{}{"a": "b"}))
+}
@@ -117,6 +117,18 @@ func (v *Validator) canPropagateType(gvk metav1.GroupVersionKind) bool {
 	return (v.isPropagateType(gvk) || v.isAllowPropagateType(gvk))
 }
 
+// getSanitizers returns the sanitizers used by the reconciler of the given type, or only the builtin
+// ones if there's no reconciler for it, so that users can change the fields that HNC doesn't
+// propagate.
+func (v *Validator) getSanitizers(gk schema.GroupKind) []sanitizer {
+	v.Forest.Lock()
+	defer v.Forest.Unlock()
+	if r, ok := v.Forest.GetTypeSyncerFromGroupKind(gk).(*Reconciler); ok {
+		return r.getSanitizers()
+	}
+	return getSanitizers(gk, nil)
+}
+
 // handle implements the non-webhook-y businesss logic of this validator, allowing it to be more
 // easily unit tested (ie without constructing an admission.Request, setting up user infos, etc).
 func (v *Validator) handle(ctx context.Context, req *request) admission.Response {
@@ -148,6 +160,15 @@ func (v *Validator) handle(ctx context.Context, req *request) admission.Response
 		if err := validateAllSelectorChange(inst, oldInst); err != nil {
 			return webhooks.DenyBadRequest(err)
 		}
+		if err := validatePausedChange(inst, oldInst); err != nil {
+			return webhooks.DenyBadRequest(err)
+		}
+		if err := validateRolloutChange(inst, oldInst); err != nil {
+			return webhooks.DenyBadRequest(err)
+		}
+		if err := validateRolloutStatusChange(inst, oldInst); err != nil {
+			return webhooks.DenyForbidden(req.gr(), req.name(), err)
+		}
 		if msg := validateSelectorUniqueness(inst, oldInst); msg != "" {
 			return webhooks.DenyBadRequest(errors.New(msg))
 		}
@@ -177,7 +198,10 @@ func validateSelectorAnnot(inst *unstructured.Unstructured) string {
 			api.AnnotationSelector +
 			"; " + api.AnnotationTreeSelector +
 			"; " + api.AnnotationNoneSelector +
-			"; " + api.AnnotationAllSelector
+			"; " + api.AnnotationAllSelector +
+			"; " + api.AnnotationPaused +
+			"; " + api.AnnotationRollout +
+			"; " + api.AnnotationRolloutInterval
 		// If this annotation is part of HNC metagroup, we check if the prefix value is valid
 		if segs[0] != api.AnnotationPropagatePrefix {
 			return fmt.Sprintf(msg, key)
@@ -186,7 +210,10 @@ func validateSelectorAnnot(inst *unstructured.Unstructured) string {
 		if key != api.AnnotationSelector &&
 			key != api.AnnotationTreeSelector &&
 			key != api.AnnotationNoneSelector &&
-			key != api.AnnotationAllSelector {
+			key != api.AnnotationAllSelector &&
+			key != api.AnnotationPaused &&
+			key != api.AnnotationRollout &&
+			key != api.AnnotationRolloutInterval {
 			return fmt.Sprintf(msg, key)
 		}
 	}
@@ -268,6 +295,40 @@ func validateAllSelectorChange(inst, oldInst *unstructured.Unstructured) error {
 	return err
 }
 
+func validatePausedChange(inst, oldInst *unstructured.Unstructured) error {
+	oldPausedStr := selectors.GetPausedAnnotation(oldInst)
+	newPausedStr := selectors.GetPausedAnnotation(inst)
+	if newPausedStr == "" || oldPausedStr == newPausedStr {
+		return nil
+	}
+	_, err := selectors.GetPaused(inst)
+	return err
+}
+
+func validateRolloutChange(inst, oldInst *unstructured.Unstructured) error {
+	annots, oldAnnots := inst.GetAnnotations(), oldInst.GetAnnotations()
+	if annots[api.AnnotationRollout] == oldAnnots[api.AnnotationRollout] &&
+		annots[api.AnnotationRolloutInterval] == oldAnnots[api.AnnotationRolloutInterval] {
+		return nil
+	}
+	if annots[api.AnnotationRollout] == "" && annots[api.AnnotationRolloutInterval] != "" {
+		return fmt.Errorf("%s can only be used with %s", api.AnnotationRolloutInterval, api.AnnotationRollout)
+	}
+	_, err := getRolloutSpec(inst)
+	return err
+}
+
+// validateRolloutStatusChange prevents users from setting or modifying the rollout status, since
+// otherwise they could skip the stages of a rollout (e.g. by marking it as complete). Removing it is
+// allowed, since that only restarts the rollout from the first stage.
+func validateRolloutStatusChange(inst, oldInst *unstructured.Unstructured) error {
+	st, ok := inst.GetAnnotations()[api.AnnotationRolloutStatus]
+	if !ok || st == oldInst.GetAnnotations()[api.AnnotationRolloutStatus] {
+		return nil
+	}
+	return fmt.Errorf("the %s annotation can only be set by HNC", api.AnnotationRolloutStatus)
+}
+
 func (v *Validator) handleInherited(ctx context.Context, req *request, newSource, oldSource string) admission.Response {
 	op := req.op
 	inst := req.obj
@@ -307,15 +368,26 @@ func (v *Validator) handleInherited(ctx context.Context, req *request, newSource
 		}
 
 		// If the existing object has an inheritedFrom label, it's a propagated object. Any user changes
-		// should be rejected. Note that canonical does *not* compare any HNC labels or
-		// annotations.
-		if !reflect.DeepEqual(canonical(inst), canonical(oldInst)) {
+		// to the fields that HNC owns should be rejected; other fields may be set by other controllers.
+		// We use the ownership information of the *old* object so that it can't be bypassed by changing
+		// the managed fields. If HNC has never applied this object, it owns the whole object. Note that
+		// canonical does *not* compare any HNC labels or annotations.
+		owned := hncFields(oldInst)
+		ss := v.getSanitizers(inst.GroupVersionKind().GroupKind())
+		if !reflect.DeepEqual(canonical(withFields(inst, owned), ss), canonical(withFields(oldInst, owned), ss)) {
 			err := fmt.Errorf("cannot modify object propagated from namespace \"%s\"", oldSource)
 			return webhooks.DenyForbidden(req.gr(), req.name(), err)
 		}
 
-		// Check for all the labels and annotations (including HNC and non HNC)
-		if !reflect.DeepEqual(oldInst.GetLabels(), inst.GetLabels()) || !reflect.DeepEqual(oldInst.GetAnnotations(), inst.GetAnnotations()) {
+		// Check for all the labels and annotations (including HNC and non HNC), unless HNC only owns
+		// some of them, in which case we've already checked the non-HNC ones above.
+		oldLabels, oldAnnots := oldInst.GetLabels(), oldInst.GetAnnotations()
+		labels, annots := inst.GetLabels(), inst.GetAnnotations()
+		if owned != nil {
+			oldLabels, oldAnnots = hncMetadata(oldLabels), hncMetadata(oldAnnots)
+			labels, annots = hncMetadata(labels), hncMetadata(annots)
+		}
+		if !reflect.DeepEqual(oldLabels, labels) || !reflect.DeepEqual(oldAnnots, annots) {
 			err := fmt.Errorf("cannot modify object propagated from namespace \"%s\"", oldSource)
 			return webhooks.DenyForbidden(req.gr(), req.name(), err)
 		}
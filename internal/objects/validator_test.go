@@ -186,6 +186,103 @@ func TestUserChanges(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		name: "Allow changes to fields that HNC doesn't own in applied propagated objects",
+		oldInst: appliedByHNC(&unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{
+						api.LabelInheritedFrom: "foo",
+						"testLabel":            "1",
+					},
+				},
+				"data": map[string]interface{}{
+					"key": "value",
+				},
+			},
+		}, `{"f:data":{"f:key":{}},"f:metadata":{"f:labels":{"f:hnc.x-k8s.io/inherited-from":{},"f:testLabel":{}}}}`),
+		inst: appliedByHNC(&unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{
+						api.LabelInheritedFrom: "foo",
+						"testLabel":            "1",
+						"otherLabel":           "added",
+					},
+					"annotations": map[string]interface{}{
+						"other-controller/annot": "added",
+					},
+				},
+				"data": map[string]interface{}{
+					"key":   "value",
+					"other": "added",
+				},
+			},
+		}, `{"f:data":{"f:key":{}},"f:metadata":{"f:labels":{"f:hnc.x-k8s.io/inherited-from":{},"f:testLabel":{}}}}`),
+	}, {
+		name: "Deny changes to fields that HNC owns in applied propagated objects",
+		fail: true,
+		oldInst: appliedByHNC(&unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{
+						api.LabelInheritedFrom: "foo",
+					},
+				},
+				"data": map[string]interface{}{
+					"key": "value",
+				},
+			},
+		}, `{"f:data":{"f:key":{}},"f:metadata":{"f:labels":{"f:hnc.x-k8s.io/inherited-from":{}}}}`),
+		// Removing HNC's managed fields shouldn't help.
+		inst: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{
+						api.LabelInheritedFrom: "foo",
+					},
+				},
+				"data": map[string]interface{}{
+					"key": "changed",
+				},
+			},
+		},
+	}, {
+		name: "Deny adding HNC annotations to applied propagated objects",
+		fail: true,
+		oldInst: appliedByHNC(&unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{
+						api.LabelInheritedFrom: "foo",
+					},
+				},
+			},
+		}, `{"f:metadata":{"f:labels":{"f:hnc.x-k8s.io/inherited-from":{}}}}`),
+		inst: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{
+						api.LabelInheritedFrom: "foo",
+					},
+					"annotations": map[string]interface{}{
+						api.AnnotationNoneSelector: "true",
+					},
+				},
+			},
+		},
 	}, {
 		name: "Deny metadata changes to propagated objects",
 		fail: true,
@@ -568,6 +665,150 @@ func TestUserChanges(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		name: "Deny creation of object with invalid paused annotation",
+		fail: true,
+		inst: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						api.AnnotationPaused: "foo",
+					},
+				},
+			},
+		},
+	}, {
+		name: "Allow creation of object with paused annotation and a selector",
+		fail: false,
+		inst: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						api.AnnotationPaused:       "true",
+						api.AnnotationTreeSelector: "foo",
+					},
+				},
+			},
+		},
+	}, {
+		name: "Deny creation of object with invalid rollout annotation",
+		fail: true,
+		inst: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						api.AnnotationRollout: "everywhere",
+					},
+				},
+			},
+		},
+	}, {
+		name: "Deny creation of object with rolloutInterval annotation but no rollout",
+		fail: true,
+		inst: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						api.AnnotationRolloutInterval: "10m",
+					},
+				},
+			},
+		},
+	}, {
+		name: "Allow creation of object with valid rollout annotations",
+		fail: false,
+		inst: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						api.AnnotationRollout:         "canary=child",
+						api.AnnotationRolloutInterval: "10m",
+					},
+				},
+			},
+		},
+	}, {
+		name: "Deny creation of object with rollout status",
+		fail: true,
+		inst: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						api.AnnotationRollout:       "levels",
+						api.AnnotationRolloutStatus: `{"version":"abc","stage":2,"stages":2,"complete":true}`,
+					},
+				},
+			},
+		},
+	}, {
+		name: "Deny changes to the rollout status",
+		fail: true,
+		oldInst: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						api.AnnotationRollout:       "levels",
+						api.AnnotationRolloutStatus: `{"version":"abc","stage":1,"stages":2}`,
+					},
+				},
+			},
+		},
+		inst: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						api.AnnotationRollout:       "levels",
+						api.AnnotationRolloutStatus: `{"version":"abc","stage":2,"stages":2,"complete":true}`,
+					},
+				},
+			},
+		},
+	}, {
+		name: "Allow changes that keep the rollout status",
+		fail: false,
+		oldInst: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						api.AnnotationRollout:       "levels",
+						api.AnnotationRolloutStatus: `{"version":"abc","stage":1,"stages":2}`,
+					},
+				},
+			},
+		},
+		inst: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{
+						"testLabel": "2",
+					},
+					"annotations": map[string]interface{}{
+						api.AnnotationRollout:       "levels",
+						api.AnnotationRolloutStatus: `{"version":"abc","stage":1,"stages":2}`,
+					},
+				},
+			},
+		},
 	}, {
 		name: "Deny creation of object with invalid selector and valid treeSelect annotation",
 		fail: true,
@@ -818,3 +1059,15 @@ func TestCreatingConflictSource(t *testing.T) {
 		})
 	}
 }
+
+// appliedByHNC records that HNC owns the given fields (in FieldsV1 format) in the object.
+func appliedByHNC(inst *unstructured.Unstructured, fields string) *unstructured.Unstructured {
+	inst.SetManagedFields([]metav1.ManagedFieldsEntry{{
+		Manager:    fieldManager,
+		Operation:  metav1.ManagedFieldsOperationApply,
+		APIVersion: "v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(fields)},
+	}})
+	return inst
+}
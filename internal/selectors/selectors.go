@@ -18,12 +18,13 @@ import (
 
 // ShouldPropagate returns true if the given object should be propagated
 // based on the SynchronizationMode and on the selectors of the object.
-// Propagation will be done only with 'Propagate' or 'AllowPropagate' modes.
+// Propagation will be done only with 'Propagate' or 'AllowPropagate' modes ('Audit' mode follows
+// the same logic as 'Propagate' mode, but only reports what would be propagated).
 // When selectors are set, 'AllowPropagate' follows the same logic as 'Propagate' mode,
 // when they are not set, then if 'Propagate' mode is used then propagate by default;
 // if 'AllowPropagate' mode is used then do not propagate by default.
 func ShouldPropagate(inst *unstructured.Unstructured, nsLabels labels.Set, mode api.SynchronizationMode) (bool, error) {
-	propIfNotExcluded := (mode == api.Propagate)
+	propIfNotExcluded := (mode == api.Propagate || mode == api.Audit)
 
 	if sel, err := GetSelector(inst); err != nil {
 		return false, err
@@ -76,6 +77,11 @@ func GetAllSelectorAnnotation(inst *unstructured.Unstructured) string {
 	return annot[api.AnnotationAllSelector]
 }
 
+func GetPausedAnnotation(inst *unstructured.Unstructured) string {
+	annot := inst.GetAnnotations()
+	return annot[api.AnnotationPaused]
+}
+
 // GetTreeSelector is similar to a regular selector, except that it adds the LabelTreeDepthSuffix to every string
 // To transform a tree selector into a regular label selector, we follow these steps:
 // 1. get the treeSelector annotation if it exists
@@ -197,6 +203,21 @@ func GetAllSelector(inst *unstructured.Unstructured) (bool, error) {
 	return allSelector, nil
 }
 
+// GetPaused returns true if the propagation of this object has been paused, i.e. HNC shouldn't
+// touch any of its existing propagated copies.
+func GetPaused(inst *unstructured.Unstructured) (bool, error) {
+	pausedStr := GetPausedAnnotation(inst)
+	// Empty string is treated as 'false'.
+	if pausedStr == "" {
+		return false, nil
+	}
+	paused, err := strconv.ParseBool(pausedStr)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s value: %w", api.AnnotationPaused, err)
+	}
+	return paused, nil
+}
+
 // cmExclusionsByName are known (istio and kube-root) CA configmap which are excluded from propagation
 var cmExclusionsByName = []string{"istio-ca-root-cert", "kube-root-ca.crt"}
 
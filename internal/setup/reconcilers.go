@@ -8,12 +8,15 @@ import (
 	"github.com/go-logr/logr"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
 	"sigs.k8s.io/hierarchical-namespaces/internal/anchor"
 	"sigs.k8s.io/hierarchical-namespaces/internal/crd"
 	"sigs.k8s.io/hierarchical-namespaces/internal/forest"
+	"sigs.k8s.io/hierarchical-namespaces/internal/generator"
 	"sigs.k8s.io/hierarchical-namespaces/internal/hierarchyconfig"
 	"sigs.k8s.io/hierarchical-namespaces/internal/hncconfig"
 	"sigs.k8s.io/hierarchical-namespaces/internal/hrq"
+	"sigs.k8s.io/hierarchical-namespaces/internal/netpol"
 )
 
 var (
@@ -28,6 +31,18 @@ type Options struct {
 	HNCCfgRefresh   time.Duration
 	HRQ             bool
 	HRQSyncInterval time.Duration
+
+	// CacheObjectMetadataOnly causes the object reconcilers to only cache the metadata of the
+	// objects they propagate; see objects.Reconciler.MetadataOnly.
+	CacheObjectMetadataOnly bool
+
+	// DeletionGracePeriod is how long subnamespaces are kept after their anchors are deleted; see
+	// anchor.Reconciler.DeletionGracePeriod.
+	DeletionGracePeriod time.Duration
+
+	// HierarchyHistoryLimit is the maximum number of entries in each HierarchyHistory; see
+	// hierarchyconfig.Reconciler.HistoryLimit.
+	HierarchyHistoryLimit int
 }
 
 func Create(log logr.Logger, mgr ctrl.Manager, f *forest.Forest, opts Options) {
@@ -58,6 +73,8 @@ func CreateReconcilers(mgr ctrl.Manager, f *forest.Forest, opts Options) error {
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("anchor").WithName("reconcile"),
 		Forest: f,
+
+		DeletionGracePeriod: opts.DeletionGracePeriod,
 	}
 	f.AddListener(ar)
 
@@ -68,6 +85,7 @@ func CreateReconcilers(mgr ctrl.Manager, f *forest.Forest, opts Options) error {
 		Manager:         mgr,
 		Forest:          f,
 		RefreshDuration: opts.HNCCfgRefresh,
+		MetadataOnly:    opts.CacheObjectMetadataOnly,
 	}
 
 	// Create the HC reconciler with a pointer to the Anchor reconciler.
@@ -75,7 +93,28 @@ func CreateReconcilers(mgr ctrl.Manager, f *forest.Forest, opts Options) error {
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("hierarchyconfig").WithName("reconcile"),
 		Forest: f,
+		// This field will be shown as source.component=hnc.x-k8s.io in events.
+		EventRecorder: mgr.GetEventRecorderFor(api.MetaGroup),
+		HistoryLimit:  opts.HierarchyHistoryLimit,
+	}
+
+	// Create the HierarchicalObjectGenerator reconciler.
+	gr := &generator.Reconciler{
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("generator").WithName("reconcile"),
+		Forest:        f,
+		APIReader:     mgr.GetAPIReader(),
+		EventRecorder: mgr.GetEventRecorderFor(api.MetaGroup),
+	}
+	f.AddListener(gr)
+
+	// Create the NetworkPolicy reconciler, which implements network isolation.
+	npr := &netpol.Reconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("netpol").WithName("reconcile"),
+		Forest: f,
 	}
+	f.AddListener(npr)
 
 	if opts.HRQ {
 		// Create resource quota reconciler
@@ -120,6 +159,12 @@ func CreateReconcilers(mgr ctrl.Manager, f *forest.Forest, opts Options) error {
 	if err := hcr.SetupWithManager(mgr, opts.MaxReconciles); err != nil {
 		return fmt.Errorf("cannot create Hierarchy reconciler: %s", err.Error())
 	}
+	if err := gr.SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("cannot create HierarchicalObjectGenerator reconciler: %s", err.Error())
+	}
+	if err := npr.SetupWithManager(mgr); err != nil {
+		return fmt.Errorf("cannot create NetworkPolicy reconciler: %s", err.Error())
+	}
 
 	return nil
 }
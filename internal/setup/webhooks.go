@@ -11,6 +11,7 @@ import (
 	"sigs.k8s.io/hierarchical-namespaces/internal/anchor"
 	"sigs.k8s.io/hierarchical-namespaces/internal/config"
 	"sigs.k8s.io/hierarchical-namespaces/internal/forest"
+	"sigs.k8s.io/hierarchical-namespaces/internal/generator"
 	"sigs.k8s.io/hierarchical-namespaces/internal/hierarchyconfig"
 	"sigs.k8s.io/hierarchical-namespaces/internal/hncconfig"
 	"sigs.k8s.io/hierarchical-namespaces/internal/hrq"
@@ -81,9 +82,15 @@ func ManageCerts(mgr ctrl.Manager, setupFinished chan struct{}, restartOnSecretR
 // createWebhooks creates all mutators and validators.
 func createWebhooks(mgr ctrl.Manager, f *forest.Forest, opts Options) {
 	// Create webhook for Hierarchy
-	mgr.GetWebhookServer().Register(hierarchyconfig.ServingPath, &webhook.Admission{Handler: &hierarchyconfig.Validator{
+	hcv := &hierarchyconfig.Validator{
 		Log:    ctrl.Log.WithName("hierarchyconfig").WithName("validate"),
 		Forest: f,
+	}
+	mgr.GetWebhookServer().Register(hierarchyconfig.ServingPath, &webhook.Admission{Handler: hcv})
+
+	// Create mutator for Hierarchy, to record who changed the parent of a namespace.
+	mgr.GetWebhookServer().Register(hierarchyconfig.MutatorServingPath, &webhook.Admission{Handler: &hierarchyconfig.Mutator{
+		Log: ctrl.Log.WithName("hierarchyconfig").WithName("mutate"),
 	}})
 
 	// Create webhooks for managed objects
@@ -102,17 +109,27 @@ func createWebhooks(mgr ctrl.Manager, f *forest.Forest, opts Options) {
 	mgr.GetWebhookServer().Register(anchor.ServingPath, &webhook.Admission{Handler: &anchor.Validator{
 		Log:    ctrl.Log.WithName("anchor").WithName("validate"),
 		Forest: f,
+		Mover:  hcv,
+		Reader: mgr.GetAPIReader(),
 	}})
 
 	// Create webhook for the namespaces (core type).
 	mgr.GetWebhookServer().Register(ns.ServingPath, &webhook.Admission{Handler: &ns.Validator{
 		Log:    ctrl.Log.WithName("namespace").WithName("validate"),
 		Forest: f,
+		Reader: mgr.GetAPIReader(),
 	}})
 
-	// Create mutator for namespace `included-namespace` label.
+	// Create mutator for namespace `included-namespace` label and default parents.
 	mgr.GetWebhookServer().Register(ns.MutatorServingPath, &webhook.Admission{Handler: &ns.Mutator{
-		Log: ctrl.Log.WithName("namespace").WithName("mutate"),
+		Log:    ctrl.Log.WithName("namespace").WithName("mutate"),
+		Forest: f,
+	}})
+
+	// Create webhook for the HierarchicalObjectGenerators.
+	mgr.GetWebhookServer().Register(generator.ServingPath, &webhook.Admission{Handler: &generator.Validator{
+		Log:    ctrl.Log.WithName("generator").WithName("validate"),
+		Forest: f,
 	}})
 
 	if opts.HRQ {
@@ -32,6 +32,9 @@ var (
 	objectWritesTotal             = ocstats.Int64("object_writes_total", "The number of object writes happened during object reconciliations", "writes")
 	namespaceConditions           = ocstats.Int64("namespace_conditions", "The number of namespaces with conditions", "conditions")
 	objectOverwritesTotal         = ocstats.Int64("object_overwrites_total", "The number of overwritten objects", "overwrites")
+	objectImmutableUpdatesTotal   = ocstats.Int64("object_immutable_updates_total", "The number of object updates that failed because they would change immutable fields", "updates")
+	objectRecreatesTotal          = ocstats.Int64("object_recreates_total", "The number of objects that were deleted and recreated to change immutable fields", "recreates")
+	objectDriftsTotal             = ocstats.Int64("object_drifts_total", "The number of objects found to differ from what would be propagated in Audit mode", "drifts")
 )
 
 // Create Tags. Tags are used to group and filter collected metrics later on.
@@ -116,6 +119,30 @@ var (
 		Aggregation: ocview.LastValue(),
 		TagKeys:     []tag.Key{KeyGroupKind},
 	}
+
+	objectImmutableUpdatesTotalView = &ocview.View{
+		Name:        "hnc/reconcilers/object/immutable_updates_total",
+		Measure:     objectImmutableUpdatesTotal,
+		Description: "The number of object updates that failed because they would change immutable fields",
+		Aggregation: ocview.LastValue(),
+		TagKeys:     []tag.Key{KeyGroupKind},
+	}
+
+	objectRecreatesTotalView = &ocview.View{
+		Name:        "hnc/reconcilers/object/recreates_total",
+		Measure:     objectRecreatesTotal,
+		Description: "The number of objects that were deleted and recreated to change immutable fields",
+		Aggregation: ocview.LastValue(),
+		TagKeys:     []tag.Key{KeyGroupKind},
+	}
+
+	objectDriftsTotalView = &ocview.View{
+		Name:        "hnc/reconcilers/object/drifts_total",
+		Measure:     objectDriftsTotal,
+		Description: "The number of objects found to differ from what would be propagated in Audit mode",
+		Aggregation: ocview.LastValue(),
+		TagKeys:     []tag.Key{KeyGroupKind},
+	}
 )
 
 // periodicPeak contains periodic peaks for concurrent reconciliations.
@@ -143,6 +170,9 @@ func startRecordingMetrics() {
 		objectWritesView,
 		namespaceConditionsView,
 		objectOverwritesTotalView,
+		objectImmutableUpdatesTotalView,
+		objectRecreatesTotalView,
+		objectDriftsTotalView,
 	); err != nil {
 		log.Error(err, "Failed to register the views")
 	}
@@ -11,10 +11,13 @@ import (
 )
 
 type object struct {
-	totalReconciles counter
-	curReconciles   counter
-	apiWrites       counter
-	totalOverwrites counter
+	totalReconciles  counter
+	curReconciles    counter
+	apiWrites        counter
+	totalOverwrites  counter
+	immutableUpdates counter
+	recreates        counter
+	drifts           counter
 }
 
 type objects map[schema.GroupKind]*object
@@ -115,6 +118,32 @@ func OverwriteObject(gvk schema.GroupVersionKind) {
 	recordObjectMetric(stats.objects[gk].totalOverwrites, objectOverwritesTotal, gk)
 }
 
+// ImmutableObjectUpdate updates the object stats by GK when an update to the object fails because it
+// would change an immutable field.
+func ImmutableObjectUpdate(gvk schema.GroupVersionKind) {
+	gk := gvk.GroupKind()
+	stats.objects[gk].immutableUpdates.incr()
+
+	recordObjectMetric(stats.objects[gk].immutableUpdates, objectImmutableUpdatesTotal, gk)
+}
+
+// RecreateObject updates the object stats by GK when the object is deleted and recreated.
+func RecreateObject(gvk schema.GroupVersionKind) {
+	gk := gvk.GroupKind()
+	stats.objects[gk].recreates.incr()
+
+	recordObjectMetric(stats.objects[gk].recreates, objectRecreatesTotal, gk)
+}
+
+// DriftObject updates the object stats by GK when an object is found to differ from what HNC would
+// propagate, in the Audit synchronization mode.
+func DriftObject(gvk schema.GroupVersionKind) {
+	gk := gvk.GroupKind()
+	stats.objects[gk].drifts.incr()
+
+	recordObjectMetric(stats.objects[gk].drifts, objectDriftsTotal, gk)
+}
+
 func init() {
 	objects := make(map[schema.GroupKind]*object)
 	peak = periodicPeak{
@@ -0,0 +1,54 @@
+package webhooks
+
+import (
+	"context"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
+)
+
+// IsAdmin returns true if the user is an admin of the namespace, which HNC defines as being able to
+// update its HierarchyConfiguration.
+func IsAdmin(ctx context.Context, c client.Client, ui *authnv1.UserInfo, nnm string) (bool, error) {
+	gvr := schema.GroupVersionResource{Group: api.MetaGroup, Version: "*", Resource: api.HierarchyConfigurations}
+	return CanAccess(ctx, c, ui, "update", gvr, nnm)
+}
+
+// CanAccess returns true if the user can perform the verb on the resource in the namespace,
+// according to a SubjectAccessReview.
+func CanAccess(ctx context.Context, c client.Client, ui *authnv1.UserInfo, verb string, gvr schema.GroupVersionResource, nnm string) (bool, error) {
+	// Convert the Extra type
+	authzExtra := map[string]authzv1.ExtraValue{}
+	for k, v := range ui.Extra {
+		authzExtra[k] = (authzv1.ExtraValue)(v)
+	}
+
+	// Construct the request
+	sar := &authzv1.SubjectAccessReview{
+		Spec: authzv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authzv1.ResourceAttributes{
+				Namespace: nnm,
+				Verb:      verb,
+				Group:     gvr.Group,
+				Version:   gvr.Version,
+				Resource:  gvr.Resource,
+			},
+			User:   ui.Username,
+			Groups: ui.Groups,
+			UID:    ui.UID,
+			Extra:  authzExtra,
+		},
+	}
+
+	// Call the server
+	if err := c.Create(ctx, sar); err != nil {
+		return false, err
+	}
+
+	// Extract the interesting result
+	return sar.Status.Allowed, nil
+}
@@ -0,0 +1,125 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/hierarchical-namespaces/internal/forest"
+)
+
+// workloadGVKs are the kinds of workloads counted by DeletionImpact.CountWorkloads.
+var workloadGVKs = []schema.GroupVersionKind{
+	{Version: "v1", Kind: "Pod"},
+	{Group: "apps", Version: "v1", Kind: "Deployment"},
+	{Group: "apps", Version: "v1", Kind: "StatefulSet"},
+	{Group: "apps", Version: "v1", Kind: "DaemonSet"},
+	{Group: "batch", Version: "v1", Kind: "Job"},
+	{Group: "batch", Version: "v1", Kind: "CronJob"},
+}
+
+// DeletionImpact describes everything that would be deleted along with a namespace. The namespace
+// and anchor validators report it as warnings when a deletion is requested in dry-run mode, so that
+// users can see what would actually happen before they set allowCascadingDeletion.
+type DeletionImpact struct {
+	// Namespaces is the namespace being deleted, followed by all its descendants that would be
+	// deleted along with it.
+	Namespaces []string
+
+	// Objects counts the source objects of each kind managed by HNC in these namespaces.
+	Objects map[string]int
+
+	// Workloads counts the workloads of each kind in these namespaces. It's nil until CountWorkloads
+	// is called.
+	Workloads map[string]int
+}
+
+// GetDeletionImpact returns the namespaces and HNC-managed objects that would be deleted along with
+// the given namespace, using only the forest. The forest must be locked.
+func GetDeletionImpact(f *forest.Forest, nm string) *DeletionImpact {
+	d := &DeletionImpact{
+		Namespaces: append([]string{nm}, f.Get(nm).SubnamespaceDescendantNames()...),
+		Objects:    map[string]int{},
+	}
+	for _, ts := range f.GetTypeSyncers() {
+		gvk := ts.GetGVK()
+		for _, dnm := range d.Namespaces {
+			if n := f.Get(dnm).GetNumSourceObjects(gvk); n > 0 {
+				d.Objects[gvk.Kind] += n
+			}
+		}
+	}
+	return d
+}
+
+// CountWorkloads counts the workloads in the namespaces by listing them from the apiserver. It only
+// reads their metadata, but should still only be called for dry-run requests since it makes a call
+// per kind per namespace.
+func (d *DeletionImpact) CountWorkloads(ctx context.Context, rdr client.Reader) error {
+	d.Workloads = map[string]int{}
+	for _, gvk := range workloadGVKs {
+		for _, nm := range d.Namespaces {
+			l := &metav1.PartialObjectMetadataList{}
+			l.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+			if err := rdr.List(ctx, l, client.InNamespace(nm)); err != nil {
+				return fmt.Errorf("while counting %s objects in %q: %w", gvk.Kind, nm, err)
+			}
+			if len(l.Items) > 0 {
+				d.Workloads[gvk.Kind] += len(l.Items)
+			}
+		}
+	}
+	return nil
+}
+
+// Warnings summarizes the impact in a form that's suitable for admission warnings, which kubectl
+// displays to the user.
+func (d *DeletionImpact) Warnings() []string {
+	w := []string{fmt.Sprintf("dry run: this would delete %d namespace(s): %s", len(d.Namespaces), strings.Join(d.Namespaces, ", "))}
+	if d.Workloads != nil {
+		w = append(w, "dry run: workloads that would be deleted: "+formatCounts(d.Workloads))
+	}
+	w = append(w, "dry run: objects of types managed by HNC that would be deleted (not including propagated copies): "+formatCounts(d.Objects))
+	return w
+}
+
+// DryRunDeletion returns warnings that describe the impact of deleting the given namespace. If rdr
+// is nil (e.g. in unit tests), workloads aren't counted.
+func DryRunDeletion(ctx context.Context, log logr.Logger, f *forest.Forest, rdr client.Reader, nm string) []string {
+	f.Lock()
+	d := GetDeletionImpact(f, nm)
+	f.Unlock()
+
+	// Don't hold the lock while talking to the apiserver.
+	if rdr != nil {
+		if err := d.CountWorkloads(ctx, rdr); err != nil {
+			log.Error(err, "Couldn't count workloads for dry run")
+			d.Workloads = nil
+		}
+	}
+	return d.Warnings()
+}
+
+// formatCounts returns a string like "Deployment: 1, Pod: 3", sorted by kind, or "none" if there are
+// no counts.
+func formatCounts(counts map[string]int) string {
+	if len(counts) == 0 {
+		return "none"
+	}
+	kinds := []string{}
+	for k := range counts {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	strs := []string{}
+	for _, k := range kinds {
+		strs = append(strs, fmt.Sprintf("%s: %d", k, counts[k]))
+	}
+	return strings.Join(strs, ", ")
+}
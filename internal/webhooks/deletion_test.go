@@ -0,0 +1,54 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/hierarchical-namespaces/internal/foresttest"
+)
+
+func TestDeletionImpact(t *testing.T) {
+	// a <- B <- C, a <- d (full) <- E
+	f := foresttest.Create("-ABaD")
+	rdr := fake.NewClientBuilder().WithObjects(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "b", Name: "p1"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "c", Name: "p2"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "e", Name: "p3"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "c", Name: "d1"}},
+	).Build()
+
+	tests := []struct {
+		name      string
+		nm        string
+		wantNames []string
+		wantWork  map[string]int
+	}{
+		{name: "leaf", nm: "c", wantNames: []string{"c"}, wantWork: map[string]int{"Pod": 1, "Deployment": 1}},
+		{name: "subtree", nm: "b", wantNames: []string{"b", "c"}, wantWork: map[string]int{"Pod": 2, "Deployment": 1}},
+		{name: "skips full namespaces", nm: "a", wantNames: []string{"a", "b", "c"}, wantWork: map[string]int{"Pod": 2, "Deployment": 1}},
+		{name: "full namespace", nm: "d", wantNames: []string{"d", "e"}, wantWork: map[string]int{"Pod": 1}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			d := GetDeletionImpact(f, tc.nm)
+			g.Expect(d.Namespaces).Should(Equal(tc.wantNames))
+			g.Expect(d.Workloads).Should(BeNil())
+			g.Expect(d.CountWorkloads(context.Background(), rdr)).Should(Succeed())
+			g.Expect(d.Workloads).Should(Equal(tc.wantWork))
+			g.Expect(d.Warnings()).Should(HaveLen(3))
+		})
+	}
+}
+
+func TestFormatCounts(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(formatCounts(nil)).Should(Equal("none"))
+	g.Expect(formatCounts(map[string]int{"Pod": 3, "Deployment": 1})).Should(Equal("Deployment: 1, Pod: 3"))
+}
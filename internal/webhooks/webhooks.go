@@ -11,6 +11,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	api "sigs.k8s.io/hierarchical-namespaces/api/v1alpha2"
 	"sigs.k8s.io/hierarchical-namespaces/internal/config"
 )
 
@@ -37,6 +38,30 @@ func IsHNCServiceAccount(user *authnv1.UserInfo) bool {
 	return false
 }
 
+// CanCreateRoot returns true if the user is allowed to create a root namespace, or make an existing
+// namespace into a root, according to the given root policy, which may be nil.
+func CanCreateRoot(p *api.RootPolicy, user *authnv1.UserInfo) bool {
+	if p == nil || !p.RequireParent || IsHNCServiceAccount(user) {
+		return true
+	}
+	if user == nil {
+		return false
+	}
+	for _, u := range p.AllowedUsers {
+		if u == user.Username {
+			return true
+		}
+	}
+	for _, ag := range p.AllowedGroups {
+		for _, g := range user.Groups {
+			if g == ag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Allow is a replacement for controller-runtime's admission.Allowed() that allows you to set the
 // message (human-readable) as opposed to the reason (machine-readable).
 func Allow(msg string) admission.Response {